@@ -1,58 +1,214 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"log"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"k8s-deployment-scaler/internal/handlers"
+	"k8s-deployment-scaler/internal/audit"
+	"k8s-deployment-scaler/internal/cluster"
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/cost"
+	"k8s-deployment-scaler/internal/health"
 	"k8s-deployment-scaler/internal/kubernetes"
+	"k8s-deployment-scaler/internal/logging"
+	"k8s-deployment-scaler/internal/metrics"
+	"k8s-deployment-scaler/internal/policy"
 	"k8s-deployment-scaler/internal/server"
+	"k8s-deployment-scaler/internal/tracing"
 
-	"k8s.io/client-go/informers"
+	"google.golang.org/grpc"
+
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
 )
 
 func main() {
+	// Load the configuration from the config file, SCALER_* variables and flags
+	cfg, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fatal("Error loading configuration", err)
+	}
+
+	// Set up structured JSON logging, including client-go's klog output
+	logLevel := new(slog.LevelVar)
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logLevel.Set(level)
+	logger := logging.New(os.Stdout, logLevel)
+	slog.SetDefault(logger)
+	klog.SetSlogLogger(logger)
+
+	// Set up tracing
+	shutdownTracing, err := setupTracing(cfg.Tracing)
+	if err != nil {
+		fatal("Error setting up tracing", err)
+	}
+
 	// Initialize Kubernetes client
 	clientset, err := kubernetes.NewClientset()
 	if err != nil {
-		log.Fatalf("Error creating Kubernetes client: %v", err)
+		fatal("Error creating Kubernetes client", err)
 	}
 
-	handlers.SetClientset(clientset)
+	serverOpts := []server.Option{server.WithLogLevel(logLevel)}
 
-	// Set up deployment informer and lister
-	factory := informers.NewSharedInformerFactory(clientset, time.Minute*10)
-	deploymentInformer := factory.Apps().V1().Deployments()
-	deploymentLister := deploymentInformer.Lister()
+	// Load per-namespace change-management requirements, if configured
+	if cfg.ChangePolicyFile != "" {
+		policies, err := policy.LoadChangePolicies(cfg.ChangePolicyFile)
+		if err != nil {
+			fatal("Error loading change policies", err)
+		}
+		serverOpts = append(serverOpts, server.WithChangePolicies(policies))
+	}
+
+	// Load the price table for cost estimates, if configured
+	if cfg.PriceTableFile != "" {
+		prices, err := cost.LoadPriceTable(cfg.PriceTableFile)
+		if err != nil {
+			fatal("Error loading price table", err)
+		}
+		serverOpts = append(serverOpts, server.WithPriceTable(prices))
+	}
+
+	// Emit Kubernetes Events for scale actions
+	recorder, stopRecorder := kubernetes.NewEventRecorder(clientset)
+	defer stopRecorder()
+	serverOpts = append(serverOpts, server.WithEventRecorder(recorder))
+
+	// Set up the deployment informer, with one informer per namespace when
+	// limited to some
+	deploymentInformer := kubernetes.NewDeploymentInformer(clientset, cfg.Informer.ResyncPeriod.Duration, cfg.Namespaces)
 	deploymentsSynced := deploymentInformer.Informer().HasSynced
 
+	// Watch the HorizontalPodAutoscalers and PodDisruptionBudgets in the same
+	// namespaces, so scales they would undo or forbid are detected
+	hpaInformer := deploymentInformer.HorizontalPodAutoscalers()
+	pdbInformer := deploymentInformer.PodDisruptionBudgets()
+	serverOpts = append(serverOpts, server.WithHPAInformer(hpaInformer), server.WithPDBInformer(pdbInformer))
+	informersSynced := []cache.InformerSynced{deploymentsSynced, hpaInformer.Informer().HasSynced, pdbInformer.Informer().HasSynced}
+
+	// Watch ResourceQuotas too, unless scale-ups aren't checked against them
+	var quotaInformer coreinformers.ResourceQuotaInformer
+	if cfg.Scale.QuotaCheck != config.QuotaCheckOff {
+		quotaInformer = deploymentInformer.ResourceQuotas()
+		serverOpts = append(serverOpts, server.WithQuotaInformer(quotaInformer))
+		informersSynced = append(informersSynced, quotaInformer.Informer().HasSynced)
+	}
+
 	// Start all informers
 	stopCh := make(chan struct{})
 	defer close(stopCh)
-	factory.Start(stopCh)
+	deploymentInformer.Start(stopCh)
+
+	// Wait for the deployment, HPA, PDB and quota caches to sync
+	if !cache.WaitForCacheSync(stopCh, informersSynced...) {
+		fatal("Failed to sync informers", nil)
+	}
 
-	// Wait for the deployment cache to sync
-	if !cache.WaitForCacheSync(stopCh, deploymentsSynced) {
-		log.Fatal("Failed to sync deployment informer")
+	// Set up Prometheus metrics
+	scalerMetrics := metrics.New()
+	if err := scalerMetrics.RegisterInformer("deployments", deploymentInformer.Informer()); err != nil {
+		fatal("Error registering informer metrics", err)
+	}
+	if err := scalerMetrics.RegisterInformer("horizontalpodautoscalers", hpaInformer.Informer()); err != nil {
+		fatal("Error registering informer metrics", err)
+	}
+	if err := scalerMetrics.RegisterInformer("poddisruptionbudgets", pdbInformer.Informer()); err != nil {
+		fatal("Error registering informer metrics", err)
+	}
+	if quotaInformer != nil {
+		if err := scalerMetrics.RegisterInformer("resourcequotas", quotaInformer.Informer()); err != nil {
+			fatal("Error registering informer metrics", err)
+		}
+	}
+	serverOpts = append(serverOpts, server.WithMetrics(scalerMetrics))
+
+	// Report not ready only after repeated API server failures
+	serverOpts = append(serverOpts, server.WithReadinessCheck(health.APIServer("kubernetes-api",
+		clientset.Discovery(), cfg.Health.APICheckInterval.Duration, cfg.Health.APICheckTimeout.Duration, cfg.Health.APIFailureThreshold)))
+
+	// Set up the audit trail, if configured
+	auditLogger, err := newAuditLogger(cfg.Audit)
+	if err != nil {
+		fatal("Error setting up audit log", err)
+	}
+	if auditLogger != nil {
+		defer auditLogger.Close()
+		serverOpts = append(serverOpts, server.WithAuditLogger(auditLogger))
 	}
 
 	// Create and configure the server
-	srv, err := server.New(deploymentLister, true)
+	srv, err := server.New(clientset, deploymentInformer, cfg, serverOpts...)
 	if err != nil {
-		log.Fatalf("Failed to create server: %v", err)
+		fatal("Failed to create server", err)
+	}
+
+	// Serve the configured clusters, following changes to the list on reload
+	if err := srv.Clusters.Sync(cfg.Clusters); err != nil {
+		fatal("Error setting up clusters", err)
 	}
+	defer srv.Clusters.Stop()
+	go reloadClusters(srv.Clusters, cfg.File())
 
 	// Start the server
 	go func() {
-		log.Printf("Server starting on %s...\n", srv.Addr)
-		if err := srv.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+		slog.Info("Server starting", "addr", srv.Addr, "tls", cfg.TLS.Enabled)
+		var err error
+		if cfg.TLS.Enabled {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server failed", err)
+		}
+	}()
+
+	// Start the gRPC server on its own port
+	if srv.GRPC != nil {
+		listener, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			fatal("gRPC server failed", err)
+		}
+		go func() {
+			slog.Info("gRPC server starting", "addr", cfg.GRPC.Addr, "tls", cfg.TLS.Enabled)
+			if err := srv.GRPC.Serve(listener); err != nil {
+				fatal("gRPC server failed", err)
+			}
+		}()
+	}
+
+	// Start the metrics and probe server, without mTLS unless configured
+	var metricsTLS *tls.Config
+	if cfg.Metrics.TLS {
+		metricsTLS = srv.TLSConfig
+	}
+	metricsSrv := server.NewMetricsServer(cfg.Metrics.Addr, scalerMetrics, metricsTLS, cfg.Timeouts.TLSHandshake.Duration, srv)
+	go func() {
+		slog.Info("Metrics server starting", "addr", metricsSrv.Addr, "tls", metricsTLS != nil)
+		var err error
+		if metricsTLS != nil {
+			err = metricsSrv.ListenAndServeTLS("", "")
+		} else {
+			err = metricsSrv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Metrics server failed", err)
 		}
 	}()
 
@@ -62,11 +218,146 @@ func main() {
 
 	<-stop
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown.Duration)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server shutdown failed: %v", err)
+		fatal("Server shutdown failed", err)
+	}
+	if srv.GRPC != nil {
+		stopGRPC(ctx, srv.GRPC)
+	}
+	if err := metricsSrv.Shutdown(ctx); err != nil {
+		fatal("Metrics server shutdown failed", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+	slog.Info("Server gracefully stopped")
+}
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 10 * time.Second
+
+// reloadClusters reloads the configuration on SIGHUP, or when the config file's
+// contents change as when its ConfigMap is updated, and applies its cluster
+// list. Other settings still take effect only on restart.
+func reloadClusters(registry *cluster.Registry, file string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var poll <-chan time.Time
+	var last []byte
+	if file != "" {
+		last, _ = os.ReadFile(file)
+		poll = time.NewTicker(configPollInterval).C
+	}
+
+	for {
+		select {
+		case <-hup:
+		case <-poll:
+			data, err := os.ReadFile(file)
+			if err != nil || bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+		}
+
+		cfg, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+		if err != nil {
+			slog.Error("Error reloading configuration; keeping the current clusters", "error", err)
+			continue
+		}
+		if err := registry.Sync(cfg.Clusters); err != nil {
+			slog.Error("Error applying the cluster configuration", "error", err)
+			continue
+		}
+		slog.Info("Cluster configuration reloaded", "clusters", len(cfg.Clusters))
+	}
+}
+
+// stopGRPC lets in-flight calls finish until ctx expires, then closes the rest,
+// such as watches that would otherwise never end
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// newAuditLogger builds the audit logger from the configured file and webhook outputs.
+// It returns nil when neither output is configured.
+func newAuditLogger(cfg config.AuditConfig) (*audit.Logger, error) {
+	var sinks []audit.Sink
+
+	if cfg.File != "" {
+		fileSink, err := audit.NewFileSink(cfg.File, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, fileSink)
+	}
+
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, audit.NewWebhookSink(audit.WebhookConfig{
+			URL:           cfg.WebhookURL,
+			BatchSize:     cfg.WebhookBatchSize,
+			FlushInterval: cfg.WebhookFlushInterval.Duration,
+			BufferSize:    cfg.WebhookBufferSize,
+			MaxRetries:    cfg.WebhookMaxRetries,
+			RetryBackoff:  cfg.WebhookRetryBackoff.Duration,
+		}))
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return audit.New(sinks...), nil
+}
+
+// setupTracing installs the configured exporter. The stdout exporter writes to
+// the trace file when one is set, for local testing.
+func setupTracing(cfg config.TracingConfig) (func(context.Context) error, error) {
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if cfg.File != "" && cfg.Exporter == tracing.ExporterStdout {
+		var err error
+		file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("opening trace file: %v", err)
+		}
+		out = file
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), cfg.Exporter, out)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return shutdown, nil
+	}
+	return func(ctx context.Context) error {
+		err := shutdown(ctx)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+// fatal logs the error and exits
+func fatal(msg string, err error) {
+	if err != nil {
+		slog.Error(msg, "error", err)
+	} else {
+		slog.Error(msg)
 	}
-	log.Println("Server gracefully stopped")
+	os.Exit(1)
 }
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+	"k8s-deployment-scaler/pkg/client"
+)
+
+// maxWait is the longest wait the server allows a long-poll request
+const maxWait = 5 * time.Minute
+
+var getCommand = command{
+	args:    "NAMESPACE/NAME",
+	summary: "Print a deployment's replica count",
+	flags: func(fs *flag.FlagSet) func(context.Context, *env, []string) error {
+		return func(ctx context.Context, env *env, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected NAMESPACE/NAME")
+			}
+			namespace, name, err := parseDeployment(args[0])
+			if err != nil {
+				return err
+			}
+			scale, err := env.getScale(ctx, namespace, name)
+			if err != nil {
+				return err
+			}
+			return env.printer.print(scale)
+		}
+	},
+}
+
+var setCommand = command{
+	args:    "NAMESPACE/NAME REPLICAS",
+	summary: "Set a deployment's replica count; +N and -N scale relative to the current count",
+	flags: func(fs *flag.FlagSet) func(context.Context, *env, []string) error {
+		reason := fs.String("reason", "", "Why the deployment is being scaled")
+		ticket := fs.String("ticket", "", "Change ticket authorising the scale")
+		force := fs.Bool("force", false, "Scale during the cooldown or past a PodDisruptionBudget; only the server's force identities may")
+		pinHPA := fs.Bool("pin-hpa", false, "Pin the bounds of the deployment's HorizontalPodAutoscaler to REPLICAS")
+
+		return func(ctx context.Context, env *env, args []string) error {
+			if len(args) != 2 {
+				return usageErrorf("expected NAMESPACE/NAME and REPLICAS")
+			}
+			namespace, name, err := parseDeployment(args[0])
+			if err != nil {
+				return err
+			}
+			replicas, relative, err := parseReplicas(args[1])
+			if err != nil {
+				return err
+			}
+
+			// Relative counts apply to the count when the command runs; a concurrent
+			// scale between the read and the write is overwritten
+			if relative {
+				current, err := env.getScale(ctx, namespace, name)
+				if err != nil {
+					return err
+				}
+				replicas += current.Replicas
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, env.timeout)
+			defer cancel()
+			scale, err := env.client.SetScale(ctx, namespace, name, apiv1.ScaleRequest{
+				Replicas: replicas,
+				Reason:   *reason,
+				Ticket:   *ticket,
+			}, client.SetScaleOptions{Force: *force, PinHPA: *pinHPA})
+			if err != nil {
+				return err
+			}
+			return env.printer.print(scale)
+		}
+	},
+}
+
+var restoreHPACommand = command{
+	args:    "NAMESPACE/NAME",
+	summary: "Restore the bounds of a deployment's HorizontalPodAutoscaler pinned by set --pin-hpa",
+	flags: func(fs *flag.FlagSet) func(context.Context, *env, []string) error {
+		return func(ctx context.Context, env *env, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected NAMESPACE/NAME")
+			}
+			namespace, name, err := parseDeployment(args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, env.timeout)
+			defer cancel()
+			hpa, err := env.client.RestoreHPA(ctx, namespace, name)
+			if err != nil {
+				return err
+			}
+			return env.printer.print(hpa)
+		}
+	},
+}
+
+var listCommand = command{
+	args:    "[NAMESPACE]",
+	summary: "List deployments in a namespace, or in all namespaces",
+	flags: func(fs *flag.FlagSet) func(context.Context, *env, []string) error {
+		return func(ctx context.Context, env *env, args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("expected at most one NAMESPACE")
+			}
+			namespace := ""
+			if len(args) == 1 {
+				namespace = args[0]
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, env.timeout)
+			defer cancel()
+			list, err := env.client.ListDeployments(ctx, namespace)
+			if err != nil {
+				return err
+			}
+			return env.printer.print(list)
+		}
+	},
+}
+
+var watchCommand = command{
+	args:    "NAMESPACE/NAME",
+	summary: "Print a deployment's replica count and then every change, until interrupted",
+	flags: func(fs *flag.FlagSet) func(context.Context, *env, []string) error {
+		until := fs.Int("until", -1, "Exit once the deployment has this many replicas")
+
+		return func(ctx context.Context, env *env, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected NAMESPACE/NAME")
+			}
+			namespace, name, err := parseDeployment(args[0])
+			if err != nil {
+				return err
+			}
+
+			scale, err := env.getScale(ctx, namespace, name)
+			if err != nil {
+				return err
+			}
+			if scale.ResourceVersion == "" {
+				return fmt.Errorf("the server did not report a resourceVersion to watch from")
+			}
+
+			// Each long poll waits up to --timeout, within the server's limit
+			wait := min(env.timeout, maxWait)
+			for {
+				if err := env.printer.print(scale); err != nil {
+					return err
+				}
+				if int(scale.Replicas) == *until {
+					return nil
+				}
+
+				for {
+					next, err := env.waitForChange(ctx, scale, wait)
+					if err != nil {
+						if ctx.Err() != nil {
+							// Interrupted
+							return nil
+						}
+						return err
+					}
+					if !*next.TimedOut {
+						next.TimedOut = nil
+						scale = next
+						break
+					}
+				}
+			}
+		}
+	},
+}
+
+var healthCommand = command{
+	summary: "Check that the server can reach the Kubernetes API",
+	flags: func(fs *flag.FlagSet) func(context.Context, *env, []string) error {
+		return func(ctx context.Context, env *env, args []string) error {
+			if len(args) != 0 {
+				return usageErrorf("health takes no arguments")
+			}
+			ctx, cancel := context.WithTimeout(ctx, env.timeout)
+			defer cancel()
+			status, err := env.client.Health(ctx)
+			if err != nil {
+				return err
+			}
+			return env.printer.print(status)
+		}
+	},
+}
+
+// getScale reads a deployment's current scale
+func (e *env) getScale(ctx context.Context, namespace, name string) (*apiv1.Scale, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	return e.client.GetScale(ctx, namespace, name)
+}
+
+// waitForChange long-polls until the deployment's resourceVersion differs from
+// scale's or wait expires, which the response's timedOut reports
+func (e *env) waitForChange(ctx context.Context, scale *apiv1.Scale, wait time.Duration) (*apiv1.Scale, error) {
+	// Allow the request itself the usual timeout on top of the wait
+	ctx, cancel := context.WithTimeout(ctx, wait+e.timeout)
+	defer cancel()
+	next, err := e.client.WaitForScale(ctx, scale.Namespace, scale.Name, client.WaitOptions{
+		ResourceVersion: scale.ResourceVersion,
+		Timeout:         wait,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if next.TimedOut == nil {
+		return nil, fmt.Errorf("the server does not support waiting for changes")
+	}
+	return next, nil
+}
+
+// parseDeployment splits a NAMESPACE/NAME argument
+func parseDeployment(arg string) (string, string, error) {
+	namespace, name, ok := strings.Cut(arg, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", usageErrorf("expected NAMESPACE/NAME, got %q", arg)
+	}
+	return namespace, name, nil
+}
+
+// parseReplicas parses an absolute replica count, or a relative one with a sign
+func parseReplicas(arg string) (int32, bool, error) {
+	count, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		return 0, false, usageErrorf("REPLICAS must be a number, or +N or -N to scale relative to the current count; got %q", arg)
+	}
+	relative := strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-")
+	return int32(count), relative, nil
+}
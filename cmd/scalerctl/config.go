@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"k8s-deployment-scaler/pkg/client"
+
+	"sigs.k8s.io/yaml"
+)
+
+// configEnv names the environment variable pointing at the client config
+const configEnv = "SCALERCONFIG"
+
+// clientConfig lists the scaler servers the client can talk to, in the manner
+// of a kubeconfig. Relative certificate paths are relative to the config file.
+type clientConfig struct {
+	CurrentContext string          `json:"currentContext"`
+	Contexts       []clientContext `json:"contexts"`
+}
+
+// clientContext is one server and the client certificate used to reach it
+type clientContext struct {
+	Name     string `json:"name"`
+	Server   string `json:"server"`
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	CAFile   string `json:"caFile"`
+}
+
+// configPath returns the config file to read: the flag, then $SCALERCONFIG,
+// then ~/.scaler/config
+func configPath(flagValue string, getenv func(string) string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if path := getenv(configEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locating the client config: %v; set --config or %s", err, configEnv)
+	}
+	return filepath.Join(home, ".scaler", "config"), nil
+}
+
+// loadConfig reads the client config, rejecting unknown fields
+func loadConfig(path string) (*clientConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading client config: %v", err)
+	}
+	var cfg clientConfig
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing client config %s: %v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range cfg.Contexts {
+		c := &cfg.Contexts[i]
+		for _, file := range []*string{&c.CertFile, &c.KeyFile, &c.CAFile} {
+			if *file != "" && !filepath.IsAbs(*file) {
+				*file = filepath.Join(dir, *file)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// context returns the named context, or the current one when name is empty.
+// A config with a single context needs no currentContext.
+func (c *clientConfig) context(name string) (*clientContext, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		if len(c.Contexts) == 1 {
+			return &c.Contexts[0], nil
+		}
+		return nil, fmt.Errorf("no context selected; set currentContext or pass --context")
+	}
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("context %q not found in the client config", name)
+}
+
+// tlsConfig loads the context's client certificate and the CA that signed the
+// server's. Plain http servers need neither.
+func (c *clientContext) tlsConfig() (*tls.Config, error) {
+	server, err := url.Parse(c.Server)
+	if err != nil || (server.Scheme != "https" && server.Scheme != "http") || server.Host == "" {
+		return nil, fmt.Errorf("context %q: server must be an http or https URL, got %q", c.Name, c.Server)
+	}
+	if server.Scheme == "http" {
+		return nil, nil
+	}
+
+	config, err := client.TLSConfigFromFiles(c.CertFile, c.KeyFile, c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("context %q: %v", c.Name, err)
+	}
+	return config, nil
+}
@@ -0,0 +1,255 @@
+// Command scalerctl reads and sets deployment replica counts through the
+// k8s-deployment-scaler API, using servers and client certificates from a
+// kubeconfig-like client config.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+	"k8s-deployment-scaler/pkg/client"
+)
+
+// Exit codes
+const (
+	exitOK = iota
+	// exitError covers failures without a more specific code
+	exitError
+	// exitUsage means the command line or client config is invalid
+	exitUsage
+	// exitNotFound means the deployment doesn't exist
+	exitNotFound
+	// exitRejected means the server refused the request: validation, change
+	// policy, conflict, permission, rate limit or cooldown
+	exitRejected
+	// exitUnavailable means the server or the Kubernetes API behind it couldn't be reached
+	exitUnavailable
+)
+
+// usageError is a mistake on the command line or in the client config
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// options are the flags shared by every command
+type options struct {
+	config  string
+	context string
+	output  string
+	timeout time.Duration
+}
+
+// bindFlags registers the shared flags, so they may be given before or after the command
+func (o *options) bindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.config, "config", o.config, "Client config file (default $"+configEnv+" or ~/.scaler/config)")
+	fs.StringVar(&o.context, "context", o.context, "Context to use instead of the config's currentContext")
+	fs.StringVar(&o.output, "o", o.output, "Output format: table, json or yaml")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "Timeout for each request")
+}
+
+// command is a scalerctl subcommand
+type command struct {
+	args    string
+	summary string
+	// flags registers the command's own flags and returns the function running it
+	flags func(fs *flag.FlagSet) func(ctx context.Context, env *env, args []string) error
+}
+
+// env is what a command runs with
+type env struct {
+	client  *client.Client
+	printer *printer
+	timeout time.Duration
+}
+
+var commands = map[string]command{
+	"get":         getCommand,
+	"set":         setCommand,
+	"list":        listCommand,
+	"watch":       watchCommand,
+	"health":      healthCommand,
+	"restore-hpa": restoreHPACommand,
+}
+
+// commandOrder lists the commands in the usage message
+var commandOrder = []string{"get", "set", "restore-hpa", "list", "watch", "health"}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line and returns the process exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	opts := &options{output: outputTable, timeout: 30 * time.Second}
+
+	root := flag.NewFlagSet("scalerctl", flag.ContinueOnError)
+	root.SetOutput(stderr)
+	opts.bindFlags(root)
+	root.Usage = func() { usage(stderr, root) }
+	if err := root.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if root.NArg() == 0 {
+		usage(stderr, root)
+		return exitUsage
+	}
+
+	name := root.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "scalerctl: unknown command %q\n", name)
+		usage(stderr, root)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("scalerctl "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts.bindFlags(fs)
+	runCommand := cmd.flags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: scalerctl %s [flags] %s\n\n%s\n\nFlags:\n", name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, root.Args()[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	err = execute(ctx, opts, positional, stdout, getenv, runCommand)
+	if err == nil {
+		return exitOK
+	}
+	fmt.Fprintf(stderr, "scalerctl %s: %v\n", name, err)
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		fs.Usage()
+	}
+	return exitCode(err)
+}
+
+// execute loads the client config and runs the command
+func execute(ctx context.Context, opts *options, args []string, stdout io.Writer, getenv func(string) string, runCommand func(context.Context, *env, []string) error) error {
+	printer, err := newPrinter(stdout, opts.output)
+	if err != nil {
+		return err
+	}
+
+	path, err := configPath(opts.config, getenv)
+	if err != nil {
+		return &usageError{message: err.Error()}
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return &usageError{message: err.Error()}
+	}
+	clientContext, err := cfg.context(opts.context)
+	if err != nil {
+		return &usageError{message: err.Error()}
+	}
+	tlsConfig, err := clientContext.tlsConfig()
+	if err != nil {
+		return &usageError{message: err.Error()}
+	}
+	c, err := client.New(clientContext.Server, tlsConfig)
+	if err != nil {
+		return &usageError{message: err.Error()}
+	}
+
+	return runCommand(ctx, &env{client: c, printer: printer, timeout: opts.timeout}, args)
+}
+
+// parseInterspersed parses flags given before, between or after the positional
+// arguments, which it returns. Negative numbers following a positional argument,
+// as in a -2 relative scale, are positional too.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		// Parsing stopped at a positional argument
+		positional = append(positional, args[0])
+		args = args[1:]
+		for len(args) > 0 && isNegativeNumber(args[0]) {
+			positional = append(positional, args[0])
+			args = args[1:]
+		}
+	}
+}
+
+func isNegativeNumber(arg string) bool {
+	_, err := strconv.Atoi(arg)
+	return err == nil && strings.HasPrefix(arg, "-")
+}
+
+// exitCode maps an error to the process exit code
+func exitCode(err error) int {
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Reason {
+		case apiv1.ReasonNotFound:
+			return exitNotFound
+		case apiv1.ReasonValidationFailed, apiv1.ReasonConflict, apiv1.ReasonForbidden, apiv1.ReasonTooManyRequests:
+			return exitRejected
+		case apiv1.ReasonUpstreamUnavailable:
+			return exitUnavailable
+		}
+		return exitError
+	}
+
+	// The server couldn't be reached at all
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return exitUnavailable
+	}
+	return exitError
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: scalerctl [flags] <command> [flags] [arguments]\n\nCommands:\n")
+	for _, name := range commandOrder {
+		cmd := commands[name]
+		fmt.Fprintf(w, "  %-7s %s\n", name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nExit codes: %d success, %d error, %d usage, %d not found, %d rejected, %d unavailable\n\nFlags:\n",
+		exitOK, exitError, exitUsage, exitNotFound, exitRejected, exitUnavailable)
+	fs.PrintDefaults()
+}
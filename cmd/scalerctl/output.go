@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// printer writes responses in the chosen format. A printer used for several
+// values, as by watch, prints the table header once and separates YAML documents;
+// each table row after the first is aligned on its own, as it's printed when it arrives.
+type printer struct {
+	w       io.Writer
+	format  string
+	printed bool
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return &printer{w: w, format: format}, nil
+	default:
+		return nil, usageErrorf("unknown output format %q; use table, json or yaml", format)
+	}
+}
+
+// print writes a *apiv1.Scale, *apiv1.DeploymentList, *apiv1.HorizontalPodAutoscaler
+// or *apiv1.Status
+func (p *printer) print(v interface{}) error {
+	defer func() { p.printed = true }()
+
+	switch p.format {
+	case outputJSON:
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case outputYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if p.printed {
+			fmt.Fprintln(p.w, "---")
+		}
+		_, err = p.w.Write(data)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 8, 3, ' ', 0)
+	switch v := v.(type) {
+	case *apiv1.Scale:
+		if !p.printed {
+			fmt.Fprintln(tw, "NAMESPACE\tNAME\tREPLICAS")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", v.Namespace, v.Name, v.Replicas)
+	case *apiv1.DeploymentList:
+		fmt.Fprintln(tw, "NAMESPACE\tNAME\tREPLICAS")
+		for _, item := range v.Items {
+			fmt.Fprintf(tw, "%s\t%s\t%d\n", item.Namespace, item.Name, item.Replicas)
+		}
+	case *apiv1.HorizontalPodAutoscaler:
+		fmt.Fprintln(tw, "NAME\tMIN\tMAX\tCURRENT\tDESIRED")
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", v.Name, v.MinReplicas, v.MaxReplicas, v.CurrentReplicas, v.DesiredReplicas)
+	case *apiv1.Status:
+		fmt.Fprintln(tw, v.Status)
+	default:
+		return fmt.Errorf("cannot print %T as a table", v)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/server"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestServer serves the real API over mTLS for the deployments, and returns
+// a client config file whose only context reaches it as commonName
+func newTestServer(t *testing.T, commonName string, cfg *config.Config, deployments ...*appsv1.Deployment) string {
+	t.Helper()
+
+	fakeClientset := fake.NewSimpleClientset()
+	addScaleReactors(fakeClientset)
+	for _, deployment := range deployments {
+		if _, err := fakeClientset.AppsV1().Deployments(deployment.Namespace).Create(context.TODO(), deployment, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	cfg.TLS.Enabled = false
+	srv, err := server.New(fakeClientset, deploymentInformer, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	dir := t.TempDir()
+	caPool, certFile, keyFile := writeClientCert(t, dir, commonName)
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	ts.TLS = &tls.Config{ClientCAs: caPool, ClientAuth: tls.RequireAndVerifyClientCert}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	caFile := filepath.Join(dir, "server-ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", ts.Certificate().Raw)
+
+	// Certificate paths are relative to the config file
+	configFile := filepath.Join(dir, "config")
+	writeFile(t, configFile, "contexts:\n"+
+		"- name: test\n"+
+		"  server: "+ts.URL+"\n"+
+		"  certFile: "+filepath.Base(certFile)+"\n"+
+		"  keyFile: "+filepath.Base(keyFile)+"\n"+
+		"  caFile: "+filepath.Base(caFile)+"\n")
+	return configFile
+}
+
+// addScaleReactors serves the deployments/scale subresource from the stored
+// deployment and bumps its resourceVersion on every scale, as the API server does
+func addScaleReactors(fakeClientset *fake.Clientset) {
+	deploymentsResource := appsv1.SchemeGroupVersion.WithResource("deployments")
+
+	fakeClientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		scale := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		obj, err := fakeClientset.Tracker().Get(deploymentsResource, action.GetNamespace(), scale.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		deployment := obj.(*appsv1.Deployment).DeepCopy()
+		deployment.Spec.Replicas = &scale.Spec.Replicas
+		version, _ := strconv.Atoi(deployment.ResourceVersion)
+		deployment.ResourceVersion = strconv.Itoa(version + 1)
+		if err := fakeClientset.Tracker().Update(deploymentsResource, deployment, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		scale.ResourceVersion = deployment.ResourceVersion
+		return true, scale, nil
+	})
+}
+
+// writeClientCert issues a client certificate from a new CA, returning the CA
+// and the certificate and key files
+func writeClientCert(t *testing.T, dir, commonName string) (*x509.CertPool, string, string) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "client-cert.pem"), filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", certDER)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return pool, certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	writeFile(t, path, string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newDeployment(namespace, name string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, ResourceVersion: "1"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+}
+
+// runCommand runs scalerctl with the config file and returns its exit code and output
+func runCommand(ctx context.Context, configFile string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(ctx, append([]string{"--config", configFile}, args...), &stdout, &stderr, func(string) string { return "" })
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	cfg := config.Default()
+	cfg.Scale.Cooldown = metav1.Duration{Duration: time.Hour}
+	configFile := newTestServer(t, "ci", cfg,
+		newDeployment("default", "web", 3),
+		newDeployment("default", "worker", 1),
+		newDeployment("batch", "jobs", 2),
+	)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "Get as a table",
+			args:       []string{"get", "default/web"},
+			wantStdout: "NAMESPACE   NAME   REPLICAS\ndefault     web    3\n",
+		},
+		{
+			name:       "Get as JSON",
+			args:       []string{"get", "default/web", "-o", "json"},
+			wantStdout: "{\n  \"namespace\": \"default\",\n  \"name\": \"web\",\n  \"replicas\": 3,\n  \"resourceVersion\": \"1\"\n}\n",
+		},
+		{
+			name:       "Get as YAML",
+			args:       []string{"-o", "yaml", "get", "default/web"},
+			wantStdout: "name: web\nnamespace: default\nreplicas: 3\nresourceVersion: \"1\"\n",
+		},
+		{
+			name:       "List all namespaces",
+			args:       []string{"list"},
+			wantStdout: "NAMESPACE   NAME     REPLICAS\nbatch       jobs     2\ndefault     web      3\ndefault     worker   1\n",
+		},
+		{
+			name:       "List one namespace",
+			args:       []string{"list", "batch"},
+			wantStdout: "NAMESPACE   NAME   REPLICAS\nbatch       jobs   2\n",
+		},
+		{
+			name:       "Relative scale up",
+			args:       []string{"set", "default/web", "+2", "--reason", "load test"},
+			wantStdout: "NAMESPACE   NAME   REPLICAS\ndefault     web    5\n",
+		},
+		{
+			name:       "Relative scale down",
+			args:       []string{"set", "default/worker", "-1"},
+			wantStdout: "NAMESPACE   NAME     REPLICAS\ndefault     worker   0\n",
+		},
+		{
+			name:       "Rejected by the cooldown",
+			args:       []string{"set", "default/web", "4"},
+			wantCode:   exitRejected,
+			wantStderr: "(TooManyRequests)",
+		},
+		{
+			name:       "Force without the privilege",
+			args:       []string{"set", "--force", "default/web", "4"},
+			wantCode:   exitRejected,
+			wantStderr: "scalerctl set: Client ci may not force a scale during the cooldown (Forbidden)",
+		},
+		{
+			name:       "Unknown deployment",
+			args:       []string{"get", "default/missing"},
+			wantCode:   exitNotFound,
+			wantStderr: "scalerctl get: Deployment not found (NotFound)",
+		},
+		{
+			name:       "Health",
+			args:       []string{"health"},
+			wantStdout: "OK\n",
+		},
+		{
+			name:     "Missing argument",
+			args:     []string{"get"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "Bad replica count",
+			args:     []string{"set", "default/web", "five"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "Unknown command",
+			args:     []string{"scale", "default/web"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "Unknown context",
+			args:     []string{"--context", "prod", "list"},
+			wantCode: exitUsage,
+		},
+	}
+
+	// Cases run in order, as the scales affect later ones
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(context.Background(), configFile, tt.args...)
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d; stderr: %s", code, tt.wantCode, stderr)
+			}
+			if tt.wantStdout != "" && stdout != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	configFile := newTestServer(t, "ci", config.Default(), newDeployment("default", "web", 3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type result struct {
+		code   int
+		stdout string
+	}
+	done := make(chan result)
+	go func() {
+		code, stdout, _ := runCommand(ctx, configFile, "watch", "default/web", "--until", "5")
+		done <- result{code, stdout}
+	}()
+
+	// Let the watch start before scaling
+	time.Sleep(200 * time.Millisecond)
+	if code, _, stderr := runCommand(ctx, configFile, "set", "default/web", "4"); code != exitOK {
+		t.Fatalf("set failed: %s", stderr)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if code, _, stderr := runCommand(ctx, configFile, "set", "default/web", "5"); code != exitOK {
+		t.Fatalf("set failed: %s", stderr)
+	}
+
+	got := <-done
+	if got.code != exitOK {
+		t.Fatalf("watch exit code = %d", got.code)
+	}
+	// Each change is aligned on its own, as it's printed when it arrives
+	want := "NAMESPACE   NAME   REPLICAS\ndefault     web    3\ndefault   web   4\ndefault   web   5\n"
+	if got.stdout != want {
+		t.Errorf("watch output = %q, want %q", got.stdout, want)
+	}
+}
+
+func TestExitCodeUnavailable(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	writeFile(t, configFile, "currentContext: down\ncontexts:\n- name: down\n  server: http://127.0.0.1:1\n- name: other\n  server: http://127.0.0.1:2\n")
+
+	code, _, stderr := runCommand(context.Background(), configFile, "health")
+	if code != exitUnavailable {
+		t.Errorf("exit code = %d, want %d; stderr: %s", code, exitUnavailable, stderr)
+	}
+	if !strings.Contains(stderr, "127.0.0.1:1") {
+		t.Errorf("stderr does not name the current context's server: %s", stderr)
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	opts := &options{}
+	fs := flag.NewFlagSet("scalerctl set", flag.ContinueOnError)
+	opts.bindFlags(fs)
+	reason := fs.String("reason", "", "")
+
+	args, err := parseInterspersed(fs, []string{"-o", "json", "default/web", "-3", "--reason", "quiet hours"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(args, " ") != "default/web -3" || *reason != "quiet hours" || opts.output != "json" {
+		t.Errorf("args = %q, reason = %q, output = %q", args, *reason, opts.output)
+	}
+}
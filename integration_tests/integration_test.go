@@ -14,6 +14,8 @@ import (
 	"testing"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -26,6 +28,10 @@ const (
 	certPath       = "../certs/client-cert.pem"
 	keyPath        = "../certs/client-key.pem"
 	caPath         = "../certs/ca-cert.pem"
+
+	// targetName is a throwaway deployment the scale tests create, since the
+	// scaler refuses to scale its own deployment.
+	targetName = "scaler-integration-target"
 )
 
 var client *kubernetes.Clientset
@@ -116,10 +122,12 @@ func TestGetReplicaCount(t *testing.T) {
 }
 
 func TestSetReplicaCount(t *testing.T) {
+	createTargetDeployment(t)
+
 	newReplicaCount := 3
 	payload := fmt.Sprintf(`{"replicas": %d}`, newReplicaCount)
 
-	resp, err := makeRequest("POST", fmt.Sprintf("/replica-count?namespace=%s&deployment=%s", namespace, deploymentName), []byte(payload))
+	resp, err := makeRequest("POST", fmt.Sprintf("/replica-count?namespace=%s&deployment=%s", namespace, targetName), []byte(payload))
 	if err != nil {
 		t.Fatalf("Error making request: %v", err)
 	}
@@ -133,7 +141,7 @@ func TestSetReplicaCount(t *testing.T) {
 	time.Sleep(5 * time.Second)
 
 	// Verify the replica count was updated
-	deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), deploymentName, metav1.GetOptions{})
+	deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), targetName, metav1.GetOptions{})
 	if err != nil {
 		t.Fatalf("Error getting deployment: %v", err)
 	}
@@ -143,6 +151,20 @@ func TestSetReplicaCount(t *testing.T) {
 	}
 }
 
+func TestSetReplicaCountSelfProtected(t *testing.T) {
+	payload := []byte(`{"replicas": 3}`)
+
+	resp, err := makeRequest("POST", fmt.Sprintf("/replica-count?namespace=%s&deployment=%s", namespace, deploymentName), payload)
+	if err != nil {
+		t.Fatalf("Error making request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status Forbidden when scaling the scaler itself, got %v", resp.Status)
+	}
+}
+
 func TestListDeployments(t *testing.T) {
 	resp, err := makeRequest("GET", "/deployments", nil)
 	if err != nil {
@@ -182,6 +204,40 @@ func TestListDeployments(t *testing.T) {
 	}
 }
 
+// createTargetDeployment creates a single-replica pause deployment for the scale
+// tests and removes it when the test finishes.
+func createTargetDeployment(t *testing.T) {
+	t.Helper()
+
+	replicas := int32(1)
+	labels := map[string]string{"app": targetName}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: targetName, Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "pause", Image: "registry.k8s.io/pause:3.9"}},
+				},
+			},
+		},
+	}
+
+	deployments := client.AppsV1().Deployments(namespace)
+	_ = deployments.Delete(context.TODO(), targetName, metav1.DeleteOptions{})
+	if _, err := deployments.Create(context.TODO(), deployment, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Error creating test deployment: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = deployments.Delete(context.TODO(), targetName, metav1.DeleteOptions{})
+	})
+
+	// Give the scaler's informer a moment to see the new deployment
+	time.Sleep(2 * time.Second)
+}
+
 func makeRequest(method, path string, payload []byte) (*http.Response, error) {
 	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
 	if err != nil {
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Decisions recorded for each request
+const (
+	DecisionAllowed  = "allowed"
+	DecisionRejected = "rejected"
+	DecisionFailed   = "failed"
+)
+
+// Record is one audited API request. PrevHash and Hash chain each record to the
+// one before it, so removing or editing a line breaks verification.
+type Record struct {
+	Time        time.Time `json:"time"`
+	RequestID   string    `json:"requestId,omitempty"`
+	Identity    string    `json:"identity"`
+	SourceIP    string    `json:"sourceIP"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Deployment  string    `json:"deployment,omitempty"`
+	Decision    string    `json:"decision"`
+	OldReplicas *int32    `json:"oldReplicas,omitempty"`
+	NewReplicas *int32    `json:"newReplicas,omitempty"`
+	// Clusters holds the outcome in each cluster of a request fanned out to several
+	Clusters  []ClusterRecord `json:"clusters,omitempty"`
+	LatencyMS float64         `json:"latencyMs"`
+	Status    int             `json:"status"`
+	PrevHash  string          `json:"prevHash"`
+	Hash      string          `json:"hash"`
+}
+
+// ClusterRecord is the outcome of one cluster's part of an audited request
+type ClusterRecord struct {
+	Cluster     string `json:"cluster"`
+	Decision    string `json:"decision"`
+	Status      int    `json:"status"`
+	OldReplicas *int32 `json:"oldReplicas,omitempty"`
+	NewReplicas *int32 `json:"newReplicas,omitempty"`
+}
+
+// Sink receives audit records. Write must not retain the slice after returning.
+type Sink interface {
+	Write(records []Record) error
+	Close() error
+}
+
+// Resumer is implemented by sinks that persist records, returning the hash of
+// the last record written before the process started so the chain continues
+type Resumer interface {
+	LastHash() string
+}
+
+// Logger chains records and writes them to every configured sink
+type Logger struct {
+	mu       sync.Mutex
+	sinks    []Sink
+	lastHash string
+}
+
+// New creates a Logger writing to the given sinks. The chain continues from
+// the first sink that remembers an earlier record.
+func New(sinks ...Sink) *Logger {
+	l := &Logger{sinks: sinks}
+	for _, sink := range sinks {
+		if resumer, ok := sink.(Resumer); ok {
+			if hash := resumer.LastHash(); hash != "" {
+				l.lastHash = hash
+				break
+			}
+		}
+	}
+	return l
+}
+
+// Log seals the record into the hash chain and writes it to each sink.
+// Sink failures are logged; auditing never fails the request being audited.
+func (l *Logger) Log(record Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.PrevHash = l.lastHash
+	hash, err := hashRecord(record)
+	if err != nil {
+		slog.Error("Failed to hash audit record", "error", err)
+		return
+	}
+	record.Hash = hash
+	l.lastHash = hash
+
+	for _, sink := range l.sinks {
+		if err := sink.Write([]Record{record}); err != nil {
+			slog.Error("Failed to write audit record", "request_id", record.RequestID, "error", err)
+		}
+	}
+}
+
+// Close closes every sink, flushing any buffered records
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var firstErr error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// hashRecord returns the hex SHA-256 of the record's JSON encoding without its own hash
+func hashRecord(record Record) (string, error) {
+	record.Hash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks that the records form an unbroken hash chain
+func Verify(records []Record) error {
+	for i, record := range records {
+		if i > 0 && record.PrevHash != records[i-1].Hash {
+			return fmt.Errorf("record %d does not chain to the previous record", i)
+		}
+		hash, err := hashRecord(record)
+		if err != nil {
+			return err
+		}
+		if hash != record.Hash {
+			return fmt.Errorf("record %d has been modified", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/middleware"
+)
+
+// memorySink collects records in memory
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *memorySink) Write(records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestMiddleware(t *testing.T) {
+	sink := &memorySink{}
+	logger := New(sink)
+
+	handler := middleware.RequestID(logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetTarget(r.Context(), "default", "my-deployment")
+		SetReplicas(r.Context(), 3, 5)
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest("POST", "/replica-count?namespace=default&deployment=my-deployment", nil)
+	req.RemoteAddr = "10.0.0.1:51234"
+	req.Header.Set("X-Request-ID", "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rejecting := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	rejecting.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/replica-count", nil))
+
+	if len(sink.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(sink.records))
+	}
+
+	record := sink.records[0]
+	if record.Namespace != "default" || record.Deployment != "my-deployment" {
+		t.Errorf("unexpected target %s/%s", record.Namespace, record.Deployment)
+	}
+	if record.OldReplicas == nil || *record.OldReplicas != 3 || record.NewReplicas == nil || *record.NewReplicas != 5 {
+		t.Errorf("unexpected replicas %v -> %v", record.OldReplicas, record.NewReplicas)
+	}
+	if record.SourceIP != "10.0.0.1" || record.RequestID != "req-1" || record.Identity != "unknown" {
+		t.Errorf("unexpected caller %+v", record)
+	}
+	if record.Decision != DecisionAllowed || record.Status != http.StatusOK {
+		t.Errorf("unexpected outcome %s/%d", record.Decision, record.Status)
+	}
+	if sink.records[1].Decision != DecisionRejected {
+		t.Errorf("expected rejected decision, got %s", sink.records[1].Decision)
+	}
+
+	if err := Verify(sink.records); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestMiddlewareRecordsClusters(t *testing.T) {
+	sink := &memorySink{}
+	logger := New(sink)
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetTarget(r.Context(), "default", "my-deployment")
+		west := WithCluster(r.Context(), "west")
+		east := WithCluster(r.Context(), "east")
+		SetReplicas(east, 3, 5)
+		SetClusterStatus(east, http.StatusOK)
+		SetClusterStatus(west, http.StatusConflict)
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/global/namespaces/default/deployments/my-deployment/scale", nil))
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.OldReplicas != nil || record.NewReplicas != nil {
+		t.Errorf("unexpected request replicas %v -> %v", record.OldReplicas, record.NewReplicas)
+	}
+	if len(record.Clusters) != 2 {
+		t.Fatalf("expected 2 cluster records, got %+v", record.Clusters)
+	}
+	east, west := record.Clusters[0], record.Clusters[1]
+	if east.Cluster != "east" || east.Decision != DecisionAllowed || east.Status != http.StatusOK ||
+		east.OldReplicas == nil || *east.OldReplicas != 3 || east.NewReplicas == nil || *east.NewReplicas != 5 {
+		t.Errorf("unexpected east record %+v", east)
+	}
+	if west.Cluster != "west" || west.Decision != DecisionRejected || west.Status != http.StatusConflict || west.NewReplicas != nil {
+		t.Errorf("unexpected west record %+v", west)
+	}
+	if err := Verify(sink.records); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	sink := &memorySink{}
+	logger := New(sink)
+	for i := 0; i < 3; i++ {
+		logger.Log(Record{Method: "GET", Path: "/deployments", Status: http.StatusOK})
+	}
+
+	modified := append([]Record(nil), sink.records...)
+	modified[1].Status = http.StatusInternalServerError
+	if err := Verify(modified); err == nil {
+		t.Error("Verify() expected error for modified record")
+	}
+
+	removed := []Record{sink.records[0], sink.records[2]}
+	if err := Verify(removed); err == nil {
+		t.Error("Verify() expected error for removed record")
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path, 300, 2)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	logger := New(sink)
+
+	for i := 0; i < 10; i++ {
+		logger.Log(Record{Method: "GET", Path: "/deployments", Status: http.StatusOK})
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups to be kept")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		t.Fatal("expected records in the current audit file")
+	}
+	if err := Verify(records); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestFileSinkResumesChainAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for run := 0; run < 2; run++ {
+		sink, err := NewFileSink(path, 0, 0)
+		if err != nil {
+			t.Fatalf("NewFileSink() error = %v", err)
+		}
+		logger := New(sink)
+		for i := 0; i < 3; i++ {
+			logger.Log(Record{Method: "GET", Path: "/deployments", Status: http.StatusOK})
+		}
+		if err := logger.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 6 {
+		t.Fatalf("expected 6 records, got %d", len(records))
+	}
+	if err := Verify(records); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestFileSinkKeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path, 300, 1)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	logger := New(sink)
+
+	// A non-empty directory in the backup's place makes the rename fail
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		logger.Log(Record{Method: "GET", Path: "/deployments", Status: http.StatusOK})
+	}
+	if err := sink.Write([]Record{{Method: "GET", Path: "/deployments", Status: http.StatusOK}}); err != nil {
+		t.Fatalf("Write() after a failed rotation error = %v", err)
+	}
+
+	// Once the rename can succeed again, rotation resumes
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Log(Record{Method: "GET", Path: "/deployments", Status: http.StatusOK})
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var lines int
+	for _, name := range []string{path + ".1", path} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		lines += strings.Count(string(data), "\n")
+	}
+	if lines != 7 {
+		t.Errorf("expected all 7 records across the audit files, got %d", lines)
+	}
+}
+
+func TestWebhookSinkRetriesAndBatches(t *testing.T) {
+	var attempts atomic.Int32
+	var mu sync.Mutex
+	var delivered []Record
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []Record
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("invalid batch: %v", err)
+		}
+		mu.Lock()
+		delivered = append(delivered, batch...)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	sink := NewWebhookSink(WebhookConfig{
+		URL:           webhook.URL,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		MaxRetries:    3,
+		RetryBackoff:  time.Millisecond,
+	})
+	logger := New(sink)
+	for i := 0; i < 3; i++ {
+		logger.Log(Record{Method: "GET", Path: "/deployments", Status: http.StatusOK})
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(delivered) != 3 {
+		t.Fatalf("expected 3 delivered records, got %d", len(delivered))
+	}
+	if err := Verify(delivered); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("expected 3 webhook calls (one retried), got %d", attempts.Load())
+	}
+}
+
+func TestWebhookSinkWithoutRetries(t *testing.T) {
+	var attempts atomic.Int32
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer webhook.Close()
+
+	sink := NewWebhookSink(WebhookConfig{URL: webhook.URL, RetryBackoff: time.Millisecond})
+	sink.Write([]Record{{}})
+	sink.Close()
+
+	if attempts.Load() != 1 {
+		t.Errorf("expected 1 webhook call with zero MaxRetries, got %d", attempts.Load())
+	}
+}
+
+func TestWebhookSinkDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer webhook.Close()
+
+	sink := NewWebhookSink(WebhookConfig{
+		URL:        webhook.URL,
+		BatchSize:  1,
+		BufferSize: 1,
+	})
+
+	// The first record is taken by the sender, which then blocks on the webhook
+	sink.Write([]Record{{}})
+	time.Sleep(50 * time.Millisecond)
+
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = sink.Write([]Record{{}})
+	}
+	if err == nil || sink.Dropped() == 0 {
+		t.Error("expected records to be dropped once the buffer is full")
+	}
+
+	close(block)
+	sink.Close()
+}
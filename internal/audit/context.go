@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type contextKey struct{}
+
+// details holds what handlers know about a request that the middleware cannot see
+type details struct {
+	mu          sync.Mutex
+	namespace   string
+	deployment  string
+	oldReplicas *int32
+	newReplicas *int32
+	// clusters holds the details of each cluster a fanned out request acts on
+	clusters map[string]*details
+	status   int
+}
+
+func fromContext(ctx context.Context) *details {
+	d, _ := ctx.Value(contextKey{}).(*details)
+	return d
+}
+
+// SetTarget records the deployment a request acts on. It is a no-op when the
+// request is not being audited.
+func SetTarget(ctx context.Context, namespace, deployment string) {
+	if d := fromContext(ctx); d != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.namespace, d.deployment = namespace, deployment
+	}
+}
+
+// SetReplicas records the replica counts before and after a scale
+func SetReplicas(ctx context.Context, oldReplicas, newReplicas int32) {
+	if d := fromContext(ctx); d != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.oldReplicas, d.newReplicas = &oldReplicas, &newReplicas
+	}
+}
+
+// WithCluster returns a context whose SetReplicas and SetClusterStatus calls are
+// recorded for cluster alone, for requests that act on several clusters at once.
+// It returns ctx unchanged when the request is not being audited.
+func WithCluster(ctx context.Context, cluster string) context.Context {
+	d := fromContext(ctx)
+	if d == nil {
+		return ctx
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.clusters == nil {
+		d.clusters = make(map[string]*details)
+	}
+	child := &details{}
+	d.clusters[cluster] = child
+	return context.WithValue(ctx, contextKey{}, child)
+}
+
+// SetClusterStatus records the status of a cluster's part of a request, on a
+// context returned by WithCluster
+func SetClusterStatus(ctx context.Context, status int) {
+	if d := fromContext(ctx); d != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.status = status
+	}
+}
+
+// clusterRecords returns the per-cluster outcomes, ordered by cluster. The
+// caller holds d.mu.
+func (d *details) clusterRecords() []ClusterRecord {
+	var records []ClusterRecord
+	for cluster, child := range d.clusters {
+		child.mu.Lock()
+		records = append(records, ClusterRecord{
+			Cluster:     cluster,
+			Decision:    decision(child.status),
+			Status:      child.status,
+			OldReplicas: child.oldReplicas,
+			NewReplicas: child.newReplicas,
+		})
+		child.mu.Unlock()
+	}
+	slices.SortFunc(records, func(a, b ClusterRecord) int {
+		return strings.Compare(a.Cluster, b.Cluster)
+	})
+	return records
+}
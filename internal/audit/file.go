@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// tailChunk is how much of the audit file is read at a time when looking for its last record
+const tailChunk = 64 * 1024
+
+// FileSink appends records as JSON lines to a local file, rotating it once it
+// grows past maxBytes and keeping up to maxBackups rotated files (path.1 is newest)
+type FileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	lastHash   string
+}
+
+// NewFileSink opens (or creates) the audit file at path
+func NewFileSink(path string, maxBytes int64, maxBackups int) (*FileSink, error) {
+	s := &FileSink{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	s.lastHash = s.resumeHash()
+	return s, nil
+}
+
+// LastHash returns the hash of the last record in the file when it was opened
+func (s *FileSink) LastHash() string {
+	return s.lastHash
+}
+
+// resumeHash finds the last record written by a previous process, looking in
+// the newest backup when the current file is empty because it was just rotated
+func (s *FileSink) resumeHash() string {
+	for _, name := range []string{s.path, s.path + ".1"} {
+		line, err := lastLine(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			slog.Warn("Failed to read last audit record, starting a new hash chain", "file", name, "error", err)
+			return ""
+		}
+		if line == nil {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			slog.Warn("Last audit record is unreadable, starting a new hash chain", "file", name, "error", err)
+			return ""
+		}
+		return record.Hash
+	}
+	return ""
+}
+
+// lastLine returns the last non-empty line of the file, reading it backwards
+// in chunks so large files aren't read whole; nil means the file is empty
+func lastLine(name string) ([]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var tail []byte
+	for end := info.Size(); end > 0; {
+		start := max(end-tailChunk, 0)
+		chunk := make([]byte, end-start)
+		if _, err := file.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(chunk, tail...)
+		end = start
+
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if end == 0 && len(trimmed) > 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening audit file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("reading audit file size: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// Write appends each record on its own line
+func (s *FileSink) Write(records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encoding audit record: %w", err)
+		}
+		line = append(line, '\n')
+
+		// A failed rotation is retried on the next write; until then records
+		// keep going to the current file rather than being lost
+		if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+			if err := s.rotate(); err != nil {
+				slog.Error("Failed to rotate audit file", "file", s.path, "error", err)
+			}
+		}
+
+		n, err := s.file.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("writing audit record: %w", err)
+		}
+	}
+	return nil
+}
+
+// rotate shifts path.N-1 to path.N, moves the current file to path.1 and reopens
+// path. The current file stays open until its replacement is, so a failure
+// leaves the sink writing to it rather than to a closed file.
+func (s *FileSink) rotate() error {
+	if s.maxBackups <= 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing audit file: %w", err)
+		}
+	} else {
+		for i := s.maxBackups - 1; i >= 1; i-- {
+			from := fmt.Sprintf("%s.%d", s.path, i)
+			to := fmt.Sprintf("%s.%d", s.path, i+1)
+			if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("rotating audit file: %w", err)
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return fmt.Errorf("rotating audit file: %w", err)
+		}
+	}
+
+	previous := s.file
+	if err := s.open(); err != nil {
+		return err
+	}
+	if err := previous.Close(); err != nil {
+		return fmt.Errorf("closing rotated audit file: %w", err)
+	}
+	return nil
+}
+
+// Close syncs and closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"k8s-deployment-scaler/internal/logging"
+	"k8s-deployment-scaler/internal/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor records one audit record for every unary gRPC call. The method
+// is recorded as POST, as on the wire, and the path as the full gRPC method name.
+func (l *Logger) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	d := &details{}
+	resp, err := handler(context.WithValue(ctx, contextKey{}, d), req)
+	l.logCall(ctx, info.FullMethod, d, err, start)
+	return resp, err
+}
+
+// StreamInterceptor records one audit record for every streaming gRPC call, once it ends
+func (l *Logger) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	d := &details{}
+	err := handler(srv, middleware.WithStreamContext(stream, context.WithValue(stream.Context(), contextKey{}, d)))
+	l.logCall(stream.Context(), info.FullMethod, d, err, start)
+	return err
+}
+
+func (l *Logger) logCall(ctx context.Context, method string, d *details, err error, start time.Time) {
+	statusCode := middleware.HTTPStatus(status.Code(err))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	l.Log(Record{
+		Time:        start.UTC(),
+		RequestID:   logging.RequestID(ctx),
+		Identity:    peerIdentity(ctx),
+		SourceIP:    peerIP(ctx),
+		Method:      http.MethodPost,
+		Path:        method,
+		Namespace:   d.namespace,
+		Deployment:  d.deployment,
+		Decision:    decision(statusCode),
+		OldReplicas: d.oldReplicas,
+		NewReplicas: d.newReplicas,
+		Clusters:    d.clusterRecords(),
+		LatencyMS:   float64(time.Since(start).Microseconds()) / 1000,
+		Status:      statusCode,
+	})
+}
+
+// peerIdentity returns the verified client certificate's common name
+func peerIdentity(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			return info.State.PeerCertificates[0].Subject.CommonName
+		}
+	}
+	return "unknown"
+}
+
+// peerIP returns the caller's address without its port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"k8s-deployment-scaler/internal/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryInterceptor(t *testing.T) {
+	sink := &memorySink{}
+	logger := New(sink)
+
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 51234}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/scaler.v1.ScalerService/SetScale"}
+
+	_, err := logger.UnaryInterceptor(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		SetTarget(ctx, "default", "my-deployment")
+		SetReplicas(ctx, 3, 5)
+		return nil, status.Error(codes.PermissionDenied, "denied")
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("interceptor changed the error: %v", err)
+	}
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.Method != http.MethodPost || record.Path != info.FullMethod {
+		t.Errorf("unexpected call %s %s", record.Method, record.Path)
+	}
+	if record.Namespace != "default" || record.Deployment != "my-deployment" || *record.NewReplicas != 5 {
+		t.Errorf("unexpected target %+v", record)
+	}
+	if record.SourceIP != "10.0.0.1" || record.RequestID != "req-1" || record.Identity != "unknown" {
+		t.Errorf("unexpected caller %+v", record)
+	}
+	if record.Decision != DecisionRejected || record.Status != http.StatusForbidden {
+		t.Errorf("unexpected outcome %s/%d", record.Decision, record.Status)
+	}
+}
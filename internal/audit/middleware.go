@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"k8s-deployment-scaler/internal/logging"
+	"k8s-deployment-scaler/internal/middleware"
+)
+
+// Middleware records one audit record for every request served by next
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		d := &details{}
+		recorder := middleware.NewStatusRecorder(w)
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), contextKey{}, d)))
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		l.Log(Record{
+			Time:        start.UTC(),
+			RequestID:   logging.RequestID(r.Context()),
+			Identity:    identity(r),
+			SourceIP:    sourceIP(r),
+			Method:      r.Method,
+			Path:        r.URL.RequestURI(),
+			Namespace:   d.namespace,
+			Deployment:  d.deployment,
+			Decision:    decision(recorder.Status),
+			OldReplicas: d.oldReplicas,
+			NewReplicas: d.newReplicas,
+			Clusters:    d.clusterRecords(),
+			LatencyMS:   float64(time.Since(start).Microseconds()) / 1000,
+			Status:      recorder.Status,
+		})
+	})
+}
+
+// decision derives the outcome of the request from its response status
+func decision(status int) string {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return DecisionFailed
+	case status >= http.StatusBadRequest:
+		return DecisionRejected
+	default:
+		return DecisionAllowed
+	}
+}
+
+// identity returns the verified client certificate's common name
+func identity(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return "unknown"
+}
+
+// sourceIP strips the port from the request's remote address
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WebhookConfig configures delivery of audit batches to an HTTP endpoint
+type WebhookConfig struct {
+	URL           string
+	Client        *http.Client
+	BatchSize     int
+	FlushInterval time.Duration
+	BufferSize    int
+	// MaxRetries is how often a failed POST is retried; zero disables retries
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+// WebhookSink buffers records and POSTs them as JSON arrays. When the buffer is
+// full new records are dropped rather than blocking request handling.
+type WebhookSink struct {
+	config  WebhookConfig
+	records chan Record
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// NewWebhookSink starts the background sender; zero config values other than
+// MaxRetries take defaults
+func NewWebhookSink(config WebhookConfig) *WebhookSink {
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 10000
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = time.Second
+	}
+
+	s := &WebhookSink{
+		config:  config,
+		records: make(chan Record, config.BufferSize),
+		done:    make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// Write enqueues the records without blocking
+func (s *WebhookSink) Write(records []Record) error {
+	for _, record := range records {
+		select {
+		case s.records <- record:
+		default:
+			s.mu.Lock()
+			s.dropped++
+			s.mu.Unlock()
+			return fmt.Errorf("audit webhook buffer full, record dropped")
+		}
+	}
+	return nil
+}
+
+// Dropped returns how many records were dropped because the buffer was full
+func (s *WebhookSink) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close stops the sender after delivering everything already buffered
+func (s *WebhookSink) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
+
+func (s *WebhookSink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, s.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.send(batch); err != nil {
+			slog.Error("Failed to deliver audit records", "count", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case record := <-s.records:
+			batch = append(batch, record)
+			if len(batch) >= s.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.done:
+			for {
+				select {
+				case record := <-s.records:
+					batch = append(batch, record)
+					if len(batch) >= s.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// send POSTs the batch, retrying with exponential backoff on transport errors and non-2xx responses
+func (s *WebhookSink) send(batch []Record) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("encoding audit batch: %w", err)
+	}
+
+	backoff := s.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err = s.post(body)
+		if err == nil || attempt >= s.config.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (s *WebhookSink) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
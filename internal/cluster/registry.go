@@ -0,0 +1,293 @@
+// Package cluster keeps a Kubernetes client, deployment informer and handlers
+// for each cluster the scaler serves.
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/handlers"
+	"k8s-deployment-scaler/internal/health"
+	k8s "k8s-deployment-scaler/internal/kubernetes"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	autoscalinginformers "k8s.io/client-go/informers/autoscaling/v2"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	policyinformers "k8s.io/client-go/informers/policy/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Cluster is one cluster the scaler serves
+type Cluster struct {
+	Name        string
+	Clientset   kubernetes.Interface
+	Deployments appsinformers.DeploymentInformer
+	Handlers    *handlers.Handlers
+	// HPAs, PDBs and Quotas watch the cluster's HorizontalPodAutoscalers,
+	// PodDisruptionBudgets and ResourceQuotas, when enabled
+	HPAs   autoscalinginformers.HorizontalPodAutoscalerInformer
+	PDBs   policyinformers.PodDisruptionBudgetInformer
+	Quotas coreinformers.ResourceQuotaInformer
+	// Local marks the cluster the scaler runs in
+	Local bool
+	// Checks report whether the cluster is ready; the local cluster's are part
+	// of the server's own readiness checks instead
+	Checks []health.Check
+
+	config config.ClusterConfig
+	stop   func()
+}
+
+// Synced reports whether the cluster's deployment cache, and HPA, PDB and
+// quota caches if any, have been filled
+func (c *Cluster) Synced() bool {
+	if c.HPAs != nil && !c.HPAs.Informer().HasSynced() {
+		return false
+	}
+	if c.PDBs != nil && !c.PDBs.Informer().HasSynced() {
+		return false
+	}
+	if c.Quotas != nil && !c.Quotas.Informer().HasSynced() {
+		return false
+	}
+	return c.Deployments.Informer().HasSynced()
+}
+
+// Connector builds the client for a configured cluster
+type Connector func(config.ClusterConfig) (kubernetes.Interface, error)
+
+// Setup builds the handlers serving a cluster from its client and informer. The
+// returned function releases anything they hold once the cluster is removed.
+type Setup func(*Cluster) (*handlers.Handlers, func(), error)
+
+// Options configure how the registry builds clusters
+type Options struct {
+	Connect      Connector
+	Setup        Setup
+	ResyncPeriod time.Duration
+	// Namespaces limits each cluster's informer to these namespaces
+	Namespaces []string
+	// WatchHPAs, WatchPDBs and WatchQuotas watch each cluster's
+	// HorizontalPodAutoscalers, PodDisruptionBudgets and ResourceQuotas as well
+	WatchHPAs   bool
+	WatchPDBs   bool
+	WatchQuotas bool
+	Health      config.HealthConfig
+	Logger      *slog.Logger
+}
+
+// Registry holds the local cluster and the configured remote clusters. It is
+// safe for concurrent use; Sync adds and removes clusters while others are served.
+type Registry struct {
+	local *Cluster
+	opts  Options
+
+	// syncMu serialises Sync calls, so mu is only held while swapping clusters
+	syncMu sync.Mutex
+	mu     sync.RWMutex
+	remote map[string]*Cluster
+}
+
+// NewRegistry returns a registry serving local, which is never removed
+func NewRegistry(local *Cluster, opts Options) *Registry {
+	local.Local = true
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Registry{
+		local:  local,
+		opts:   opts,
+		remote: make(map[string]*Cluster),
+	}
+}
+
+// Get returns the named cluster
+func (r *Registry) Get(name string) (*Cluster, bool) {
+	if name == r.local.Name {
+		return r.local, true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.remote[name]
+	return c, ok
+}
+
+// Handlers returns the named cluster's handlers once its cache has synced, as
+// a handlers.ClusterLookup
+func (r *Registry) Handlers(name string) (*handlers.Handlers, error) {
+	c, ok := r.Get(name)
+	if !ok {
+		return nil, handlers.ErrClusterNotFound
+	}
+	if !c.Synced() {
+		return nil, handlers.ErrClusterNotSynced
+	}
+	return c.Handlers, nil
+}
+
+// List returns every cluster ordered by name
+func (r *Registry) List() []*Cluster {
+	r.mu.RLock()
+	clusters := make([]*Cluster, 0, len(r.remote)+1)
+	clusters = append(clusters, r.local)
+	for _, c := range r.remote {
+		clusters = append(clusters, c)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters
+}
+
+// Names returns the name of every cluster in order
+func (r *Registry) Names() []string {
+	var names []string
+	for _, c := range r.List() {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// Summary describes every cluster for the API
+func (r *Registry) Summary() apiv1.ClusterList {
+	list := apiv1.ClusterList{Items: []apiv1.Cluster{}}
+	for _, c := range r.List() {
+		list.Items = append(list.Items, apiv1.Cluster{Name: c.Name, Local: c.Local, Synced: c.Synced()})
+	}
+	return list
+}
+
+// Checks returns the readiness checks of every remote cluster
+func (r *Registry) Checks() []health.Check {
+	var checks []health.Check
+	for _, c := range r.List() {
+		checks = append(checks, c.Checks...)
+	}
+	return checks
+}
+
+// Sync makes the remote clusters match configs: clusters no longer listed are
+// stopped, clusters whose kubeconfig or context changed are rebuilt and new ones
+// are started. A cluster that can't be built is left out and reported in the
+// returned error without affecting the others. Sync doesn't wait for new
+// clusters' caches; they answer 503 until synced.
+func (r *Registry) Sync(configs []config.ClusterConfig) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	wanted := make(map[string]config.ClusterConfig, len(configs))
+	for _, cfg := range configs {
+		wanted[cfg.Name] = cfg
+	}
+
+	r.mu.RLock()
+	current := make(map[string]*Cluster, len(r.remote))
+	for name, c := range r.remote {
+		current[name] = c
+	}
+	r.mu.RUnlock()
+
+	for name, c := range current {
+		if cfg, ok := wanted[name]; !ok || cfg != c.config {
+			r.remove(c)
+		}
+	}
+
+	var errs []error
+	for _, cfg := range configs {
+		if c, ok := current[cfg.Name]; ok && cfg == c.config {
+			continue
+		}
+		if err := r.add(cfg); err != nil {
+			errs = append(errs, fmt.Errorf("cluster %s: %w", cfg.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// add builds the cluster and starts its informer
+func (r *Registry) add(cfg config.ClusterConfig) error {
+	clientset, err := r.opts.Connect(cfg)
+	if err != nil {
+		return err
+	}
+
+	deployments := k8s.NewDeploymentInformer(clientset, r.opts.ResyncPeriod, r.opts.Namespaces)
+	c := &Cluster{
+		Name:        cfg.Name,
+		Clientset:   clientset,
+		Deployments: deployments,
+		config:      cfg,
+	}
+	informer := c.Deployments.Informer()
+
+	activity, err := health.InformerActivity("cluster-"+cfg.Name+"-informer-watch", informer, r.opts.Health.InformerMaxWatchAge.Duration)
+	if err != nil {
+		return err
+	}
+	c.Checks = []health.Check{
+		health.InformerSynced("cluster-"+cfg.Name+"-informer-sync", informer),
+		activity,
+		health.APIServer("cluster-"+cfg.Name+"-kubernetes-api", clientset.Discovery(),
+			r.opts.Health.APICheckInterval.Duration, r.opts.Health.APICheckTimeout.Duration, r.opts.Health.APIFailureThreshold),
+	}
+	if r.opts.WatchHPAs {
+		c.HPAs = deployments.HorizontalPodAutoscalers()
+		c.Checks = append(c.Checks, health.InformerSynced("cluster-"+cfg.Name+"-hpa-informer-sync", c.HPAs.Informer()))
+	}
+	if r.opts.WatchPDBs {
+		c.PDBs = deployments.PodDisruptionBudgets()
+		c.Checks = append(c.Checks, health.InformerSynced("cluster-"+cfg.Name+"-pdb-informer-sync", c.PDBs.Informer()))
+	}
+	if r.opts.WatchQuotas {
+		c.Quotas = deployments.ResourceQuotas()
+		c.Checks = append(c.Checks, health.InformerSynced("cluster-"+cfg.Name+"-quota-informer-sync", c.Quotas.Informer()))
+	}
+
+	h, release, err := r.opts.Setup(c)
+	if err != nil {
+		return err
+	}
+	c.Handlers = h
+
+	stopCh := make(chan struct{})
+	deployments.Start(stopCh)
+	c.stop = func() {
+		close(stopCh)
+		deployments.Shutdown()
+		release()
+	}
+
+	r.mu.Lock()
+	r.remote[cfg.Name] = c
+	r.mu.Unlock()
+	r.opts.Logger.Info("Cluster added", "cluster", cfg.Name, "kubeconfig", cfg.Kubeconfig, "context", cfg.Context)
+	return nil
+}
+
+// remove stops serving the cluster and shuts its informer down
+func (r *Registry) remove(c *Cluster) {
+	r.mu.Lock()
+	delete(r.remote, c.Name)
+	r.mu.Unlock()
+	c.stop()
+	r.opts.Logger.Info("Cluster removed", "cluster", c.Name)
+}
+
+// Stop shuts every remote cluster down
+func (r *Registry) Stop() {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	for _, c := range r.List() {
+		if !c.Local {
+			r.remove(c)
+		}
+	}
+}
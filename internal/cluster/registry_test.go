@@ -0,0 +1,269 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/handlers"
+	"k8s-deployment-scaler/internal/health"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// testRegistry connects each cluster to a fake clientset holding one deployment
+// named after the cluster, and counts the setups still to be released
+type testRegistry struct {
+	*Registry
+
+	setupMu  sync.Mutex
+	live     map[string]int
+	failures map[string]error
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	t.Helper()
+
+	localClientset := fake.NewSimpleClientset()
+	factory := informers.NewSharedInformerFactory(localClientset, 0)
+	local := &Cluster{Name: "local", Clientset: localClientset, Deployments: factory.Apps().V1().Deployments()}
+	local.Deployments.Informer()
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	r := &testRegistry{live: make(map[string]int), failures: make(map[string]error)}
+	r.Registry = NewRegistry(local, Options{
+		Connect: func(cfg config.ClusterConfig) (kubernetes.Interface, error) {
+			r.setupMu.Lock()
+			defer r.setupMu.Unlock()
+			if err := r.failures[cfg.Name]; err != nil {
+				return nil, err
+			}
+			replicas := int32(1)
+			return fake.NewSimpleClientset(&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: cfg.Name, Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			}), nil
+		},
+		Setup: func(c *Cluster) (*handlers.Handlers, func(), error) {
+			h, err := handlers.New(c.Clientset, c.Deployments)
+			if err != nil {
+				return nil, nil, err
+			}
+			r.setupMu.Lock()
+			r.live[c.Name]++
+			r.setupMu.Unlock()
+			return h, func() {
+				r.setupMu.Lock()
+				r.live[c.Name]--
+				r.setupMu.Unlock()
+			}, nil
+		},
+		Health: config.Default().Health,
+	})
+	t.Cleanup(r.Stop)
+	return r
+}
+
+// names lists the registry's clusters in order
+func (r *testRegistry) names() string {
+	var names []string
+	for _, c := range r.List() {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// waitSynced waits for the named cluster's cache to fill
+func (r *testRegistry) waitSynced(t *testing.T, name string) *Cluster {
+	t.Helper()
+	c, ok := r.Get(name)
+	if !ok {
+		t.Fatalf("cluster %s not registered", name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), c.Synced) {
+		t.Fatalf("cluster %s did not sync", name)
+	}
+	return c
+}
+
+func TestRegistrySync(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	if got := r.names(); got != "local" {
+		t.Fatalf("clusters = %s, want only local", got)
+	}
+
+	east := config.ClusterConfig{Name: "east", Context: "east"}
+	west := config.ClusterConfig{Name: "west", Kubeconfig: "west.kubeconfig"}
+	if err := r.Sync([]config.ClusterConfig{west, east}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := r.names(); got != "east,local,west" {
+		t.Errorf("clusters = %s", got)
+	}
+
+	// Each cluster serves its own cache
+	c := r.waitSynced(t, "east")
+	if _, err := c.Deployments.Lister().Deployments("default").Get("east"); err != nil {
+		t.Errorf("east's cache: %v", err)
+	}
+	if _, err := c.Deployments.Lister().Deployments("default").Get("west"); err == nil {
+		t.Error("east's cache holds west's deployment")
+	}
+	r.waitSynced(t, "west")
+
+	// An unchanged cluster is kept, a changed one rebuilt and a missing one removed
+	before, _ := r.Get("east")
+	east.Context = "east-admin"
+	if err := r.Sync([]config.ClusterConfig{east}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	after, _ := r.Get("east")
+	if after == before || after.config.Context != "east-admin" {
+		t.Error("changed cluster was not rebuilt")
+	}
+	if got := r.names(); got != "east,local" {
+		t.Errorf("clusters = %s", got)
+	}
+	if err := r.Sync([]config.ClusterConfig{east}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if again, _ := r.Get("east"); again != after {
+		t.Error("unchanged cluster was rebuilt")
+	}
+	if !before.Deployments.Informer().IsStopped() {
+		t.Error("replaced cluster's informer is still running")
+	}
+
+	r.setupMu.Lock()
+	live := r.live["east"] + r.live["west"]
+	r.setupMu.Unlock()
+	if live != 1 {
+		t.Errorf("%d clusters' handlers unreleased, want 1", live)
+	}
+}
+
+func TestRegistrySyncFailure(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	r.failures["west"] = errors.New("context \"west\" does not exist")
+
+	err := r.Sync([]config.ClusterConfig{{Name: "east", Context: "east"}, {Name: "west", Context: "west"}})
+	if err == nil || !strings.Contains(err.Error(), `cluster west: context "west" does not exist`) {
+		t.Errorf("Sync() error = %v", err)
+	}
+	// The other cluster is still served
+	if got := r.names(); got != "east,local" {
+		t.Errorf("clusters = %s", got)
+	}
+}
+
+func TestRegistryHandlers(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	if err := r.Sync([]config.ClusterConfig{{Name: "east", Context: "east"}}); err != nil {
+		t.Fatal(err)
+	}
+	r.waitSynced(t, "east")
+
+	if h, err := r.Handlers("local"); err != nil || h != r.local.Handlers {
+		t.Errorf("Handlers(local) = %v, %v", h, err)
+	}
+	if h, err := r.Handlers("east"); err != nil || h == nil {
+		t.Errorf("Handlers(east) = %v, %v", h, err)
+	}
+	if _, err := r.Handlers("north"); !errors.Is(err, handlers.ErrClusterNotFound) {
+		t.Errorf("Handlers(north) error = %v, want ErrClusterNotFound", err)
+	}
+
+	summary := r.Summary()
+	if len(summary.Items) != 2 || summary.Items[0].Name != "east" || !summary.Items[0].Synced || summary.Items[0].Local || !summary.Items[1].Local {
+		t.Errorf("Summary() = %+v", summary)
+	}
+
+	// Only remote clusters add readiness checks
+	var names []string
+	for _, check := range r.Checks() {
+		names = append(names, check.Name)
+	}
+	if got := strings.Join(names, ","); got != "cluster-east-informer-sync,cluster-east-informer-watch,cluster-east-kubernetes-api" {
+		t.Errorf("Checks() = %s", got)
+	}
+	for _, check := range r.Checks() {
+		if err := check.Run(context.Background()); err != nil {
+			t.Errorf("%s failed: %v", check.Name, err)
+		}
+	}
+}
+
+func TestRegistryNotSynced(t *testing.T) {
+	t.Parallel()
+
+	// A cluster whose informer never starts stays unsynced
+	clientset := fake.NewSimpleClientset()
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	c := &Cluster{Name: "east", Clientset: clientset, Deployments: factory.Apps().V1().Deployments()}
+	c.Checks = []health.Check{health.InformerSynced("cluster-east-informer-sync", c.Deployments.Informer())}
+
+	r := newTestRegistry(t)
+	r.Registry.mu.Lock()
+	r.remote["east"] = c
+	r.Registry.mu.Unlock()
+
+	if _, err := r.Handlers("east"); !errors.Is(err, handlers.ErrClusterNotSynced) {
+		t.Errorf("Handlers(east) error = %v, want ErrClusterNotSynced", err)
+	}
+	if err := r.Checks()[0].Run(context.Background()); err == nil {
+		t.Error("unsynced cluster passed its readiness check")
+	}
+	r.Registry.mu.Lock()
+	delete(r.remote, "east")
+	r.Registry.mu.Unlock()
+}
+
+func TestRegistryWatchRelatedObjects(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRegistry(t)
+	r.opts.WatchHPAs = true
+	r.opts.WatchPDBs = true
+	r.opts.WatchQuotas = true
+	if err := r.Sync([]config.ClusterConfig{{Name: "east"}}); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	c := r.waitSynced(t, "east")
+	if c.HPAs == nil || !c.HPAs.Informer().HasSynced() {
+		t.Fatal("east's HPAs aren't watched")
+	}
+	if c.PDBs == nil || !c.PDBs.Informer().HasSynced() {
+		t.Fatal("east's PDBs aren't watched")
+	}
+	if c.Quotas == nil || !c.Quotas.Informer().HasSynced() {
+		t.Fatal("east's ResourceQuotas aren't watched")
+	}
+
+	var names []string
+	for _, check := range r.Checks() {
+		names = append(names, check.Name)
+	}
+	if got := strings.Join(names, ","); !strings.HasSuffix(got, ",cluster-east-hpa-informer-sync,cluster-east-pdb-informer-sync,cluster-east-quota-informer-sync") {
+		t.Errorf("Checks() = %s", got)
+	}
+}
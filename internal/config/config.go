@@ -0,0 +1,597 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s-deployment-scaler/internal/logging"
+	"k8s-deployment-scaler/internal/policy"
+	"k8s-deployment-scaler/internal/ratelimit"
+	"k8s-deployment-scaler/internal/tracing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// envPrefix prefixes the environment variable for each flag, e.g. SCALER_LISTEN_ADDR
+const envPrefix = "SCALER_"
+
+// redacted replaces secret values in the effective configuration
+const redacted = "<redacted>"
+
+// Values of scale.quotaCheck
+const (
+	// QuotaCheckEnforce refuses scale-ups exceeding a ResourceQuota
+	QuotaCheckEnforce = "enforce"
+	// QuotaCheckWarn allows them, warning in the response
+	QuotaCheckWarn = "warn"
+	// QuotaCheckOff doesn't watch ResourceQuotas
+	QuotaCheckOff = "off"
+)
+
+// dnsLabel matches a DNS label, the form of namespace names and of cluster
+// names, which appear in URL paths
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// Config holds every setting of the server
+type Config struct {
+	ListenAddr       string         `json:"listenAddr"`
+	TLS              TLSConfig      `json:"tls"`
+	Metrics          MetricsConfig  `json:"metrics"`
+	GRPC             GRPCConfig     `json:"grpc"`
+	Log              LogConfig      `json:"log"`
+	Informer         InformerConfig `json:"informer"`
+	Timeouts         TimeoutsConfig `json:"timeouts"`
+	Health           HealthConfig   `json:"health"`
+	ChangePolicyFile string         `json:"changePolicyFile"`
+	PriceTableFile   string         `json:"priceTableFile"`
+	Audit            AuditConfig    `json:"audit"`
+	Tracing          TracingConfig  `json:"tracing"`
+	Scale            ScaleConfig    `json:"scale"`
+	Self             SelfConfig     `json:"self"`
+
+	// AdminIdentities may use the /debug endpoints, which read the configuration
+	// and change the log level; empty refuses every client
+	AdminIdentities []string `json:"adminIdentities,omitempty"`
+
+	// RateLimits maps route patterns such as "POST /replica-count" to their limits
+	RateLimits map[string]RouteRateLimit `json:"rateLimits,omitempty"`
+
+	// ClusterName names the cluster the scaler runs in, served by the unqualified routes
+	ClusterName string `json:"clusterName"`
+	// Clusters lists further clusters served under /clusters/{name}
+	Clusters []ClusterConfig `json:"clusters,omitempty"`
+
+	// Namespaces limits every cluster to these namespaces, each watched by its
+	// own informer, so the scaler needs only namespaced roles; empty serves all
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// file is the config file the configuration was loaded from, if any
+	file string
+}
+
+// TLSConfig holds the server certificate and the CA used to verify client certificates
+type TLSConfig struct {
+	Enabled  bool   `json:"enabled"`
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	CAFile   string `json:"caFile"`
+}
+
+// MetricsConfig holds the metrics and probe listener settings
+type MetricsConfig struct {
+	Addr string `json:"addr"`
+	TLS  bool   `json:"tls"`
+}
+
+// GRPCConfig holds the gRPC listener settings. The listener shares the API's
+// TLS settings; an empty Addr disables it.
+type GRPCConfig struct {
+	Addr string `json:"addr"`
+}
+
+// LogConfig holds the initial log level
+type LogConfig struct {
+	Level string `json:"level"`
+}
+
+// InformerConfig holds the deployment informer settings
+type InformerConfig struct {
+	ResyncPeriod metav1.Duration `json:"resyncPeriod"`
+}
+
+// TimeoutsConfig bounds calls to the Kubernetes API and server shutdown
+type TimeoutsConfig struct {
+	UpdateScale metav1.Duration `json:"updateScale"`
+	Shutdown    metav1.Duration `json:"shutdown"`
+	// TLSHandshake bounds how long a client may take to complete a TLS handshake
+	TLSHandshake metav1.Duration `json:"tlsHandshake"`
+}
+
+// HealthConfig tunes the /readyz checks
+type HealthConfig struct {
+	APICheckInterval metav1.Duration `json:"apiCheckInterval"`
+	// APICheckTimeout bounds each call to the API server, so a hung API server
+	// fails the check instead of stalling the probe
+	APICheckTimeout     metav1.Duration `json:"apiCheckTimeout"`
+	APIFailureThreshold int             `json:"apiFailureThreshold"`
+	InformerMaxWatchAge metav1.Duration `json:"informerMaxWatchAge"`
+}
+
+// AuditConfig selects the audit log outputs; no output disables auditing
+type AuditConfig struct {
+	File       string `json:"file"`
+	MaxSizeMB  int    `json:"maxSizeMB"`
+	MaxBackups int    `json:"maxBackups"`
+	WebhookURL string `json:"webhookURL"`
+	// WebhookBatchSize records are sent per POST, or fewer every WebhookFlushInterval
+	WebhookBatchSize     int             `json:"webhookBatchSize"`
+	WebhookFlushInterval metav1.Duration `json:"webhookFlushInterval"`
+	// WebhookBufferSize records are held for delivery before new ones are dropped
+	WebhookBufferSize int `json:"webhookBufferSize"`
+	// WebhookMaxRetries failed POSTs are retried, waiting WebhookRetryBackoff
+	// and doubling the wait each time; zero disables retries
+	WebhookMaxRetries   int             `json:"webhookMaxRetries"`
+	WebhookRetryBackoff metav1.Duration `json:"webhookRetryBackoff"`
+}
+
+// TracingConfig selects the trace exporter
+type TracingConfig struct {
+	Exporter string `json:"exporter"`
+	File     string `json:"file"`
+}
+
+// ScaleConfig holds the safeguards applied to scale requests
+type ScaleConfig struct {
+	// Cooldown rejects a second scale of the same deployment within this period; zero disables it
+	Cooldown metav1.Duration `json:"cooldown"`
+	// ForceIdentities may bypass the cooldown with force=true
+	ForceIdentities []string `json:"forceIdentities,omitempty"`
+	// PDBForceIdentities may scale past a PodDisruptionBudget with force=true
+	PDBForceIdentities []string `json:"pdbForceIdentities,omitempty"`
+	// ProtectedNamespaces are never scaled
+	ProtectedNamespaces []string `json:"protectedNamespaces,omitempty"`
+	// ProtectedSelectors protect the deployments matching any of these label
+	// selectors, e.g. "scaler.example.com/protected=true"
+	ProtectedSelectors []string `json:"protectedSelectors,omitempty"`
+	// QuotaCheck compares scale-ups with the namespace's ResourceQuotas: enforce,
+	// warn or off
+	QuotaCheck string `json:"quotaCheck"`
+}
+
+// SelfConfig identifies the scaler's own deployment, which it never scales, as
+// set through the downward API
+type SelfConfig struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+}
+
+// ClusterConfig names a cluster and the kubeconfig reaching it. An empty
+// Kubeconfig uses the default loading rules, such as $KUBECONFIG; an empty
+// Context uses the kubeconfig's current context.
+type ClusterConfig struct {
+	Name       string `json:"name"`
+	Kubeconfig string `json:"kubeconfig"`
+	Context    string `json:"context"`
+}
+
+// RouteRateLimit holds token bucket limits for one route. Either may be omitted.
+type RouteRateLimit struct {
+	PerIdentity   *ratelimit.Limit `json:"perIdentity,omitempty"`
+	PerDeployment *ratelimit.Limit `json:"perDeployment,omitempty"`
+}
+
+// stringList is a flag holding a comma-separated list
+type stringList struct {
+	values *[]string
+}
+
+func (l stringList) String() string {
+	if l.values == nil {
+		return ""
+	}
+	return strings.Join(*l.values, ",")
+}
+
+func (l stringList) Set(value string) error {
+	*l.values = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l.values = append(*l.values, item)
+		}
+	}
+	return nil
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
+	return &Config{
+		ListenAddr: ":8443",
+		TLS: TLSConfig{
+			Enabled:  true,
+			CertFile: "certs/server-cert.pem",
+			KeyFile:  "certs/server-key.pem",
+			CAFile:   "certs/ca-cert.pem",
+		},
+		Metrics: MetricsConfig{
+			Addr: ":9090",
+		},
+		GRPC: GRPCConfig{
+			Addr: ":8444",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Informer: InformerConfig{
+			ResyncPeriod: metav1.Duration{Duration: 10 * time.Minute},
+		},
+		Timeouts: TimeoutsConfig{
+			UpdateScale:  metav1.Duration{Duration: 10 * time.Second},
+			Shutdown:     metav1.Duration{Duration: 5 * time.Second},
+			TLSHandshake: metav1.Duration{Duration: 10 * time.Second},
+		},
+		Health: HealthConfig{
+			APICheckInterval:    metav1.Duration{Duration: 10 * time.Second},
+			APICheckTimeout:     metav1.Duration{Duration: 2 * time.Second},
+			APIFailureThreshold: 3,
+			InformerMaxWatchAge: metav1.Duration{Duration: 15 * time.Minute},
+		},
+		Audit: AuditConfig{
+			MaxSizeMB:            100,
+			MaxBackups:           5,
+			WebhookBatchSize:     100,
+			WebhookFlushInterval: metav1.Duration{Duration: 5 * time.Second},
+			WebhookBufferSize:    10000,
+			WebhookMaxRetries:    3,
+			WebhookRetryBackoff:  metav1.Duration{Duration: time.Second},
+		},
+		Tracing: TracingConfig{
+			Exporter: tracing.ExporterNone,
+		},
+		Scale: ScaleConfig{
+			ProtectedNamespaces: []string{"kube-system"},
+			QuotaCheck:          QuotaCheckEnforce,
+		},
+		ClusterName: "local",
+	}
+}
+
+// bindFlags registers a flag for every setting, writing into c
+func (c *Config) bindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.ListenAddr, "listen-addr", c.ListenAddr, "Address the API listens on")
+	fs.BoolVar(&c.TLS.Enabled, "tls-enabled", c.TLS.Enabled, "Serve the API over mTLS")
+	fs.StringVar(&c.TLS.CertFile, "tls-cert-file", c.TLS.CertFile, "Server certificate")
+	fs.StringVar(&c.TLS.KeyFile, "tls-key-file", c.TLS.KeyFile, "Server private key")
+	fs.StringVar(&c.TLS.CAFile, "tls-ca-file", c.TLS.CAFile, "CA certificate used to verify client certificates")
+	fs.StringVar(&c.Metrics.Addr, "metrics-addr", c.Metrics.Addr, "Address the metrics and probe endpoints listen on")
+	fs.BoolVar(&c.Metrics.TLS, "metrics-tls", c.Metrics.TLS, "Require mTLS on the metrics listener")
+	fs.StringVar(&c.GRPC.Addr, "grpc-addr", c.GRPC.Addr, "Address the gRPC API listens on; empty disables it")
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "Initial log level: debug, info, warn or error")
+	fs.DurationVar(&c.Informer.ResyncPeriod.Duration, "resync-period", c.Informer.ResyncPeriod.Duration, "Deployment informer resync period")
+	fs.DurationVar(&c.Timeouts.UpdateScale.Duration, "update-scale-timeout", c.Timeouts.UpdateScale.Duration, "Timeout for UpdateScale calls")
+	fs.DurationVar(&c.Timeouts.Shutdown.Duration, "shutdown-timeout", c.Timeouts.Shutdown.Duration, "Time allowed for in-flight requests on shutdown")
+	fs.DurationVar(&c.Timeouts.TLSHandshake.Duration, "tls-handshake-timeout", c.Timeouts.TLSHandshake.Duration, "Time allowed for a client to complete the TLS handshake")
+	fs.DurationVar(&c.Health.APICheckInterval.Duration, "api-check-interval", c.Health.APICheckInterval.Duration, "Minimum interval between API server readiness checks")
+	fs.DurationVar(&c.Health.APICheckTimeout.Duration, "api-check-timeout", c.Health.APICheckTimeout.Duration, "Timeout of each API server readiness check")
+	fs.IntVar(&c.Health.APIFailureThreshold, "api-failure-threshold", c.Health.APIFailureThreshold, "Consecutive API server failures before reporting not ready")
+	fs.DurationVar(&c.Health.InformerMaxWatchAge.Duration, "informer-max-watch-age", c.Health.InformerMaxWatchAge.Duration, "Longest the informer may go without watch activity before reporting not ready")
+	fs.StringVar(&c.ChangePolicyFile, "change-policy-file", c.ChangePolicyFile, "YAML file of per-namespace reason and ticket requirements")
+	fs.StringVar(&c.PriceTableFile, "price-table-file", c.PriceTableFile, "YAML file of CPU and memory prices per node pool, enabling cost estimates")
+	fs.StringVar(&c.Audit.File, "audit-log-file", c.Audit.File, "Append audit records to this file")
+	fs.IntVar(&c.Audit.MaxSizeMB, "audit-log-max-size-mb", c.Audit.MaxSizeMB, "Size at which the audit file is rotated")
+	fs.IntVar(&c.Audit.MaxBackups, "audit-log-max-backups", c.Audit.MaxBackups, "Number of rotated audit files kept")
+	fs.StringVar(&c.Audit.WebhookURL, "audit-webhook-url", c.Audit.WebhookURL, "POST audit record batches to this URL")
+	fs.IntVar(&c.Audit.WebhookBatchSize, "audit-webhook-batch-size", c.Audit.WebhookBatchSize, "Audit records sent per webhook POST")
+	fs.DurationVar(&c.Audit.WebhookFlushInterval.Duration, "audit-webhook-flush-interval", c.Audit.WebhookFlushInterval.Duration, "Send a partial audit batch after this long")
+	fs.IntVar(&c.Audit.WebhookBufferSize, "audit-webhook-buffer-size", c.Audit.WebhookBufferSize, "Audit records buffered for the webhook before new ones are dropped")
+	fs.IntVar(&c.Audit.WebhookMaxRetries, "audit-webhook-max-retries", c.Audit.WebhookMaxRetries, "Retries of a failed audit webhook POST; 0 disables retries")
+	fs.DurationVar(&c.Audit.WebhookRetryBackoff.Duration, "audit-webhook-retry-backoff", c.Audit.WebhookRetryBackoff.Duration, "Wait before the first audit webhook retry, doubled for each further one")
+	fs.StringVar(&c.Tracing.Exporter, "trace-exporter", c.Tracing.Exporter, "Trace exporter: otlp, stdout or none")
+	fs.StringVar(&c.Tracing.File, "trace-file", c.Tracing.File, "With the stdout exporter, write spans to this file")
+	fs.DurationVar(&c.Scale.Cooldown.Duration, "scale-cooldown", c.Scale.Cooldown.Duration, "Reject a second scale of the same deployment within this period")
+	fs.Var(stringList{&c.Scale.ForceIdentities}, "force-identities", "Comma-separated client identities allowed to bypass the cooldown with force=true")
+	fs.Var(stringList{&c.Scale.PDBForceIdentities}, "pdb-force-identities", "Comma-separated client identities allowed to scale past a PodDisruptionBudget with force=true")
+	fs.Var(stringList{&c.Scale.ProtectedNamespaces}, "protected-namespaces", "Comma-separated namespaces whose deployments are never scaled")
+	fs.StringVar(&c.Scale.QuotaCheck, "quota-check", c.Scale.QuotaCheck, "Compare scale-ups with ResourceQuotas: enforce, warn or off")
+	fs.StringVar(&c.Self.Namespace, "self-namespace", c.Self.Namespace, "Namespace of the scaler's own deployment, which is never scaled")
+	fs.StringVar(&c.Self.Deployment, "self-deployment", c.Self.Deployment, "Name of the scaler's own deployment, which is never scaled")
+	fs.Var(stringList{&c.AdminIdentities}, "admin-identities", "Comma-separated client identities allowed to use the /debug endpoints")
+	fs.Var(stringList{&c.Namespaces}, "namespaces", "Comma-separated namespaces to serve, instead of every namespace")
+	fs.StringVar(&c.ClusterName, "cluster-name", c.ClusterName, "Name of the cluster the scaler runs in, as listed under /clusters")
+}
+
+// EnvName returns the environment variable overriding the named flag
+func EnvName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Load builds the configuration from, in increasing precedence, the defaults, the
+// YAML file named by --config or SCALER_CONFIG, SCALER_* environment variables and
+// command-line flags, then validates it.
+func Load(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML configuration file")
+	cfg.bindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Remember the flags given, then rebuild from the lowest precedence source up
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv(EnvName("config"))
+	}
+
+	*cfg = *Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+		cfg.file = path
+	}
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		if value, ok := lookupEnv(EnvName(f.Name)); ok {
+			if err := f.Value.Set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value %q: %v", EnvName(f.Name), value, err))
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	for name, value := range explicit {
+		if name != "config" {
+			fs.Set(name, value)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// File returns the config file the configuration was loaded from, or "" when
+// there was none
+func (c *Config) File() string {
+	return c.file
+}
+
+// loadFile overlays the settings present in a YAML or JSON file onto c
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %v", err)
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("parsing config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// Validate checks every setting, reporting all problems at once
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.ListenAddr); err != nil {
+		invalid("listenAddr %q: %v", c.ListenAddr, err)
+	}
+	if _, _, err := net.SplitHostPort(c.Metrics.Addr); err != nil {
+		invalid("metrics.addr %q: %v", c.Metrics.Addr, err)
+	}
+	if c.Metrics.Addr == c.ListenAddr {
+		invalid("metrics.addr must differ from listenAddr")
+	}
+	if c.GRPC.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.GRPC.Addr); err != nil {
+			invalid("grpc.addr %q: %v", c.GRPC.Addr, err)
+		}
+		if c.GRPC.Addr == c.ListenAddr || c.GRPC.Addr == c.Metrics.Addr {
+			invalid("grpc.addr must differ from listenAddr and metrics.addr")
+		}
+	}
+
+	if c.TLS.Enabled {
+		for _, f := range []struct {
+			field string
+			path  string
+		}{
+			{"tls.certFile", c.TLS.CertFile},
+			{"tls.keyFile", c.TLS.KeyFile},
+			{"tls.caFile", c.TLS.CAFile},
+		} {
+			if err := fileExists(f.path); err != nil {
+				invalid("%s: %v", f.field, err)
+			}
+		}
+	} else if c.Metrics.TLS {
+		invalid("metrics.tls requires tls.enabled")
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		invalid("log.level: %v", err)
+	}
+
+	for _, d := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"informer.resyncPeriod", c.Informer.ResyncPeriod.Duration},
+		{"timeouts.updateScale", c.Timeouts.UpdateScale.Duration},
+		{"timeouts.shutdown", c.Timeouts.Shutdown.Duration},
+		{"timeouts.tlsHandshake", c.Timeouts.TLSHandshake.Duration},
+		{"health.apiCheckInterval", c.Health.APICheckInterval.Duration},
+		{"health.apiCheckTimeout", c.Health.APICheckTimeout.Duration},
+		{"health.informerMaxWatchAge", c.Health.InformerMaxWatchAge.Duration},
+		{"audit.webhookFlushInterval", c.Audit.WebhookFlushInterval.Duration},
+		{"audit.webhookRetryBackoff", c.Audit.WebhookRetryBackoff.Duration},
+	} {
+		if d.value <= 0 {
+			invalid("%s must be a positive duration, got %v", d.field, d.value)
+		}
+	}
+	if c.Health.APIFailureThreshold < 1 {
+		invalid("health.apiFailureThreshold must be at least 1, got %d", c.Health.APIFailureThreshold)
+	}
+
+	if c.ChangePolicyFile != "" {
+		if err := fileExists(c.ChangePolicyFile); err != nil {
+			invalid("changePolicyFile: %v", err)
+		}
+	}
+	if c.PriceTableFile != "" {
+		if err := fileExists(c.PriceTableFile); err != nil {
+			invalid("priceTableFile: %v", err)
+		}
+	}
+
+	if c.Audit.MaxSizeMB < 1 {
+		invalid("audit.maxSizeMB must be at least 1, got %d", c.Audit.MaxSizeMB)
+	}
+	if c.Audit.MaxBackups < 0 {
+		invalid("audit.maxBackups must not be negative, got %d", c.Audit.MaxBackups)
+	}
+	if c.Audit.WebhookBatchSize < 1 {
+		invalid("audit.webhookBatchSize must be at least 1, got %d", c.Audit.WebhookBatchSize)
+	}
+	if c.Audit.WebhookBufferSize < 1 {
+		invalid("audit.webhookBufferSize must be at least 1, got %d", c.Audit.WebhookBufferSize)
+	}
+	if c.Audit.WebhookMaxRetries < 0 {
+		invalid("audit.webhookMaxRetries must not be negative, got %d", c.Audit.WebhookMaxRetries)
+	}
+	if c.Audit.WebhookURL != "" {
+		// The URL may carry credentials, so it is left out of the message
+		if u, err := url.Parse(c.Audit.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("audit.webhookURL must be an absolute http or https URL")
+		}
+	}
+
+	if c.Scale.Cooldown.Duration < 0 {
+		invalid("scale.cooldown must not be negative, got %v", c.Scale.Cooldown.Duration)
+	}
+	for i, namespace := range c.Scale.ProtectedNamespaces {
+		if !dnsLabel.MatchString(namespace) {
+			invalid("scale.protectedNamespaces[%d] must be a namespace name, got %q", i, namespace)
+		}
+	}
+	if _, err := policy.NewProtection(nil, c.Scale.ProtectedSelectors); err != nil {
+		invalid("scale.protectedSelectors: %v", err)
+	}
+	switch c.Scale.QuotaCheck {
+	case QuotaCheckEnforce, QuotaCheckWarn, QuotaCheckOff:
+	default:
+		invalid("scale.quotaCheck must be one of enforce, warn or off, got %q", c.Scale.QuotaCheck)
+	}
+	if (c.Self.Namespace == "") != (c.Self.Deployment == "") {
+		invalid("self.namespace and self.deployment must be set together")
+	}
+	routes := make([]string, 0, len(c.RateLimits))
+	for route := range c.RateLimits {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		limits := c.RateLimits[route]
+		if limits.PerIdentity != nil {
+			if err := limits.PerIdentity.Validate(); err != nil {
+				invalid("rateLimits[%q].perIdentity: %v", route, err)
+			}
+		}
+		if limits.PerDeployment != nil {
+			if err := limits.PerDeployment.Validate(); err != nil {
+				invalid("rateLimits[%q].perDeployment: %v", route, err)
+			}
+		}
+	}
+
+	if !dnsLabel.MatchString(c.ClusterName) {
+		invalid("clusterName must be a DNS label, got %q", c.ClusterName)
+	}
+	clusterNames := map[string]bool{c.ClusterName: true}
+	for i, cluster := range c.Clusters {
+		switch {
+		case !dnsLabel.MatchString(cluster.Name):
+			invalid("clusters[%d].name must be a DNS label, got %q", i, cluster.Name)
+		case clusterNames[cluster.Name]:
+			invalid("clusters[%d].name %q is already used", i, cluster.Name)
+		}
+		clusterNames[cluster.Name] = true
+		if cluster.Kubeconfig == "" && cluster.Context == "" {
+			invalid("clusters[%d] must set kubeconfig or context", i)
+		}
+		if cluster.Kubeconfig != "" {
+			if err := fileExists(cluster.Kubeconfig); err != nil {
+				invalid("clusters[%d].kubeconfig: %v", i, err)
+			}
+		}
+	}
+
+	watched := make(map[string]bool, len(c.Namespaces))
+	for i, namespace := range c.Namespaces {
+		switch {
+		case !dnsLabel.MatchString(namespace):
+			invalid("namespaces[%d] must be a namespace name, got %q", i, namespace)
+		case watched[namespace]:
+			invalid("namespaces[%d] %q is listed more than once", i, namespace)
+		}
+		watched[namespace] = true
+	}
+
+	switch c.Tracing.Exporter {
+	case "", tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		invalid("tracing.exporter must be one of otlp, stdout or none, got %q", c.Tracing.Exporter)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Redacted returns a copy of the configuration safe to expose, with secrets masked
+func (c *Config) Redacted() *Config {
+	out := *c
+	if out.Audit.WebhookURL != "" {
+		out.Audit.WebhookURL = redacted
+	}
+	return &out
+}
+
+func fileExists(path string) error {
+	if path == "" {
+		return errors.New("must be set")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/ratelimit"
+)
+
+// env returns a lookup function serving the given variables
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeFile(t, dir, "config.yaml", `
+listenAddr: ":9443"
+tls:
+  enabled: false
+timeouts:
+  updateScale: 20s
+  shutdown: 15s
+log:
+  level: warn
+`)
+
+	cfg, err := Load("test", []string{"--config", configFile, "--shutdown-timeout=30s"}, env(map[string]string{
+		"SCALER_UPDATE_SCALE_TIMEOUT":  "25s",
+		"SCALER_SHUTDOWN_TIMEOUT":      "1m",
+		"SCALER_TLS_HANDSHAKE_TIMEOUT": "3s",
+	}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// The file overrides defaults
+	if cfg.ListenAddr != ":9443" || cfg.Log.Level != "warn" {
+		t.Errorf("file settings not applied: %+v", cfg)
+	}
+	// Environment variables override the file
+	if cfg.Timeouts.UpdateScale.Duration != 25*time.Second {
+		t.Errorf("updateScale = %v, want 25s from the environment", cfg.Timeouts.UpdateScale.Duration)
+	}
+	// Flags override environment variables
+	if cfg.Timeouts.Shutdown.Duration != 30*time.Second {
+		t.Errorf("shutdown = %v, want 30s from the flag", cfg.Timeouts.Shutdown.Duration)
+	}
+	if cfg.Timeouts.TLSHandshake.Duration != 3*time.Second {
+		t.Errorf("tlsHandshake = %v, want 3s from the environment", cfg.Timeouts.TLSHandshake.Duration)
+	}
+	// Unset settings keep their defaults
+	if cfg.Informer.ResyncPeriod.Duration != 10*time.Minute || cfg.Metrics.Addr != ":9090" {
+		t.Errorf("defaults not kept: %+v", cfg)
+	}
+}
+
+func TestLoadScaleSafeguards(t *testing.T) {
+	configFile := writeFile(t, t.TempDir(), "config.yaml", `
+tls:
+  enabled: false
+rateLimits:
+  POST /replica-count:
+    perIdentity:
+      perMinute: 30
+      burst: 5
+scale:
+  protectedSelectors:
+  - scaler.example.com/protected=true
+`)
+
+	cfg, err := Load("test", []string{"--config", configFile, "--scale-cooldown=30s"}, env(map[string]string{
+		"SCALER_FORCE_IDENTITIES":     "sre-oncall, release-bot",
+		"SCALER_PDB_FORCE_IDENTITIES": "sre-oncall",
+		"SCALER_NAMESPACES":           "payments,checkout",
+		"SCALER_SELF_NAMESPACE":       "scaler",
+		"SCALER_SELF_DEPLOYMENT":      "k8s-deployment-scaler",
+		"SCALER_QUOTA_CHECK":          "warn",
+	}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	limits := cfg.RateLimits["POST /replica-count"]
+	if limits.PerIdentity == nil || limits.PerIdentity.PerMinute != 30 || limits.PerIdentity.Burst != 5 || limits.PerDeployment != nil {
+		t.Errorf("rate limits = %+v", limits)
+	}
+	if cfg.Scale.Cooldown.Duration != 30*time.Second {
+		t.Errorf("cooldown = %v, want 30s", cfg.Scale.Cooldown.Duration)
+	}
+	if strings.Join(cfg.Scale.ForceIdentities, ",") != "sre-oncall,release-bot" {
+		t.Errorf("force identities = %q", cfg.Scale.ForceIdentities)
+	}
+	if strings.Join(cfg.Scale.PDBForceIdentities, ",") != "sre-oncall" {
+		t.Errorf("PDB force identities = %q", cfg.Scale.PDBForceIdentities)
+	}
+	if strings.Join(cfg.Namespaces, ",") != "payments,checkout" {
+		t.Errorf("namespaces = %q", cfg.Namespaces)
+	}
+	// kube-system stays protected by default alongside the configured selectors
+	if strings.Join(cfg.Scale.ProtectedNamespaces, ",") != "kube-system" || strings.Join(cfg.Scale.ProtectedSelectors, ";") != "scaler.example.com/protected=true" {
+		t.Errorf("protection = %q, %q", cfg.Scale.ProtectedNamespaces, cfg.Scale.ProtectedSelectors)
+	}
+	if cfg.Scale.QuotaCheck != QuotaCheckWarn {
+		t.Errorf("quota check = %q, want warn", cfg.Scale.QuotaCheck)
+	}
+	if cfg.Self != (SelfConfig{Namespace: "scaler", Deployment: "k8s-deployment-scaler"}) {
+		t.Errorf("self = %+v", cfg.Self)
+	}
+}
+
+func TestLoadClusters(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := writeFile(t, dir, "east.kubeconfig", "apiVersion: v1\nkind: Config\n")
+	configFile := writeFile(t, dir, "config.yaml", `
+tls:
+  enabled: false
+clusterName: central
+clusters:
+- name: east
+  kubeconfig: `+kubeconfig+`
+- name: west
+  context: kind-west
+`)
+
+	cfg, err := Load("test", []string{"--config", configFile}, env(nil))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ClusterName != "central" || len(cfg.Clusters) != 2 || cfg.Clusters[0].Kubeconfig != kubeconfig || cfg.Clusters[1].Context != "kind-west" {
+		t.Errorf("clusters = %q, %+v", cfg.ClusterName, cfg.Clusters)
+	}
+	if cfg.File() != configFile {
+		t.Errorf("File() = %q, want %q", cfg.File(), configFile)
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	configFile := writeFile(t, t.TempDir(), "config.yaml", "listenAddr: \":7443\"\ntls:\n  enabled: false\n")
+
+	cfg, err := Load("test", nil, env(map[string]string{"SCALER_CONFIG": configFile}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ListenAddr != ":7443" {
+		t.Errorf("listenAddr = %q, want the value from SCALER_CONFIG's file", cfg.ListenAddr)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "Unknown field in file",
+			args:    []string{"--config", writeFile(t, dir, "typo.yaml", "listenAdr: \":8443\"\n")},
+			wantErr: `unknown field "listenAdr"`,
+		},
+		{
+			name:    "Missing file",
+			args:    []string{"--config", filepath.Join(dir, "missing.yaml")},
+			wantErr: "reading config file",
+		},
+		{
+			name:    "Bad environment value",
+			env:     map[string]string{"SCALER_SHUTDOWN_TIMEOUT": "soon"},
+			wantErr: `SCALER_SHUTDOWN_TIMEOUT: invalid value "soon"`,
+		},
+		{
+			name:    "Unknown flag",
+			args:    []string{"--listen"},
+			wantErr: "flag provided but not defined",
+		},
+		{
+			name:    "Missing certificates",
+			args:    []string{"--tls-cert-file", filepath.Join(dir, "missing.pem")},
+			wantErr: "tls.certFile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load("test", tt.args, env(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.TLS.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config without TLS is invalid: %v", err)
+	}
+
+	cfg.ListenAddr = "8443"
+	cfg.Log.Level = "verbose"
+	cfg.Timeouts.UpdateScale.Duration = 0
+	cfg.Health.APIFailureThreshold = 0
+	cfg.Audit.WebhookURL = "ftp://audit.example.com"
+	cfg.Audit.WebhookBatchSize = 0
+	cfg.Audit.WebhookMaxRetries = -1
+	cfg.Audit.WebhookRetryBackoff.Duration = 0
+	cfg.Tracing.Exporter = "zipkin"
+	cfg.Metrics.TLS = true
+	cfg.GRPC.Addr = cfg.Metrics.Addr
+	cfg.Scale.Cooldown.Duration = -time.Second
+	cfg.RateLimits = map[string]RouteRateLimit{
+		"POST /replica-count": {PerDeployment: &ratelimit.Limit{PerMinute: 10}},
+	}
+	cfg.Clusters = []ClusterConfig{
+		{Name: "local", Context: "kind-local"},
+		{Name: "East", Context: "east"},
+		{Name: "west"},
+		{Name: "south", Kubeconfig: "missing-kubeconfig"},
+	}
+	cfg.Namespaces = []string{"payments", "Payments", "payments"}
+	cfg.Scale.ProtectedNamespaces = []string{"kube_system"}
+	cfg.Scale.ProtectedSelectors = []string{"app in ("}
+	cfg.Scale.QuotaCheck = "strict"
+	cfg.PriceTableFile = "missing-prices.yaml"
+	cfg.Self.Namespace = "scaler"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() accepted an invalid config")
+	}
+	for _, want := range []string{
+		"listenAddr",
+		"log.level",
+		"timeouts.updateScale",
+		"health.apiFailureThreshold",
+		"audit.webhookURL",
+		"audit.webhookBatchSize",
+		"audit.webhookMaxRetries",
+		"audit.webhookRetryBackoff",
+		"tracing.exporter",
+		"metrics.tls requires tls.enabled",
+		"grpc.addr must differ",
+		"scale.cooldown",
+		`rateLimits["POST /replica-count"].perDeployment: burst must be at least 1`,
+		`clusters[0].name "local" is already used`,
+		`clusters[1].name must be a DNS label`,
+		"clusters[2] must set kubeconfig or context",
+		"clusters[3].kubeconfig",
+		`namespaces[1] must be a namespace name, got "Payments"`,
+		`namespaces[2] "payments" is listed more than once`,
+		`scale.protectedNamespaces[0] must be a namespace name, got "kube_system"`,
+		`scale.protectedSelectors: invalid protected selector "app in ("`,
+		`scale.quotaCheck must be one of enforce, warn or off, got "strict"`,
+		"priceTableFile",
+		"self.namespace and self.deployment must be set together",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.Audit.WebhookURL = "https://token@audit.example.com"
+
+	if got := cfg.Redacted().Audit.WebhookURL; got != redacted {
+		t.Errorf("redacted webhookURL = %q", got)
+	}
+	if cfg.Audit.WebhookURL != "https://token@audit.example.com" {
+		t.Error("Redacted modified the original config")
+	}
+}
@@ -0,0 +1,125 @@
+// Package cost prices the CPU and memory requested by pods, per node pool
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// HoursPerMonth is the length of an average month, 365 days * 24 hours / 12
+const HoursPerMonth = 730
+
+// DefaultPool names the prices of pods matching no pool
+const DefaultPool = "default"
+
+// Prices are the hourly prices of a requested CPU core and GiB of memory
+type Prices struct {
+	CPUHour       float64 `json:"cpuHour"`
+	MemoryGiBHour float64 `json:"memoryGiBHour"`
+}
+
+// Hourly returns the hourly price of cpu cores and memory bytes
+func (p Prices) Hourly(cpu float64, memory int64) float64 {
+	return cpu*p.CPUHour + float64(memory)/(1<<30)*p.MemoryGiBHour
+}
+
+// Pool prices the pods scheduled to a node pool
+type Pool struct {
+	Name string `json:"name"`
+	// NodeSelector holds labels of the pool's nodes. A pod belongs to the pool
+	// when its node selector requires every one of them.
+	NodeSelector map[string]string `json:"nodeSelector"`
+	Prices
+}
+
+// PriceTable holds the prices of each node pool, and the default prices of pods
+// matching none
+type PriceTable struct {
+	Currency string `json:"currency"`
+	Default  Prices `json:"default"`
+	Pools    []Pool `json:"pools,omitempty"`
+}
+
+// LoadPriceTable reads a price table from a YAML or JSON file
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading price table file: %v", err)
+	}
+
+	var table PriceTable
+	if err := yaml.UnmarshalStrict(data, &table); err != nil {
+		return nil, fmt.Errorf("parsing price table file: %v", err)
+	}
+
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+// validate checks that the currency is set, every price is non-negative and
+// every pool has a unique name and a node selector
+func (t *PriceTable) validate() error {
+	var errs []error
+	if t.Currency == "" {
+		errs = append(errs, errors.New("currency must be set"))
+	}
+	if err := t.Default.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("default: %v", err))
+	}
+	names := map[string]bool{DefaultPool: true}
+	for i, pool := range t.Pools {
+		switch {
+		case pool.Name == "":
+			errs = append(errs, fmt.Errorf("pools[%d].name must be set", i))
+		case names[pool.Name]:
+			errs = append(errs, fmt.Errorf("pools[%d].name %q is already used", i, pool.Name))
+		}
+		names[pool.Name] = true
+		if len(pool.NodeSelector) == 0 {
+			errs = append(errs, fmt.Errorf("pools[%d].nodeSelector must be set", i))
+		}
+		if err := pool.Prices.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("pools[%d]: %v", i, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid price table: %w", err)
+	}
+	return nil
+}
+
+func (p Prices) validate() error {
+	for _, price := range []float64{p.CPUHour, p.MemoryGiBHour} {
+		if price < 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+			return fmt.Errorf("prices must be non-negative numbers, got cpuHour %v and memoryGiBHour %v", p.CPUHour, p.MemoryGiBHour)
+		}
+	}
+	return nil
+}
+
+// PoolFor returns the first pool whose node labels the pod's node selector
+// requires, and its prices, or DefaultPool and the default prices
+func (t *PriceTable) PoolFor(nodeSelector map[string]string) (string, Prices) {
+	for _, pool := range t.Pools {
+		if selects(nodeSelector, pool.NodeSelector) {
+			return pool.Name, pool.Prices
+		}
+	}
+	return DefaultPool, t.Default
+}
+
+// selects reports whether the node selector requires every one of the labels
+func selects(nodeSelector, labels map[string]string) bool {
+	for key, value := range labels {
+		if required, ok := nodeSelector[key]; !ok || required != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package cost
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePriceTable(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Error writing price table: %v", err)
+	}
+	return path
+}
+
+func TestLoadPriceTable(t *testing.T) {
+	path := writePriceTable(t, `
+currency: USD
+default:
+  cpuHour: 0.04
+  memoryGiBHour: 0.005
+pools:
+- name: spot
+  nodeSelector:
+    cloud.google.com/gke-spot: "true"
+  cpuHour: 0.01
+  memoryGiBHour: 0.001
+- name: gpu
+  nodeSelector:
+    pool: gpu
+    accelerator: a100
+  cpuHour: 0.1
+`)
+
+	table, err := LoadPriceTable(path)
+	if err != nil {
+		t.Fatalf("LoadPriceTable() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		nodeSelector map[string]string
+		wantPool     string
+		wantPrices   Prices
+	}{
+		{name: "No node selector", wantPool: DefaultPool, wantPrices: Prices{CPUHour: 0.04, MemoryGiBHour: 0.005}},
+		{name: "Pool labels among others", nodeSelector: map[string]string{"cloud.google.com/gke-spot": "true", "zone": "a"}, wantPool: "spot", wantPrices: Prices{CPUHour: 0.01, MemoryGiBHour: 0.001}},
+		{name: "Some of the pool's labels", nodeSelector: map[string]string{"pool": "gpu"}, wantPool: DefaultPool, wantPrices: Prices{CPUHour: 0.04, MemoryGiBHour: 0.005}},
+		{name: "Every label", nodeSelector: map[string]string{"pool": "gpu", "accelerator": "a100"}, wantPool: "gpu", wantPrices: Prices{CPUHour: 0.1}},
+		{name: "Different value", nodeSelector: map[string]string{"cloud.google.com/gke-spot": "false"}, wantPool: DefaultPool, wantPrices: Prices{CPUHour: 0.04, MemoryGiBHour: 0.005}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, prices := table.PoolFor(tt.nodeSelector)
+			if pool != tt.wantPool || prices != tt.wantPrices {
+				t.Errorf("PoolFor() = %s, %+v; want %s, %+v", pool, prices, tt.wantPool, tt.wantPrices)
+			}
+		})
+	}
+
+	// Two cores and 4GiB at the default prices
+	if got := table.Default.Hourly(2, 4<<30); got != 0.1 {
+		t.Errorf("Hourly() = %v, want 0.1", got)
+	}
+}
+
+func TestLoadPriceTableInvalid(t *testing.T) {
+	path := writePriceTable(t, `
+default:
+  cpuHour: -1
+pools:
+- name: default
+  nodeSelector:
+    pool: a
+- nodeSelector:
+    pool: b
+- name: c
+`)
+
+	_, err := LoadPriceTable(path)
+	if err == nil {
+		t.Fatal("LoadPriceTable() accepted an invalid price table")
+	}
+	for _, want := range []string{
+		"currency must be set",
+		"default: prices must be non-negative",
+		`pools[0].name "default" is already used`,
+		"pools[1].name must be set",
+		"pools[2].nodeSelector must be set",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadPriceTable() error does not mention %s: %v", want, err)
+		}
+	}
+}
+
+func TestLoadPriceTableUnknownField(t *testing.T) {
+	path := writePriceTable(t, "currency: USD\ndefault:\n  cpuPerHour: 0.04\n")
+
+	if _, err := LoadPriceTable(path); err == nil {
+		t.Error("LoadPriceTable() accepted an unknown field")
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Annotations recording the most recent scale on the Deployment
+const (
+	AnnotationLastScaledBy    = "k8s-deployment-scaler/last-scaled-by"
+	AnnotationLastScaleReason = "k8s-deployment-scaler/last-scale-reason"
+	AnnotationLastScaleTicket = "k8s-deployment-scaler/last-scale-ticket"
+	AnnotationLastScaleTime   = "k8s-deployment-scaler/last-scale-time"
+)
+
+// Annotations recording the bounds an HPA had before a scale with mode=hpa
+// pinned them, for restoring them later
+const (
+	AnnotationOriginalMinReplicas = "k8s-deployment-scaler/original-min-replicas"
+	AnnotationOriginalMaxReplicas = "k8s-deployment-scaler/original-max-replicas"
+)
+
+// annotateLastScale records who scaled the deployment, why and when.
+// Empty reason or ticket values remove the stale annotation from a previous scale.
+// It returns the deployment's resource version after the change.
+func (h *Handlers) annotateLastScale(ctx context.Context, namespace, name, actor, reason, ticket string, scaledAt time.Time) (string, error) {
+	annotations := map[string]interface{}{
+		AnnotationLastScaledBy:    actor,
+		AnnotationLastScaleReason: nilIfEmpty(reason),
+		AnnotationLastScaleTicket: nilIfEmpty(ticket),
+		AnnotationLastScaleTime:   scaledAt.Format(time.RFC3339),
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	patched, err := h.clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return "", err
+	}
+	return patched.ResourceVersion, nil
+}
+
+// nilIfEmpty maps an empty string to nil so a merge patch deletes the key
+func nilIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+)
+
+// Errors returned by a ClusterLookup
+var (
+	// ErrClusterNotFound means no cluster of that name is configured
+	ErrClusterNotFound = errors.New("cluster not found")
+	// ErrClusterNotSynced means the cluster's deployment cache is still filling,
+	// so answers from it would be incomplete
+	ErrClusterNotSynced = errors.New("cluster not synced")
+)
+
+// ClusterLookup returns the handlers serving the named cluster
+type ClusterLookup func(name string) (*Handlers, error)
+
+// ForCluster serves a route under /clusters/{cluster} with the handlers of the
+// cluster named in the path
+func ForCluster(lookup ClusterLookup, handler func(*Handlers, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("cluster")
+		h, err := lookup(name)
+		if err != nil {
+			writeProblem(w, clusterError(name, err))
+			return
+		}
+		handler(h, w, r)
+	}
+}
+
+// clusterError maps a ClusterLookup error to the response for it
+func clusterError(name string, err error) apiError {
+	switch {
+	case errors.Is(err, ErrClusterNotFound):
+		return apiError{Message: fmt.Sprintf("Cluster %s not found", name), Code: http.StatusNotFound}
+	case errors.Is(err, ErrClusterNotSynced):
+		return apiError{Message: fmt.Sprintf("Cluster %s has not synced its deployments yet", name), Code: http.StatusServiceUnavailable}
+	default:
+		return apiError{Message: err.Error(), Code: http.StatusInternalServerError}
+	}
+}
+
+// ListClusters handles the /clusters endpoint
+func ListClusters(w http.ResponseWriter, r *http.Request, clusters apiv1.ClusterList) {
+	if err := encodeAndWriteJSON(w, clusters); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
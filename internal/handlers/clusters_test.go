@@ -0,0 +1,214 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/server"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newClusterClientset returns a fake clientset holding the deployment default/web
+func newClusterClientset(replicas int32) *fake.Clientset {
+	fakeClientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)},
+	})
+	addScaleReactors(fakeClientset)
+	return fakeClientset
+}
+
+// newMultiClusterServer serves the local cluster and the given remote ones,
+// each holding default/web, once every cache has synced
+func newMultiClusterServer(t *testing.T, remote map[string]*fake.Clientset) *server.Server {
+	t.Helper()
+
+	fakeClientset, deploymentInformer, stopCh := setupTestEnvironment()
+	t.Cleanup(func() { close(stopCh) })
+	if _, err := fakeClientset.AppsV1().Deployments("default").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(),
+		server.WithClusterConnector(func(c config.ClusterConfig) (kubernetes.Interface, error) {
+			return remote[c.Name], nil
+		}))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	t.Cleanup(srv.Clusters.Stop)
+
+	var clusters []config.ClusterConfig
+	for name := range remote {
+		clusters = append(clusters, config.ClusterConfig{Name: name, Context: name})
+	}
+	if err := srv.Clusters.Sync(clusters); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, c := range srv.Clusters.List() {
+		if !cache.WaitForCacheSync(ctx.Done(), c.Synced) {
+			t.Fatalf("cluster %s did not sync", c.Name)
+		}
+	}
+	// Let the local informer see the deployment
+	time.Sleep(100 * time.Millisecond)
+	return srv
+}
+
+func TestClusterRoutes(t *testing.T) {
+	t.Parallel()
+
+	east := newClusterClientset(3)
+	srv := newMultiClusterServer(t, map[string]*fake.Clientset{"east": east, "west": newClusterClientset(5)})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "List clusters",
+			method:         "GET",
+			path:           "/clusters",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[{"name":"east","local":false,"synced":true},{"name":"local","local":true,"synced":true},{"name":"west","local":false,"synced":true}]}`,
+		},
+		{
+			name:           "Scale in a remote cluster",
+			method:         "GET",
+			path:           "/clusters/east/api/v1/namespaces/default/deployments/web/scale",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":3}`,
+		},
+		{
+			name:           "Scale in another remote cluster",
+			method:         "GET",
+			path:           "/clusters/west/api/v1/namespaces/default/deployments/web/scale",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":5}`,
+		},
+		{
+			name:           "Local cluster by name",
+			method:         "GET",
+			path:           "/clusters/local/api/v1/namespaces/default/deployments/web/scale",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":1}`,
+		},
+		{
+			name:           "Across every cluster",
+			method:         "GET",
+			path:           "/global/deployments?name=web",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"items":[{"cluster":"east","namespace":"default","name":"web","replicas":3},` +
+				`{"cluster":"local","namespace":"default","name":"web","replicas":1},` +
+				`{"cluster":"west","namespace":"default","name":"web","replicas":5}],"partial":false}`,
+		},
+		{
+			name:           "Set scale in a remote cluster",
+			method:         "PUT",
+			path:           "/clusters/east/api/v1/namespaces/default/deployments/web/scale",
+			body:           `{"replicas":4}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":4}`,
+		},
+		{
+			name:           "Other clusters are unaffected",
+			method:         "GET",
+			path:           "/api/v1/namespaces/default/deployments/web/scale",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":1}`,
+		},
+		{
+			name:           "List in a remote cluster",
+			method:         "GET",
+			path:           "/clusters/west/api/v1/deployments",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[{"namespace":"default","name":"web","replicas":5}]}`,
+		},
+		{
+			name:           "Unknown cluster",
+			method:         "GET",
+			path:           "/clusters/north/api/v1/deployments",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:NotFound","title":"Not found","status":404,"detail":"Cluster north not found","instance":"test-request-id","reason":"NotFound"}`,
+		},
+	}
+
+	// Run in order: the scale affects later reads
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("X-Request-ID", testRequestID)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rr.Code, tt.expectedStatus, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tt.expectedBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.expectedBody)
+		}
+	}
+
+	// The scale went to the remote cluster's API
+	deployment, err := east.AppsV1().Deployments("default").Get(context.TODO(), "web", metav1.GetOptions{})
+	if err != nil || *deployment.Spec.Replicas != 4 {
+		t.Errorf("east's deployment = %v, %v; want 4 replicas", deployment, err)
+	}
+}
+
+func TestClusterReadiness(t *testing.T) {
+	t.Parallel()
+
+	srv := newMultiClusterServer(t, map[string]*fake.Clientset{"east": newClusterClientset(1)})
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz?verbose", nil))
+	want := "[+]ping ok\n[+]informer-sync ok\n[+]informer-watch ok\n" +
+		"[+]cluster-east-informer-sync ok\n[+]cluster-east-informer-watch ok\n[+]cluster-east-kubernetes-api ok\n" +
+		"readyz check passed\n"
+	if rr.Code != http.StatusOK || rr.Body.String() != want {
+		t.Errorf("readyz = %d %q, want %q", rr.Code, rr.Body.String(), want)
+	}
+
+	// Removing the cluster removes its checks and routes
+	if err := srv.Clusters.Sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz?verbose", nil))
+	if strings.Contains(rr.Body.String(), "cluster-east") {
+		t.Errorf("readyz still checks the removed cluster: %s", rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/clusters", nil))
+	var list apiv1.ClusterList
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil || len(list.Items) != 1 {
+		t.Errorf("clusters after removal = %+v, %v", list, err)
+	}
+	rr = httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/clusters/east/healthz", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("removed cluster's route returned %d, want 404", rr.Code)
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"k8s-deployment-scaler/internal/audit"
+	"k8s-deployment-scaler/internal/cost"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// errNoPriceTable answers cost requests when no price table is configured
+var errNoPriceTable = apiError{
+	Message: "Cost estimates need a price table, and none is configured",
+	Code:    http.StatusNotFound,
+}
+
+// GetDeploymentCost handles GET /api/v1/namespaces/{namespace}/deployments/{name}/cost,
+// estimating the deployment's cost and, given the replicas query parameter, the
+// change scaling it to that count would make
+func (h *Handlers) GetDeploymentCost(w http.ResponseWriter, r *http.Request) {
+	namespace := r.PathValue("namespace")
+	deploymentName := r.PathValue("name")
+	audit.SetTarget(r.Context(), namespace, deploymentName)
+
+	if apiErr := h.checkNamespace(namespace); apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+	if h.prices == nil {
+		writeProblem(w, errNoPriceTable)
+		return
+	}
+	target, apiErr := parseTargetReplicas(r)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+
+	deployment, exists := h.getDeploymentFromCache(r.Context(), namespace, deploymentName)
+	if !exists {
+		writeProblem(w, apiError{
+			Message: "Deployment not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	if err := encodeAndWriteJSON(w, h.deploymentCost(deployment, target)); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// GetNamespaceCost handles GET /api/v1/namespaces/{namespace}/cost, estimating
+// the cost of each deployment in the namespace and their total
+func (h *Handlers) GetNamespaceCost(w http.ResponseWriter, r *http.Request) {
+	namespace := r.PathValue("namespace")
+	if apiErr := h.checkNamespace(namespace); apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+	if h.prices == nil {
+		writeProblem(w, errNoPriceTable)
+		return
+	}
+
+	list, apiErr := h.listDeployments(r.Context(), namespace)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+
+	response := apiv1.NamespaceCost{
+		Namespace:   namespace,
+		Deployments: make([]apiv1.DeploymentCost, 0, len(list)),
+	}
+	var hourly float64
+	for _, deployment := range list {
+		response.Deployments = append(response.Deployments, h.deploymentCost(deployment, nil))
+		_, perReplica := h.replicaHourly(deployment)
+		hourly += perReplica * float64(replicasOf(deployment))
+	}
+	response.Total = h.costOf(hourly)
+
+	if err := encodeAndWriteJSON(w, response); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// parseTargetReplicas reads the optional replicas query parameter of a cost estimate
+func parseTargetReplicas(r *http.Request) (*int32, *apiError) {
+	value := r.URL.Query().Get("replicas")
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || parsed < 0 {
+		return nil, &apiError{
+			Message: "replicas must be a non-negative integer",
+			Code:    http.StatusBadRequest,
+		}
+	}
+	replicas := int32(parsed)
+	return &replicas, nil
+}
+
+// deploymentCost estimates the deployment's cost at its current count and, when
+// target is not nil, at target replicas
+func (h *Handlers) deploymentCost(deployment *appsv1.Deployment, target *int32) apiv1.DeploymentCost {
+	pool, perReplica := h.replicaHourly(deployment)
+	replicas := replicasOf(deployment)
+	estimate := apiv1.DeploymentCost{
+		Namespace:  deployment.Namespace,
+		Name:       deployment.Name,
+		Pool:       pool,
+		Replicas:   replicas,
+		PerReplica: h.costOf(perReplica),
+		Current:    h.costOf(perReplica * float64(replicas)),
+	}
+	if target != nil {
+		targetCost := h.costOf(perReplica * float64(*target))
+		delta := h.costOf(perReplica * float64(*target-replicas))
+		estimate.TargetReplicas = target
+		estimate.Target = &targetCost
+		estimate.Delta = &delta
+	}
+	return estimate
+}
+
+// costDelta estimates the change in the deployment's cost from scaling it from
+// oldReplicas to newReplicas, or returns nil without a price table or deployment
+func (h *Handlers) costDelta(deployment *appsv1.Deployment, oldReplicas, newReplicas int32) *apiv1.Cost {
+	if h.prices == nil || deployment == nil {
+		return nil
+	}
+	_, perReplica := h.replicaHourly(deployment)
+	delta := h.costOf(perReplica * float64(newReplicas-oldReplicas))
+	return &delta
+}
+
+// replicaHourly returns the pool pricing the deployment's pods, by their node
+// selector, and the hourly price of one pod's CPU and memory requests
+func (h *Handlers) replicaHourly(deployment *appsv1.Deployment) (string, float64) {
+	spec := &deployment.Spec.Template.Spec
+	requests := podRequests(spec)
+	pool, prices := h.prices.PoolFor(spec.NodeSelector)
+	return pool, prices.Hourly(float64(requests.Cpu().MilliValue())/1000, requests.Memory().Value())
+}
+
+// costOf returns the cost of an hourly price and its monthly equivalent, each
+// rounded to four decimal places
+func (h *Handlers) costOf(hourly float64) apiv1.Cost {
+	return apiv1.Cost{
+		Currency: h.prices.Currency,
+		Hourly:   roundPrice(hourly),
+		Monthly:  roundPrice(hourly * cost.HoursPerMonth),
+	}
+}
+
+func roundPrice(price float64) float64 {
+	// Adding zero turns a negative zero, which would encode as -0, positive
+	return math.Round(price*1e4)/1e4 + 0
+}
@@ -0,0 +1,189 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/cost"
+	"k8s-deployment-scaler/internal/server"
+	scalerv1 "k8s-deployment-scaler/pkg/api/scaler/v1"
+
+	"google.golang.org/grpc/credentials/insecure"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// withRequests gives the deployment's pods one container requesting cpu and memory
+func withRequests(spec *corev1.PodSpec, cpu, memory string) {
+	spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}},
+	}}
+}
+
+func TestCostEstimates(t *testing.T) {
+	t.Parallel()
+
+	// web runs in the spot pool at 0.007 an hour per pod, api at the default
+	// prices at 0.05
+	web := newDeployment("default", "web", 3, nil)
+	withRequests(&web.Spec.Template.Spec, "500m", "1Gi")
+	web.Spec.Template.Spec.NodeSelector = map[string]string{"pool": "spot", "zone": "a"}
+	api := newDeployment("default", "api", 2, nil)
+	withRequests(&api.Spec.Template.Spec, "1", "2Gi")
+	fakeClientset := fake.NewSimpleClientset(web, api)
+	addScaleReactors(fakeClientset)
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	prices := &cost.PriceTable{
+		Currency: "USD",
+		Default:  cost.Prices{CPUHour: 0.04, MemoryGiBHour: 0.005},
+		Pools: []cost.Pool{{
+			Name:         "spot",
+			NodeSelector: map[string]string{"pool": "spot"},
+			Prices:       cost.Prices{CPUHour: 0.01, MemoryGiBHour: 0.002},
+		}},
+	}
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(), server.WithPriceTable(prices))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Current cost",
+			method:         "GET",
+			url:            "/api/v1/namespaces/default/deployments/api/cost",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"api","pool":"default","replicas":2,"perReplica":{"currency":"USD","hourly":0.05,"monthly":36.5},"current":{"currency":"USD","hourly":0.1,"monthly":73}}`,
+		},
+		{
+			name:           "Cost of a change",
+			method:         "GET",
+			url:            "/api/v1/namespaces/default/deployments/web/cost?replicas=10",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","pool":"spot","replicas":3,"perReplica":{"currency":"USD","hourly":0.007,"monthly":5.11},"current":{"currency":"USD","hourly":0.021,"monthly":15.33},"targetReplicas":10,"target":{"currency":"USD","hourly":0.07,"monthly":51.1},"delta":{"currency":"USD","hourly":0.049,"monthly":35.77}}`,
+		},
+		{
+			name:           "Invalid replicas",
+			method:         "GET",
+			url:            "/api/v1/namespaces/default/deployments/web/cost?replicas=ten",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:ValidationFailed","title":"Validation failed","status":400,"detail":"replicas must be a non-negative integer","instance":"test-request-id","reason":"ValidationFailed"}`,
+		},
+		{
+			name:           "Missing deployment",
+			method:         "GET",
+			url:            "/api/v1/namespaces/default/deployments/db/cost",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:NotFound","title":"Not found","status":404,"detail":"Deployment not found","instance":"test-request-id","reason":"NotFound"}`,
+		},
+		{
+			name:           "Namespace summary",
+			method:         "GET",
+			url:            "/api/v1/namespaces/default/cost",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","total":{"currency":"USD","hourly":0.121,"monthly":88.33},"deployments":[{"namespace":"default","name":"api","pool":"default","replicas":2,"perReplica":{"currency":"USD","hourly":0.05,"monthly":36.5},"current":{"currency":"USD","hourly":0.1,"monthly":73}},{"namespace":"default","name":"web","pool":"spot","replicas":3,"perReplica":{"currency":"USD","hourly":0.007,"monthly":5.11},"current":{"currency":"USD","hourly":0.021,"monthly":15.33}}]}`,
+		},
+		{
+			name:           "Empty namespace",
+			method:         "GET",
+			url:            "/api/v1/namespaces/staging/cost",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"staging","total":{"currency":"USD","hourly":0,"monthly":0},"deployments":[]}`,
+		},
+		{
+			name:           "Scaling up reports the change",
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web",
+			body:           `{"replicas":5}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":5,"costDelta":{"currency":"USD","hourly":0.014,"monthly":10.22}}`,
+		},
+		{
+			name:           "Scaling down saves",
+			method:         "PUT",
+			url:            "/api/v1/namespaces/default/deployments/api/scale",
+			body:           `{"replicas":1}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"api","replicas":1,"costDelta":{"currency":"USD","hourly":-0.05,"monthly":-36.5}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		req.Header.Set("X-Request-ID", testRequestID)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rr.Code, tt.expectedStatus, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tt.expectedBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.expectedBody)
+		}
+	}
+
+	// Wait for the cache to see the scales
+	time.Sleep(100 * time.Millisecond)
+
+	// gRPC scales carry the same cost delta
+	client := dialBufconn(t, srv.GRPC, insecure.NewCredentials())
+	set, err := client.SetScale(context.Background(), &scalerv1.SetScaleRequest{Namespace: "default", Name: "api", Replicas: 3})
+	if err != nil {
+		t.Fatalf("SetScale() error = %v", err)
+	}
+	if delta := set.Scale.CostDelta; delta.GetCurrency() != "USD" || delta.GetHourly() != 0.1 || delta.GetMonthly() != 73 {
+		t.Errorf("SetScale() cost delta = %v, want USD 0.1 hourly, 73 monthly", delta)
+	}
+}
+
+func TestCostWithoutPriceTable(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset, deploymentInformer, stopCh := setupTestEnvironment()
+	defer close(stopCh)
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	for _, url := range []string{
+		"/api/v1/namespaces/default/deployments/web/cost",
+		"/api/v1/namespaces/default/cost",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("X-Request-ID", testRequestID)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		want := `{"type":"urn:k8s-deployment-scaler:problem:NotFound","title":"Not found","status":404,"detail":"Cost estimates need a price table, and none is configured","instance":"test-request-id","reason":"NotFound"}`
+		if got := strings.TrimSpace(rr.Body.String()); rr.Code != http.StatusNotFound || got != want {
+			t.Errorf("GET %s = %d %s, want 404 %s", url, rr.Code, got, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"k8s-deployment-scaler/internal/metrics"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Event reasons emitted against the target Deployment
+const (
+	EventReasonScaled        = "Scaled"
+	EventReasonScaleRejected = "ScaleRejected"
+	EventReasonScaleFailed   = "ScaleFailed"
+)
+
+// recordScaleEvent emits an Event against the deployment if a recorder is configured
+// and the deployment is known to the cache
+func (h *Handlers) recordScaleEvent(deployment *appsv1.Deployment, eventType, reason, messageFmt string, args ...interface{}) {
+	if h.eventRecorder == nil || deployment == nil {
+		return
+	}
+	h.eventRecorder.Eventf(deployment, eventType, reason, messageFmt, args...)
+}
+
+// rejectScale counts the validation error and records it as a Warning event,
+// returning it for the caller to report
+func (h *Handlers) rejectScale(namespace string, deployment *appsv1.Deployment, actor string, err apiError) *apiError {
+	h.metrics.ObserveScale(namespace, metrics.OutcomeRejected)
+	h.recordScaleRejected(deployment, actor, err.Message)
+	return &err
+}
+
+// Convenience wrappers so call sites read as the outcome they record
+func (h *Handlers) recordScaled(deployment *appsv1.Deployment, actor string, oldReplicas, newReplicas int32) {
+	h.recordScaleEvent(deployment, corev1.EventTypeNormal, EventReasonScaled,
+		"Scaled from %d to %d replicas by %s", oldReplicas, newReplicas, actor)
+}
+
+func (h *Handlers) recordScaleRejected(deployment *appsv1.Deployment, actor string, message string) {
+	h.recordScaleEvent(deployment, corev1.EventTypeWarning, EventReasonScaleRejected,
+		"Rejected scale request by %s: %s", actor, message)
+}
+
+func (h *Handlers) recordScaleFailed(deployment *appsv1.Deployment, actor string, oldReplicas, newReplicas int32, err error) {
+	h.recordScaleEvent(deployment, corev1.EventTypeWarning, EventReasonScaleFailed,
+		"Failed to scale from %d to %d replicas by %s: %v", oldReplicas, newReplicas, actor, err)
+}
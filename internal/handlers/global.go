@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s-deployment-scaler/internal/audit"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Global serves the /global routes, which fan a request out to every cluster
+// and merge the answers. A cluster that fails doesn't fail the request; it is
+// reported alongside the others' results.
+type Global struct {
+	clusters func() []string
+	lookup   ClusterLookup
+}
+
+// NewGlobal returns the /global handlers for the clusters named by clusters,
+// in the order it lists them, each served by the handlers lookup returns
+func NewGlobal(clusters func() []string, lookup ClusterLookup) *Global {
+	return &Global{clusters: clusters, lookup: lookup}
+}
+
+// ListDeployments handles GET /global/deployments, listing the deployments of
+// every cluster matching the name, namespace and labelSelector query parameters
+func (g *Global) ListDeployments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name, namespace := query.Get("name"), query.Get("namespace")
+	selector, err := labels.Parse(query.Get("labelSelector"))
+	if err != nil {
+		writeProblem(w, apiError{
+			Message: fmt.Sprintf("Invalid labelSelector: %v", err),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	results, errs := fanOut(g.clusters(), g.lookup, func(cluster string, h *Handlers) ([]apiv1.GlobalDeployment, *apiError) {
+		list, apiErr := h.listDeployments(r.Context(), namespace)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		var items []apiv1.GlobalDeployment
+		for _, deployment := range list {
+			if name != "" && deployment.Name != name {
+				continue
+			}
+			if !selector.Matches(labels.Set(deployment.Labels)) {
+				continue
+			}
+			items = append(items, apiv1.GlobalDeployment{
+				Cluster:   cluster,
+				Namespace: deployment.Namespace,
+				Name:      deployment.Name,
+				Replicas:  replicasOf(deployment),
+			})
+		}
+		return items, nil
+	})
+
+	response := apiv1.GlobalDeploymentList{
+		Items:   slices.Concat(results...),
+		Partial: len(errs) > 0,
+		Errors:  errs,
+	}
+	if response.Items == nil {
+		response.Items = []apiv1.GlobalDeployment{}
+	}
+	if err := encodeAndWriteJSON(w, response); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// PutScale handles PUT /global/namespaces/{namespace}/deployments/{name}/scale,
+// setting the deployment's replica count in each of the clusters the body names.
+// Every cluster is scaled as its own PUT /scale would, with its own policies,
+// cooldown and history, and audited with its own outcome. When no cluster is
+// scaled the request fails as a whole.
+func (g *Global) PutScale(w http.ResponseWriter, r *http.Request) {
+	namespace, deploymentName := r.PathValue("namespace"), r.PathValue("name")
+	audit.SetTarget(r.Context(), namespace, deploymentName)
+
+	var reqBody apiv1.GlobalScaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		writeProblem(w, apiError{Message: "Invalid request body", Code: http.StatusBadRequest})
+		return
+	}
+	if len(reqBody.Clusters) == 0 {
+		writeProblem(w, apiError{Message: "clusters must name at least one cluster", Code: http.StatusBadRequest})
+		return
+	}
+	opts, apiErr := parseScaleOptions(r)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+
+	// Refuse unknown clusters before scaling any, so a typo doesn't leave the
+	// deployment scaled in only some of the intended clusters
+	known := g.clusters()
+	var clusters []string
+	for _, cluster := range reqBody.Clusters {
+		if !slices.Contains(known, cluster) {
+			writeProblem(w, clusterError(cluster, ErrClusterNotFound))
+			return
+		}
+		if slices.Contains(clusters, cluster) {
+			writeProblem(w, apiError{Message: fmt.Sprintf("Cluster %s is listed more than once", cluster), Code: http.StatusBadRequest})
+			return
+		}
+		clusters = append(clusters, cluster)
+	}
+	slices.Sort(clusters)
+
+	// Each cluster's scale is audited on its own, rather than overwriting the others'
+	clusterCtxs := make(map[string]context.Context, len(clusters))
+	for _, cluster := range clusters {
+		clusterCtxs[cluster] = audit.WithCluster(r.Context(), cluster)
+	}
+
+	actor := clientIdentity(r)
+	scaleRequest := apiv1.ScaleRequest{Replicas: reqBody.Replicas, Reason: reqBody.Reason, Ticket: reqBody.Ticket}
+	results, errs := fanOut(clusters, g.lookup, func(cluster string, h *Handlers) ([]apiv1.ClusterScale, *apiError) {
+		scale, apiErr := h.scale(clusterCtxs[cluster], actor, namespace, deploymentName, scaleRequest, opts)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		audit.SetClusterStatus(clusterCtxs[cluster], http.StatusOK)
+		return []apiv1.ClusterScale{{
+			Cluster:         cluster,
+			Namespace:       scale.Namespace,
+			Name:            scale.Name,
+			Replicas:        scale.Replicas,
+			ResourceVersion: scale.ResourceVersion,
+			Forced:          scale.Forced,
+			Warnings:        scale.Warnings,
+			CostDelta:       scale.CostDelta,
+		}}, nil
+	})
+	for _, clusterErr := range errs {
+		audit.SetClusterStatus(clusterCtxs[clusterErr.Cluster], clusterErr.Status)
+	}
+	if len(errs) == len(clusters) {
+		writeProblem(w, allClustersFailed(errs))
+		return
+	}
+
+	response := apiv1.GlobalScale{
+		Items:   slices.Concat(results...),
+		Partial: len(errs) > 0,
+		Errors:  errs,
+	}
+	if response.Items == nil {
+		response.Items = []apiv1.ClusterScale{}
+	}
+	if err := encodeAndWriteJSON(w, response); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// allClustersFailed returns the error for a request that failed in every
+// cluster: their shared status and reason when they agree, otherwise a 502
+func allClustersFailed(errs []apiv1.ClusterError) apiError {
+	apiErr := apiError{Code: errs[0].Status, Reason: errs[0].Reason}
+	details := make([]string, len(errs))
+	for i, clusterErr := range errs {
+		if clusterErr.Status != apiErr.Code || clusterErr.Reason != apiErr.Reason {
+			apiErr.Code, apiErr.Reason = http.StatusBadGateway, ""
+		}
+		details[i] = clusterErr.Cluster + ": " + clusterErr.Detail
+	}
+	apiErr.Message = "No cluster was scaled. " + strings.Join(details, "; ")
+	return apiErr
+}
+
+// fanOut calls fn concurrently for each cluster with its handlers, returning
+// the results and the failures in the order of clusters
+func fanOut[T any](clusters []string, lookup ClusterLookup, fn func(cluster string, h *Handlers) ([]T, *apiError)) ([][]T, []apiv1.ClusterError) {
+	results := make([][]T, len(clusters))
+	failures := make([]*apiError, len(clusters))
+
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h, err := lookup(cluster)
+			if err != nil {
+				apiErr := clusterError(cluster, err)
+				failures[i] = &apiErr
+				return
+			}
+			results[i], failures[i] = fn(cluster, h)
+		}()
+	}
+	wg.Wait()
+
+	var errs []apiv1.ClusterError
+	for i, failure := range failures {
+		if failure != nil {
+			errs = append(errs, apiv1.ClusterError{
+				Cluster: clusters[i],
+				Status:  failure.Code,
+				Reason:  failure.reason(),
+				Detail:  failure.Message,
+			})
+		}
+	}
+	return results, errs
+}
@@ -0,0 +1,278 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"k8s-deployment-scaler/internal/audit"
+	"k8s-deployment-scaler/internal/handlers"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newDeployment returns a deployment with the given replicas and labels
+func newDeployment(namespace, name string, replicas int32, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)},
+	}
+}
+
+// newGlobal serves /global over clusters holding the given deployments. north
+// is also listed but never syncs.
+func newGlobal(t *testing.T, clusters map[string][]*appsv1.Deployment) (http.Handler, map[string]*fake.Clientset) {
+	t.Helper()
+
+	clientsets := make(map[string]*fake.Clientset)
+	served := make(map[string]*handlers.Handlers)
+	names := []string{"north"}
+	for name, deployments := range clusters {
+		fakeClientset := fake.NewSimpleClientset()
+		for _, deployment := range deployments {
+			fakeClientset.Tracker().Add(deployment)
+		}
+		addScaleReactors(fakeClientset)
+		factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+		deploymentInformer := factory.Apps().V1().Deployments()
+		deploymentInformer.Informer()
+		stopCh := make(chan struct{})
+		t.Cleanup(func() { close(stopCh) })
+		factory.Start(stopCh)
+		factory.WaitForCacheSync(stopCh)
+
+		h, err := handlers.New(fakeClientset, deploymentInformer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clientsets[name], served[name] = fakeClientset, h
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	global := handlers.NewGlobal(func() []string { return names }, func(name string) (*handlers.Handlers, error) {
+		if name == "north" {
+			return nil, handlers.ErrClusterNotSynced
+		}
+		h, ok := served[name]
+		if !ok {
+			return nil, handlers.ErrClusterNotFound
+		}
+		return h, nil
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /global/deployments", global.ListDeployments)
+	mux.HandleFunc("PUT /global/namespaces/{namespace}/deployments/{name}/scale", global.PutScale)
+	return mux, clientsets
+}
+
+func TestGlobalListDeployments(t *testing.T) {
+	t.Parallel()
+
+	mux, _ := newGlobal(t, map[string][]*appsv1.Deployment{
+		"east": {
+			newDeployment("default", "web", 3, map[string]string{"app": "web", "tier": "frontend"}),
+			newDeployment("default", "api", 2, map[string]string{"app": "api", "tier": "backend"}),
+		},
+		"west": {
+			newDeployment("default", "web", 5, map[string]string{"app": "web", "tier": "frontend"}),
+			newDeployment("staging", "web", 1, map[string]string{"app": "web", "tier": "frontend"}),
+		},
+	})
+	northError := `"errors":[{"cluster":"north","status":503,"reason":"UpstreamUnavailable","detail":"Cluster north has not synced its deployments yet"}]`
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "By name",
+			query:          "?name=web",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"items":[{"cluster":"east","namespace":"default","name":"web","replicas":3},` +
+				`{"cluster":"west","namespace":"default","name":"web","replicas":5},` +
+				`{"cluster":"west","namespace":"staging","name":"web","replicas":1}],"partial":true,` + northError + `}`,
+		},
+		{
+			name:           "By name and namespace",
+			query:          "?name=web&namespace=staging",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[{"cluster":"west","namespace":"staging","name":"web","replicas":1}],"partial":true,` + northError + `}`,
+		},
+		{
+			name:           "By label selector",
+			query:          "?labelSelector=tier%20in%20(backend)",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[{"cluster":"east","namespace":"default","name":"api","replicas":2}],"partial":true,` + northError + `}`,
+		},
+		{
+			name:           "No matches",
+			query:          "?name=db",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[],"partial":true,` + northError + `}`,
+		},
+		{
+			name:           "Invalid label selector",
+			query:          "?labelSelector=app%20in%20(",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, httptest.NewRequest("GET", "/global/deployments"+tt.query, nil))
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if got := strings.TrimSpace(rr.Body.String()); tt.expectedBody != "" && got != tt.expectedBody {
+				t.Errorf("body = %s, want %s", got, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestGlobalPutScale(t *testing.T) {
+	t.Parallel()
+
+	mux, clientsets := newGlobal(t, map[string][]*appsv1.Deployment{
+		"east":  {newDeployment("default", "web", 3, nil)},
+		"west":  {newDeployment("default", "web", 5, nil)},
+		"south": {},
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Every chosen cluster",
+			body:           `{"replicas":4,"clusters":["west","east"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"items":[{"cluster":"east","namespace":"default","name":"web","replicas":4},` +
+				`{"cluster":"west","namespace":"default","name":"web","replicas":4}],"partial":false}`,
+		},
+		{
+			name:           "Failing clusters are reported",
+			body:           `{"replicas":6,"clusters":["east","north","south"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"items":[{"cluster":"east","namespace":"default","name":"web","replicas":6}],"partial":true,"errors":[` +
+				`{"cluster":"north","status":503,"reason":"UpstreamUnavailable","detail":"Cluster north has not synced its deployments yet"},` +
+				`{"cluster":"south","status":404,"reason":"NotFound","detail":"Deployment not found"}]}`,
+		},
+		{
+			name:           "Every cluster fails alike",
+			body:           `{"replicas":2,"clusters":["south"]}`,
+			expectedStatus: http.StatusNotFound,
+			expectedBody: `{"type":"urn:k8s-deployment-scaler:problem:NotFound","title":"Not found","status":404,` +
+				`"detail":"No cluster was scaled. south: Deployment not found","reason":"NotFound"}`,
+		},
+		{
+			name:           "Every cluster fails differently",
+			body:           `{"replicas":2,"clusters":["north","south"]}`,
+			expectedStatus: http.StatusBadGateway,
+			expectedBody: `{"type":"urn:k8s-deployment-scaler:problem:UpstreamUnavailable","title":"Kubernetes API unavailable","status":502,` +
+				`"detail":"No cluster was scaled. north: Cluster north has not synced its deployments yet; south: Deployment not found","reason":"UpstreamUnavailable"}`,
+		},
+		{
+			name:           "Unknown cluster",
+			body:           `{"replicas":1,"clusters":["east","central"]}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Duplicate cluster",
+			body:           `{"replicas":1,"clusters":["east","east"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "No clusters",
+			body:           `{"replicas":1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	// Run in order: the scales affect later responses
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("PUT", "/global/namespaces/default/deployments/web/scale", strings.NewReader(tt.body)))
+
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rr.Code, tt.expectedStatus, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); tt.expectedBody != "" && got != tt.expectedBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.expectedBody)
+		}
+	}
+
+	// Rejected and failed requests scaled nothing
+	for name, want := range map[string]int32{"east": 6, "west": 4} {
+		deployment, err := clientsets[name].AppsV1().Deployments("default").Get(context.TODO(), "web", metav1.GetOptions{})
+		if err != nil || *deployment.Spec.Replicas != want {
+			t.Errorf("%s's deployment = %v, %v; want %d replicas", name, deployment, err, want)
+		}
+	}
+}
+
+func TestGlobalPutScaleAudit(t *testing.T) {
+	t.Parallel()
+
+	mux, _ := newGlobal(t, map[string][]*appsv1.Deployment{
+		"east": {newDeployment("default", "web", 3, nil)},
+		"west": {newDeployment("default", "web", 5, nil)},
+	})
+	sink := &auditSink{}
+	handler := audit.New(sink).Middleware(mux)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("PUT", "/global/namespaces/default/deployments/web/scale",
+		strings.NewReader(`{"replicas":4,"clusters":["east","north","west"]}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	if len(sink.records) != 1 {
+		t.Fatalf("got %d audit records, want 1", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.OldReplicas != nil || record.NewReplicas != nil {
+		t.Errorf("record replicas = %v -> %v, want none", record.OldReplicas, record.NewReplicas)
+	}
+	want := []struct {
+		cluster  string
+		status   int
+		old, new int32
+	}{
+		{"east", http.StatusOK, 3, 4},
+		{"north", http.StatusServiceUnavailable, 0, 0},
+		{"west", http.StatusOK, 5, 4},
+	}
+	if len(record.Clusters) != len(want) {
+		t.Fatalf("record clusters = %+v, want %d", record.Clusters, len(want))
+	}
+	for i, w := range want {
+		got := record.Clusters[i]
+		if got.Cluster != w.cluster || got.Status != w.status {
+			t.Errorf("clusters[%d] = %s/%d, want %s/%d", i, got.Cluster, got.Status, w.cluster, w.status)
+		}
+		if w.status != http.StatusOK {
+			if got.OldReplicas != nil || got.NewReplicas != nil {
+				t.Errorf("clusters[%d] replicas = %v -> %v, want none", i, got.OldReplicas, got.NewReplicas)
+			}
+			continue
+		}
+		if got.OldReplicas == nil || *got.OldReplicas != w.old || got.NewReplicas == nil || *got.NewReplicas != w.new {
+			t.Errorf("clusters[%d] replicas = %v -> %v, want %d -> %d", i, got.OldReplicas, got.NewReplicas, w.old, w.new)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"k8s-deployment-scaler/internal/audit"
+	scalerv1 "k8s-deployment-scaler/pkg/api/scaler/v1"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// errorDomain identifies the scaler in the ErrorInfo of gRPC errors
+const errorDomain = "k8s-deployment-scaler"
+
+// scaleRoute is the REST route whose rate limits SetScale shares, so switching
+// protocols doesn't earn a client a second set of buckets
+const scaleRoute = "PUT /api/v1/namespaces/{namespace}/deployments/{name}/scale"
+
+// grpcService serves the ScalerService with the same rules as the REST API
+type grpcService struct {
+	scalerv1.UnimplementedScalerServiceServer
+	h *Handlers
+}
+
+// GRPCService returns the gRPC ScalerService backed by these handlers, sharing
+// their cache, cooldown and history with the REST API
+func (h *Handlers) GRPCService() scalerv1.ScalerServiceServer {
+	return &grpcService{h: h}
+}
+
+// GetScale returns a deployment's replica count from the cache
+func (s *grpcService) GetScale(ctx context.Context, req *scalerv1.GetScaleRequest) (*scalerv1.GetScaleResponse, error) {
+	audit.SetTarget(ctx, req.Namespace, req.Name)
+	if apiErr := requireTarget(req.Namespace, req.Name); apiErr != nil {
+		return nil, grpcError(*apiErr)
+	}
+
+	scale, apiErr := s.h.readScale(ctx, req.Namespace, req.Name, nil)
+	if apiErr != nil {
+		return nil, grpcError(*apiErr)
+	}
+	return &scalerv1.GetScaleResponse{Scale: protoScale(scale)}, nil
+}
+
+// SetScale sets a deployment's replica count on behalf of the client certificate's identity
+func (s *grpcService) SetScale(ctx context.Context, req *scalerv1.SetScaleRequest) (*scalerv1.SetScaleResponse, error) {
+	audit.SetTarget(ctx, req.Namespace, req.Name)
+	if apiErr := requireTarget(req.Namespace, req.Name); apiErr != nil {
+		return nil, grpcError(*apiErr)
+	}
+
+	identity := peerIdentity(ctx)
+	if limiters, ok := s.h.limiters[scaleRoute]; ok {
+		if apiErr := limiters.reserve(identity, req.Namespace+"/"+req.Name); apiErr != nil {
+			return nil, grpcError(*apiErr)
+		}
+	}
+
+	scale, apiErr := s.h.scale(ctx, identity, req.Namespace, req.Name, apiv1.ScaleRequest{
+		Replicas: req.Replicas,
+		Reason:   req.Reason,
+		Ticket:   req.Ticket,
+	}, scaleOptions{force: req.Force, pinHPA: req.PinHpa})
+	if apiErr != nil {
+		return nil, grpcError(*apiErr)
+	}
+	return &scalerv1.SetScaleResponse{Scale: protoScale(scale)}, nil
+}
+
+// RestoreHPA restores the bounds of the HPA pinned by a SetScale with pin_hpa
+func (s *grpcService) RestoreHPA(ctx context.Context, req *scalerv1.RestoreHPARequest) (*scalerv1.RestoreHPAResponse, error) {
+	audit.SetTarget(ctx, req.Namespace, req.Name)
+	if apiErr := requireTarget(req.Namespace, req.Name); apiErr != nil {
+		return nil, grpcError(*apiErr)
+	}
+
+	hpa, apiErr := s.h.restorePinnedHPA(ctx, peerIdentity(ctx), req.Namespace, req.Name)
+	if apiErr != nil {
+		return nil, grpcError(*apiErr)
+	}
+	return &scalerv1.RestoreHPAResponse{Hpa: protoHPA(hpa)}, nil
+}
+
+// ListDeployments lists the cached deployments ordered by namespace and name
+func (s *grpcService) ListDeployments(ctx context.Context, req *scalerv1.ListDeploymentsRequest) (*scalerv1.ListDeploymentsResponse, error) {
+	list, apiErr := s.h.listDeployments(ctx, req.Namespace)
+	if apiErr != nil {
+		return nil, grpcError(*apiErr)
+	}
+
+	response := &scalerv1.ListDeploymentsResponse{Items: make([]*scalerv1.Deployment, 0, len(list))}
+	for _, deployment := range list {
+		response.Items = append(response.Items, protoDeployment(deployment))
+	}
+	return response, nil
+}
+
+// WatchDeployments sends an ADDED event for each cached deployment and then every
+// change from the informer. A deployment changing while the initial list is sent
+// may be reported twice. Clients too slow to keep up get Unavailable and should
+// watch again.
+func (s *grpcService) WatchDeployments(req *scalerv1.WatchDeploymentsRequest, stream scalerv1.ScalerService_WatchDeploymentsServer) error {
+	ctx := stream.Context()
+
+	// Watch before listing so no change can slip in between
+	events, stop := s.h.watcher.watchNamespace(req.Namespace)
+	defer stop()
+
+	list, apiErr := s.h.listDeployments(ctx, req.Namespace)
+	if apiErr != nil {
+		return grpcError(*apiErr)
+	}
+	for _, deployment := range list {
+		if err := stream.Send(&scalerv1.WatchDeploymentsResponse{
+			Type:       scalerv1.EventType_EVENT_TYPE_ADDED,
+			Deployment: protoDeployment(deployment),
+		}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "Watch fell too far behind; list and watch again")
+			}
+			if err := stream.Send(&scalerv1.WatchDeploymentsResponse{
+				Type:       eventTypes[event.Type],
+				Deployment: protoDeployment(event.Deployment),
+			}); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// eventTypes maps informer event types to their protobuf equivalents
+var eventTypes = map[watch.EventType]scalerv1.EventType{
+	watch.Added:    scalerv1.EventType_EVENT_TYPE_ADDED,
+	watch.Modified: scalerv1.EventType_EVENT_TYPE_MODIFIED,
+	watch.Deleted:  scalerv1.EventType_EVENT_TYPE_DELETED,
+}
+
+// requireTarget checks that a request names a deployment
+func requireTarget(namespace, name string) *apiError {
+	if namespace == "" || name == "" {
+		return &apiError{
+			Message: "Both namespace and name must be specified",
+			Code:    http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+// peerIdentity returns the common name of the verified client certificate,
+// or "unknown" when the call was not made over mTLS
+func peerIdentity(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			return info.State.PeerCertificates[0].Subject.CommonName
+		}
+	}
+	return "unknown"
+}
+
+// grpcCodes maps problem reasons to gRPC status codes
+var grpcCodes = map[apiv1.Reason]codes.Code{
+	apiv1.ReasonNotFound:            codes.NotFound,
+	apiv1.ReasonConflict:            codes.Aborted,
+	apiv1.ReasonForbidden:           codes.PermissionDenied,
+	apiv1.ReasonValidationFailed:    codes.InvalidArgument,
+	apiv1.ReasonUpstreamUnavailable: codes.Unavailable,
+	apiv1.ReasonTooManyRequests:     codes.ResourceExhausted,
+	apiv1.ReasonInternalError:       codes.Internal,
+}
+
+// grpcError converts an API error to a gRPC status carrying the same reason in an
+// ErrorInfo and, when the client should retry later, a RetryInfo
+func grpcError(err apiError) error {
+	reason := err.reason()
+	code := grpcCodes[reason]
+	if err.Code == http.StatusGatewayTimeout {
+		code = codes.DeadlineExceeded
+	}
+
+	// WithDetails only fails for an OK status, which an error never has
+	st := status.New(code, err.Message)
+	if withInfo, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: string(reason),
+		Domain: errorDomain,
+	}); detailsErr == nil {
+		st = withInfo
+	}
+	if err.RetryAfter > 0 {
+		if withRetry, detailsErr := st.WithDetails(&errdetails.RetryInfo{
+			// Whole seconds, as in the REST API's Retry-After
+			RetryDelay: durationpb.New(time.Duration(math.Ceil(err.RetryAfter.Seconds())) * time.Second),
+		}); detailsErr == nil {
+			st = withRetry
+		}
+	}
+	return st.Err()
+}
+
+// protoScale converts a scale to its protobuf message
+func protoScale(scale *apiv1.Scale) *scalerv1.Scale {
+	return &scalerv1.Scale{
+		Namespace:       scale.Namespace,
+		Name:            scale.Name,
+		Replicas:        scale.Replicas,
+		ResourceVersion: scale.ResourceVersion,
+		Hpa:             protoHPA(scale.HPA),
+		Forced:          scale.Forced,
+		Warnings:        scale.Warnings,
+		CostDelta:       protoCost(scale.CostDelta),
+	}
+}
+
+// protoCost converts a cost to its protobuf message, or returns nil for a nil cost
+func protoCost(cost *apiv1.Cost) *scalerv1.Cost {
+	if cost == nil {
+		return nil
+	}
+	return &scalerv1.Cost{
+		Currency: cost.Currency,
+		Hourly:   cost.Hourly,
+		Monthly:  cost.Monthly,
+	}
+}
+
+// protoHPA converts an HPA's status to its protobuf message, or returns nil for a nil HPA
+func protoHPA(hpa *apiv1.HorizontalPodAutoscaler) *scalerv1.HorizontalPodAutoscaler {
+	if hpa == nil {
+		return nil
+	}
+	message := &scalerv1.HorizontalPodAutoscaler{
+		Name:            hpa.Name,
+		MinReplicas:     hpa.MinReplicas,
+		MaxReplicas:     hpa.MaxReplicas,
+		CurrentReplicas: hpa.CurrentReplicas,
+		DesiredReplicas: hpa.DesiredReplicas,
+	}
+	if hpa.PinnedFrom != nil {
+		message.PinnedFrom = &scalerv1.HPABounds{
+			MinReplicas: hpa.PinnedFrom.MinReplicas,
+			MaxReplicas: hpa.PinnedFrom.MaxReplicas,
+		}
+	}
+	return message
+}
+
+// protoDeployment converts a cached deployment to its protobuf message
+func protoDeployment(deployment *appsv1.Deployment) *scalerv1.Deployment {
+	return &scalerv1.Deployment{
+		Namespace:       deployment.Namespace,
+		Name:            deployment.Name,
+		Replicas:        replicasOf(deployment),
+		ResourceVersion: deployment.ResourceVersion,
+	}
+}
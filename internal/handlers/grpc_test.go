@@ -0,0 +1,425 @@
+package handlers_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/handlers"
+	"k8s-deployment-scaler/internal/ratelimit"
+	"k8s-deployment-scaler/internal/server"
+	scalerv1 "k8s-deployment-scaler/pkg/api/scaler/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// dialBufconn serves s on an in-memory listener and returns a client connected to it
+func dialBufconn(t *testing.T, s *grpc.Server, creds credentials.TransportCredentials) scalerv1.ScalerServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return scalerv1.NewScalerServiceClient(conn)
+}
+
+// errorReason returns the ErrorInfo reason and RetryInfo delay of a gRPC error
+func errorReason(err error) (string, time.Duration) {
+	var reason string
+	var retryDelay time.Duration
+	for _, detail := range status.Convert(err).Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			reason = detail.Reason
+		case *errdetails.RetryInfo:
+			retryDelay = detail.RetryDelay.AsDuration()
+		}
+	}
+	return reason, retryDelay
+}
+
+func TestGRPCScalerService(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset, deploymentInformer, stopCh := setupTestEnvironment()
+	defer close(stopCh)
+
+	for _, name := range []string{"api", "worker"} {
+		_, err := fakeClientset.AppsV1().Deployments("default").Create(context.TODO(), &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Error creating test deployment: %v", err)
+		}
+	}
+
+	// Wait for the cache to sync
+	time.Sleep(100 * time.Millisecond)
+
+	cfg := testConfig()
+	cfg.Scale.Cooldown = metav1.Duration{Duration: time.Hour}
+	srv, err := server.New(fakeClientset, deploymentInformer, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	client := dialBufconn(t, srv.GRPC, insecure.NewCredentials())
+	ctx := context.Background()
+
+	// Reads come from the cache, and the caller's request ID is echoed
+	var header metadata.MD
+	got, err := client.GetScale(metadata.AppendToOutgoingContext(ctx, "x-request-id", testRequestID),
+		&scalerv1.GetScaleRequest{Namespace: "default", Name: "api"}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("GetScale() error = %v", err)
+	}
+	if got.Scale.Replicas != 2 {
+		t.Errorf("GetScale() = %v", got.Scale)
+	}
+	if ids := header.Get("x-request-id"); len(ids) != 1 || ids[0] != testRequestID {
+		t.Errorf("x-request-id header = %q, want %q", ids, testRequestID)
+	}
+
+	list, err := client.ListDeployments(ctx, &scalerv1.ListDeploymentsRequest{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("ListDeployments() error = %v", err)
+	}
+	if len(list.Items) != 2 || list.Items[0].Name != "api" || list.Items[1].Name != "worker" {
+		t.Errorf("ListDeployments() = %v", list.Items)
+	}
+
+	set, err := client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "api", Replicas: 5})
+	if err != nil {
+		t.Fatalf("SetScale() error = %v", err)
+	}
+	if set.Scale.Replicas != 5 {
+		t.Errorf("SetScale() = %v", set.Scale)
+	}
+
+	// Errors carry the REST API's reasons
+	tests := []struct {
+		name       string
+		call       func() error
+		wantCode   codes.Code
+		wantReason string
+		wantRetry  time.Duration
+	}{
+		{
+			name: "Unknown deployment",
+			call: func() error {
+				_, err := client.GetScale(ctx, &scalerv1.GetScaleRequest{Namespace: "default", Name: "missing"})
+				return err
+			},
+			wantCode:   codes.NotFound,
+			wantReason: "NotFound",
+		},
+		{
+			name: "Missing name",
+			call: func() error {
+				_, err := client.GetScale(ctx, &scalerv1.GetScaleRequest{Namespace: "default"})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "ValidationFailed",
+		},
+		{
+			name: "Negative replicas",
+			call: func() error {
+				_, err := client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "worker", Replicas: -1})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "ValidationFailed",
+		},
+		{
+			name: "Within the cooldown",
+			call: func() error {
+				_, err := client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "api", Replicas: 6})
+				return err
+			},
+			wantCode:   codes.ResourceExhausted,
+			wantReason: "TooManyRequests",
+			wantRetry:  time.Hour,
+		},
+		{
+			name: "Force without the privilege",
+			call: func() error {
+				_, err := client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "api", Replicas: 6, Force: true})
+				return err
+			},
+			wantCode:   codes.PermissionDenied,
+			wantReason: "Forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (error %v)", code, tt.wantCode, err)
+			}
+			reason, retry := errorReason(err)
+			if reason != tt.wantReason || retry != tt.wantRetry {
+				t.Errorf("details = %q, %v; want %q, %v", reason, retry, tt.wantReason, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestGRPCRateLimits(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset, deploymentInformer, stopCh := setupTestEnvironment()
+	defer close(stopCh)
+
+	for _, name := range []string{"api", "worker"} {
+		_, err := fakeClientset.AppsV1().Deployments("default").Create(context.TODO(), &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Error creating test deployment: %v", err)
+		}
+	}
+
+	// Wait for the cache to sync
+	time.Sleep(100 * time.Millisecond)
+
+	// SetScale shares the REST scale route's buckets
+	cfg := testConfig()
+	cfg.RateLimits = map[string]config.RouteRateLimit{
+		"PUT /api/v1/namespaces/{namespace}/deployments/{name}/scale": {
+			PerIdentity: &ratelimit.Limit{PerMinute: 1, Burst: 1},
+		},
+	}
+	srv, err := server.New(fakeClientset, deploymentInformer, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	client := dialBufconn(t, srv.GRPC, insecure.NewCredentials())
+	ctx := context.Background()
+
+	if _, err := client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "api", Replicas: 3}); err != nil {
+		t.Fatalf("SetScale() error = %v", err)
+	}
+
+	_, err = client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "worker", Replicas: 3})
+	if code := status.Code(err); code != codes.ResourceExhausted {
+		t.Fatalf("code = %v, want %v (error %v)", code, codes.ResourceExhausted, err)
+	}
+	if reason, retry := errorReason(err); reason != "TooManyRequests" || retry <= 0 {
+		t.Errorf("details = %q, %v; want TooManyRequests with a retry delay", reason, retry)
+	}
+}
+
+func TestGRPCWatchDeployments(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset, deploymentInformer, stopCh := setupTestEnvironment()
+	defer close(stopCh)
+
+	deployments := fakeClientset.AppsV1().Deployments("default")
+	_, err := deployments.Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating test deployment: %v", err)
+	}
+
+	// Wait for the cache to sync
+	time.Sleep(100 * time.Millisecond)
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	client := dialBufconn(t, srv.GRPC, insecure.NewCredentials())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchDeployments(ctx, &scalerv1.WatchDeploymentsRequest{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("WatchDeployments() error = %v", err)
+	}
+
+	// expect skips modifications other than the one wanted, such as the
+	// annotations recorded after a scale
+	expect := func(wantType scalerv1.EventType, wantName string, wantReplicas int32) {
+		t.Helper()
+		event, err := stream.Recv()
+		for err == nil && event.Type == scalerv1.EventType_EVENT_TYPE_MODIFIED && wantType != event.Type {
+			event, err = stream.Recv()
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if event.Type != wantType || event.Deployment.Name != wantName || event.Deployment.Replicas != wantReplicas {
+			t.Fatalf("event = %v, want %v %s with %d replicas", event, wantType, wantName, wantReplicas)
+		}
+	}
+
+	// The existing deployment is listed first
+	expect(scalerv1.EventType_EVENT_TYPE_ADDED, "api", 2)
+
+	// Changes in other namespaces are filtered out
+	_, err = fakeClientset.AppsV1().Deployments("other").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "other"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating test deployment: %v", err)
+	}
+
+	_, err = deployments.Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating test deployment: %v", err)
+	}
+	expect(scalerv1.EventType_EVENT_TYPE_ADDED, "worker", 1)
+
+	if _, err := client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "api", Replicas: 4}); err != nil {
+		t.Fatalf("SetScale() error = %v", err)
+	}
+	expect(scalerv1.EventType_EVENT_TYPE_MODIFIED, "api", 4)
+
+	if err := deployments.Delete(context.TODO(), "worker", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Error deleting test deployment: %v", err)
+	}
+	expect(scalerv1.EventType_EVENT_TYPE_DELETED, "worker", 1)
+}
+
+func TestGRPCClientIdentity(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset, deploymentInformer, stopCh := setupTestEnvironment()
+	defer close(stopCh)
+
+	_, err := fakeClientset.AppsV1().Deployments("default").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating test deployment: %v", err)
+	}
+
+	// Wait for the cache to sync
+	time.Sleep(100 * time.Millisecond)
+
+	h, err := handlers.New(fakeClientset, deploymentInformer)
+	if err != nil {
+		t.Fatalf("Failed to create handlers: %v", err)
+	}
+	serverTLS, clientTLS := newTestMTLS(t, "release-bot")
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverTLS)))
+	scalerv1.RegisterScalerServiceServer(s, h.GRPCService())
+	client := dialBufconn(t, s, credentials.NewTLS(clientTLS))
+
+	if _, err := client.SetScale(context.Background(), &scalerv1.SetScaleRequest{Namespace: "default", Name: "api", Replicas: 3}); err != nil {
+		t.Fatalf("SetScale() error = %v", err)
+	}
+
+	// The scale is attributed to the client certificate's common name
+	deployment, err := fakeClientset.AppsV1().Deployments("default").Get(context.TODO(), "api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actor := deployment.Annotations[handlers.AnnotationLastScaledBy]; actor != "release-bot" {
+		t.Errorf("%s = %q, want release-bot", handlers.AnnotationLastScaledBy, actor)
+	}
+}
+
+// newTestMTLS returns matching server and client TLS configurations, the client
+// presenting a certificate with the given common name
+func newTestMTLS(t *testing.T, commonName string) (*tls.Config, *tls.Config) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{issue(2, "bufconn", x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}
+	clientTLS := &tls.Config{
+		Certificates: []tls.Certificate{issue(3, commonName, x509.ExtKeyUsageClientAuth)},
+		RootCAs:      pool,
+		ServerName:   "bufconn",
+		MinVersion:   tls.VersionTLS13,
+	}
+	return serverTLS, clientTLS
+}
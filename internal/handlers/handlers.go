@@ -4,99 +4,527 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"k8s-deployment-scaler/internal/audit"
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/cost"
+	"k8s-deployment-scaler/internal/history"
+	"k8s-deployment-scaler/internal/logging"
+	"k8s-deployment-scaler/internal/metrics"
+	"k8s-deployment-scaler/internal/policy"
+	"k8s-deployment-scaler/internal/tracing"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+
+	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
 	"k8s.io/client-go/kubernetes"
 	appslisters "k8s.io/client-go/listers/apps/v1"
+	autoscalinglisters "k8s.io/client-go/listers/autoscaling/v2"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 )
 
-// Define the global clientset variable using kubernetes.Interface
-var clientset kubernetes.Interface
+// Handlers serves the API endpoints. Each instance has its own Kubernetes client,
+// caches and policies, so several can run side by side in one process.
+type Handlers struct {
+	clientset          kubernetes.Interface
+	deploymentLister   appslisters.DeploymentLister
+	hpaLister          autoscalinglisters.HorizontalPodAutoscalerLister
+	pdbLister          policylisters.PodDisruptionBudgetLister
+	quotaLister        corelisters.ResourceQuotaLister
+	watcher            *DeploymentWatcher
+	history            *history.Store
+	eventRecorder      record.EventRecorder
+	changePolicies     *policy.ChangePolicies
+	protection         *policy.Protection
+	prices             *cost.PriceTable
+	metrics            *metrics.Metrics
+	clock              clock.Clock
+	logger             *slog.Logger
+	updateScaleTimeout time.Duration
+	rateLimits         map[string]config.RouteRateLimit
+	limiters           map[string]*routeLimiters
+	cooldown           cooldown
+	// forceIdentities may override PodDisruptionBudgets with force=true
+	forceIdentities map[string]bool
+	// quotaWarnOnly allows scale-ups exceeding a ResourceQuota, with a warning
+	quotaWarnOnly bool
+	// namespaces, when not nil, are the only namespaces served
+	namespaces map[string]bool
+}
+
+// Option configures optional handler dependencies
+type Option func(*Handlers)
+
+// WithEventRecorder emits Kubernetes Events for scale actions through the recorder
+func WithEventRecorder(recorder record.EventRecorder) Option {
+	return func(h *Handlers) {
+		h.eventRecorder = recorder
+	}
+}
+
+// WithChangePolicies enforces per-namespace reason and ticket requirements
+func WithChangePolicies(policies *policy.ChangePolicies) Option {
+	return func(h *Handlers) {
+		h.changePolicies = policies
+	}
+}
+
+// WithProtection refuses to scale the deployments the protection lists
+func WithProtection(protection *policy.Protection) Option {
+	return func(h *Handlers) {
+		h.protection = protection
+	}
+}
+
+// WithPriceTable estimates the cost of deployments and of scaling them at the
+// table's prices. Without it the cost routes answer 404.
+func WithPriceTable(prices *cost.PriceTable) Option {
+	return func(h *Handlers) {
+		h.prices = prices
+	}
+}
+
+// WithHorizontalPodAutoscalers reads the HPAs scaling deployments from the
+// lister's cache, reporting them with the scale and refusing scales they would
+// undo. Without it HPAs are ignored.
+func WithHorizontalPodAutoscalers(lister autoscalinglisters.HorizontalPodAutoscalerLister) Option {
+	return func(h *Handlers) {
+		h.hpaLister = lister
+	}
+}
+
+// WithPodDisruptionBudgets reads PodDisruptionBudgets from the lister's cache,
+// refusing scale-downs that would violate them unless forced. Without it PDBs
+// are ignored.
+func WithPodDisruptionBudgets(lister policylisters.PodDisruptionBudgetLister) Option {
+	return func(h *Handlers) {
+		h.pdbLister = lister
+	}
+}
+
+// WithForceIdentities allows the given client identities to scale past a
+// PodDisruptionBudget with force=true. The cooldown has its own list, set by
+// WithScaleCooldown.
+func WithForceIdentities(identities []string) Option {
+	return func(h *Handlers) {
+		h.forceIdentities = make(map[string]bool)
+		for _, identity := range identities {
+			h.forceIdentities[identity] = true
+		}
+	}
+}
+
+// WithResourceQuotas reads ResourceQuotas from the lister's cache, refusing
+// scale-ups whose new pods' requests would exceed one, or only warning of them
+// when warnOnly is set. Without it quotas are ignored.
+func WithResourceQuotas(lister corelisters.ResourceQuotaLister, warnOnly bool) Option {
+	return func(h *Handlers) {
+		h.quotaLister = lister
+		h.quotaWarnOnly = warnOnly
+	}
+}
+
+// WithMetrics records scale outcomes and UpdateScale latency
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(h *Handlers) {
+		h.metrics = m
+	}
+}
+
+// WithClock replaces the real clock, for tests
+func WithClock(c clock.Clock) Option {
+	return func(h *Handlers) {
+		h.clock = c
+	}
+}
 
-// SetClientset sets the global clientset
-func SetClientset(cs kubernetes.Interface) {
-	clientset = cs
+// WithLogger replaces slog.Default as the handlers' logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handlers) {
+		h.logger = logger
+	}
 }
 
-// healthCheck handles the /healthz endpoint for health checks
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
+// WithUpdateScaleTimeout bounds each UpdateScale call and the follow-up writes
+func WithUpdateScaleTimeout(timeout time.Duration) Option {
+	return func(h *Handlers) {
+		h.updateScaleTimeout = timeout
+	}
+}
+
+// WithNamespaces serves only the given namespaces, as watched by the informer,
+// answering 403 for any other; without it every namespace is served
+func WithNamespaces(namespaces []string) Option {
+	return func(h *Handlers) {
+		if len(namespaces) == 0 {
+			h.namespaces = nil
+			return
+		}
+		h.namespaces = make(map[string]bool, len(namespaces))
+		for _, namespace := range namespaces {
+			h.namespaces[namespace] = true
+		}
+	}
+}
+
+// New returns handlers reading deployments from the informer's cache and writing
+// through the clientset
+func New(cs kubernetes.Interface, deploymentInformer appsinformers.DeploymentInformer, opts ...Option) (*Handlers, error) {
+	watcher, err := NewDeploymentWatcher(deploymentInformer.Informer())
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch deployments: %v", err)
+	}
+
+	h := &Handlers{
+		clientset:          cs,
+		deploymentLister:   deploymentInformer.Lister(),
+		watcher:            watcher,
+		history:            history.NewStore(cs, history.DefaultMaxEntries),
+		clock:              clock.RealClock{},
+		logger:             slog.Default(),
+		updateScaleTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.buildRateLimiters()
+	return h, nil
+}
+
+// HealthCheck handles the /healthz endpoint for health checks
+func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	// Check Kubernetes connectivity
-	_, err := clientset.Discovery().ServerVersion()
+	_, span := tracing.Start(r.Context(), "kubernetes.ServerVersion")
+	_, err := h.clientset.Discovery().ServerVersion()
+	tracing.End(span, err)
 	if err != nil {
-		log.Printf("Kubernetes connectivity check failed: %v", err)
-		writeJSONError(w, apiError{
+		h.logger.ErrorContext(r.Context(), "Kubernetes connectivity check failed", "error", err)
+		writeProblem(w, apiError{
 			Message: "Kubernetes connectivity check failed",
 			Code:    http.StatusServiceUnavailable,
 		})
 		return
 	}
 
-	if err := encodeAndWriteJSON(w, map[string]string{"status": "OK"}); err != nil {
+	if err := encodeAndWriteJSON(w, apiv1.Status{Status: "OK"}); err != nil {
 		writeInternalServerError(w, err)
 	}
 }
 
-// handleGetReplicaCount handles the /replica-count endpoint for GET requests
-func GetReplicaCount(w http.ResponseWriter, r *http.Request, deploymentLister appslisters.DeploymentLister) {
-	namespace, deploymentName, err := validateQueryParams(r)
-	if err != nil {
-		writeJSONError(w, *err)
+// GetScale handles GET /api/v1/namespaces/{namespace}/deployments/{name}/scale
+func (h *Handlers) GetScale(w http.ResponseWriter, r *http.Request) {
+	scale, ok := h.getScale(w, r, r.PathValue("namespace"), r.PathValue("name"))
+	if !ok {
+		return
+	}
+	if err := encodeAndWriteJSON(w, scale); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// PutScale handles PUT /api/v1/namespaces/{namespace}/deployments/{name}/scale
+func (h *Handlers) PutScale(w http.ResponseWriter, r *http.Request) {
+	scale, ok := h.setScale(w, r, r.PathValue("namespace"), r.PathValue("name"))
+	if !ok {
 		return
 	}
+	if err := encodeAndWriteJSON(w, scale); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
 
-	deployment, exists := getDeploymentFromCache(namespace, deploymentName, deploymentLister)
-	if !exists {
-		writeJSONError(w, apiError{
-			Message: "Deployment not found",
-			Code:    http.StatusNotFound,
-		})
+// GetDeploymentList handles GET /api/v1/deployments and
+// GET /api/v1/namespaces/{namespace}/deployments
+func (h *Handlers) GetDeploymentList(w http.ResponseWriter, r *http.Request) {
+	list, apiErr := h.listDeployments(r.Context(), r.PathValue("namespace"))
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
 		return
 	}
 
-	response := map[string]interface{}{
-		"replicaCount": *deployment.Spec.Replicas,
+	response := apiv1.DeploymentList{Items: make([]apiv1.Deployment, 0, len(list))}
+	for _, deployment := range list {
+		response.Items = append(response.Items, apiv1.Deployment{
+			Namespace: deployment.Namespace,
+			Name:      deployment.Name,
+			Replicas:  replicasOf(deployment),
+		})
 	}
 	if err := encodeAndWriteJSON(w, response); err != nil {
 		writeInternalServerError(w, err)
 	}
 }
 
-// handlePostReplicaCount handles the /replica-count endpoint for POST requests
-func PostReplicaCount(w http.ResponseWriter, r *http.Request) {
-	namespace, deploymentName, apiErr := validateQueryParams(r)
+// getScale reads the deployment's scale as the request's wait parameters ask.
+// On failure it writes the error response and returns false.
+func (h *Handlers) getScale(w http.ResponseWriter, r *http.Request, namespace, deploymentName string) (*apiv1.Scale, bool) {
+	audit.SetTarget(r.Context(), namespace, deploymentName)
+
+	waitOpts, apiErr := parseWaitOptions(r)
 	if apiErr != nil {
-		writeJSONError(w, *apiErr)
-		return
+		writeProblem(w, *apiErr)
+		return nil, false
+	}
+	scale, apiErr := h.readScale(r.Context(), namespace, deploymentName, waitOpts)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return nil, false
 	}
+	return scale, true
+}
+
+// setScale applies the scale request in the body to the deployment. On failure
+// it writes the error response and returns false.
+func (h *Handlers) setScale(w http.ResponseWriter, r *http.Request, namespace, deploymentName string) (*apiv1.Scale, bool) {
+	audit.SetTarget(r.Context(), namespace, deploymentName)
+	actor := clientIdentity(r)
 
-	var reqBody struct {
-		Replicas int32 `json:"replicas"`
+	// Requests that never reach scale are counted and recorded here
+	reject := func(err apiError) {
+		deployment, _ := h.getDeploymentFromCache(r.Context(), namespace, deploymentName)
+		writeProblem(w, *h.rejectScale(namespace, deployment, actor, err))
 	}
 
-	// Decode the request body
+	var reqBody apiv1.ScaleRequest
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		writeJSONError(w, apiError{
+		reject(apiError{
 			Message: "Invalid request body",
 			Code:    http.StatusBadRequest,
 		})
-		return
+		return nil, false
+	}
+	opts, apiErr := parseScaleOptions(r)
+	if apiErr != nil {
+		reject(*apiErr)
+		return nil, false
+	}
+
+	scale, apiErr := h.scale(r.Context(), actor, namespace, deploymentName, reqBody, opts)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return nil, false
+	}
+	return scale, true
+}
+
+// readScale reads the deployment's scale from the cache, first waiting for it to
+// change when opts is not nil
+func (h *Handlers) readScale(ctx context.Context, namespace, deploymentName string, opts *waitOptions) (*apiv1.Scale, *apiError) {
+	if apiErr := h.checkNamespace(namespace); apiErr != nil {
+		return nil, apiErr
+	}
+	if opts != nil {
+		return h.waitForScale(ctx, namespace, deploymentName, opts)
+	}
+
+	deployment, exists := h.getDeploymentFromCache(ctx, namespace, deploymentName)
+	if !exists {
+		return nil, &apiError{
+			Message: "Deployment not found",
+			Code:    http.StatusNotFound,
+		}
+	}
+	scale := scaleOf(deployment)
+	scale.HPA = hpaStatus(h.hpaFor(ctx, namespace, deploymentName))
+	return scale, nil
+}
+
+// waitForScale blocks until the cached deployment satisfies the wait options,
+// the timeout expires or the client goes away, re-checking on each informer event.
+func (h *Handlers) waitForScale(ctx context.Context, namespace, deploymentName string, opts *waitOptions) (*apiv1.Scale, *apiError) {
+	// Subscribe before the first cache read so no update can slip in between
+	events, unsubscribe := h.watcher.subscribe(namespace, deploymentName)
+	defer unsubscribe()
+
+	timer := h.clock.NewTimer(opts.timeout)
+	defer timer.Stop()
+
+	for {
+		deployment, exists := h.getDeploymentFromCache(ctx, namespace, deploymentName)
+		if !exists {
+			return nil, &apiError{
+				Message: "Deployment not found",
+				Code:    http.StatusNotFound,
+			}
+		}
+
+		timedOut := false
+		if !opts.satisfied(deployment) {
+			select {
+			case <-events:
+				continue
+			case <-ctx.Done():
+				// Nobody is left to read the response
+				return nil, &apiError{
+					Message: "Request cancelled",
+					Code:    http.StatusServiceUnavailable,
+				}
+			case <-timer.C():
+				timedOut = true
+			}
+		}
+
+		scale := scaleOf(deployment)
+		scale.HPA = hpaStatus(h.hpaFor(ctx, namespace, deploymentName))
+		scale.TimedOut = &timedOut
+		return scale, nil
+	}
+}
+
+// scaleOptions are the query parameters changing how a scale is applied
+type scaleOptions struct {
+	// force bypasses the cooldown and PodDisruptionBudgets, for the identities
+	// allowed to
+	force bool
+	// pinHPA scales a deployment managed by an HPA by pinning the HPA's bounds
+	// to the requested count instead of refusing
+	pinHPA bool
+}
+
+// scale validates the request against the namespace's policies, the cooldown,
+// any HPA, PodDisruptionBudgets and ResourceQuotas, then sets the deployment's
+// replica count on behalf of actor. It records the outcome in metrics, events,
+// the deployment's annotations and its history.
+func (h *Handlers) scale(ctx context.Context, actor, namespace, deploymentName string, reqBody apiv1.ScaleRequest, opts scaleOptions) (*apiv1.Scale, *apiError) {
+	if apiErr := h.checkNamespace(namespace); apiErr != nil {
+		return nil, h.rejectScale(namespace, nil, actor, *apiErr)
+	}
+
+	// Look up the current state for the history entry and events
+	deployment, exists := h.getDeploymentFromCache(ctx, namespace, deploymentName)
+	var oldReplicas int32
+	var deploymentLabels map[string]string
+	if exists {
+		oldReplicas = replicasOf(deployment)
+		deploymentLabels = deployment.Labels
+	}
+
+	// Never scale protected deployments, whoever asks
+	if err := h.protection.Check(namespace, deploymentName, deploymentLabels); err != nil {
+		return nil, h.rejectScale(namespace, deployment, actor, apiError{
+			Message: fmt.Sprintf("Deployment %s/%s is protected: %v", namespace, deploymentName, err),
+			Code:    http.StatusForbidden,
+		})
 	}
 
 	// Validate the replica count
 	if reqBody.Replicas < 0 {
-		writeJSONError(w, apiError{
+		return nil, h.rejectScale(namespace, deployment, actor, apiError{
 			Message: "Replica count must be non-negative",
 			Code:    http.StatusBadRequest,
 		})
-		return
 	}
 
+	// Enforce the namespace's change-management requirements
+	if err := h.changePolicies.For(namespace).Validate(reqBody.Reason, reqBody.Ticket); err != nil {
+		return nil, h.rejectScale(namespace, deployment, actor, apiError{
+			Message: fmt.Sprintf("Change policy for namespace %s not satisfied: %v", namespace, err),
+			Code:    http.StatusBadRequest,
+		})
+	}
+
+	// Enforce the cooldown between scales of the same deployment, reserving it
+	// until the scale is applied. The checks a privileged client forces past are
+	// recorded in the response.
+	var forced []string
+	remaining, releaseCooldown := h.reserveCooldown(namespace, deploymentName, deployment, opts.force && h.cooldown.mayForce(actor))
+	if releaseCooldown == nil {
+		if !opts.force {
+			return nil, h.rejectScale(namespace, deployment, actor, apiError{
+				Message:    fmt.Sprintf("Deployment was scaled less than %v ago; retry in %v or set force=true", h.cooldown.period, remaining.Round(time.Second)),
+				Code:       http.StatusTooManyRequests,
+				RetryAfter: remaining,
+			})
+		}
+		return nil, h.rejectScale(namespace, deployment, actor, apiError{
+			Message: fmt.Sprintf("Client %s may not force a scale during the cooldown", actor),
+			Code:    http.StatusForbidden,
+		})
+	}
+	if remaining > 0 {
+		forced = append(forced, "cooldown")
+	}
+	applied := false
+	defer func() {
+		if !applied {
+			releaseCooldown()
+		}
+	}()
+
+	// An HPA would soon undo the scale, so refuse unless asked to pin its bounds
+	hpa := h.hpaFor(ctx, namespace, deploymentName)
+	if hpa != nil && !opts.pinHPA {
+		return nil, h.rejectScale(namespace, deployment, actor, apiError{
+			Message: fmt.Sprintf("Deployment %s/%s is scaled by HorizontalPodAutoscaler %s (min %d, max %d); set mode=hpa to pin its bounds to the requested count",
+				namespace, deploymentName, hpa.Name, minReplicasOf(hpa), hpa.Spec.MaxReplicas),
+			Code: http.StatusConflict,
+		})
+	}
+	if hpa != nil && reqBody.Replicas == 0 {
+		return nil, h.rejectScale(namespace, deployment, actor, apiError{
+			Message: fmt.Sprintf("HorizontalPodAutoscaler %s can't be pinned to 0 replicas", hpa.Name),
+			Code:    http.StatusBadRequest,
+		})
+	}
+
+	// Scaling down past a PodDisruptionBudget breaks the guarantee it gives
+	if violations := h.violatedPDBs(ctx, deployment, oldReplicas, reqBody.Replicas); len(violations) > 0 {
+		if !opts.force {
+			return nil, h.rejectScale(namespace, deployment, actor, apiError{
+				Message: fmt.Sprintf("Scaling %s/%s from %d to %d replicas would violate PodDisruptionBudget %s (%s); set force=true to override",
+					namespace, deploymentName, oldReplicas, reqBody.Replicas, violations[0].name, violations[0].budget),
+				Code: http.StatusConflict,
+			})
+		}
+		if !h.forceIdentities[actor] {
+			return nil, h.rejectScale(namespace, deployment, actor, apiError{
+				Message: fmt.Sprintf("Client %s may not force a scale past a PodDisruptionBudget", actor),
+				Code:    http.StatusForbidden,
+			})
+		}
+		for _, violation := range violations {
+			forced = append(forced, "PodDisruptionBudget "+violation.name)
+		}
+	}
+
+	// Pods past a ResourceQuota are never created, so the scale-up wouldn't take
+	// effect. In warn-only mode the excesses are returned as warnings instead.
+	var warnings []string
+	if excesses := h.exceededQuotas(ctx, deployment, oldReplicas, reqBody.Replicas); len(excesses) > 0 {
+		descriptions := make([]string, 0, len(excesses))
+		for _, excess := range excesses {
+			descriptions = append(descriptions, excess.String())
+		}
+		if !h.quotaWarnOnly {
+			return nil, h.rejectScale(namespace, deployment, actor, apiError{
+				Message: fmt.Sprintf("Scaling %s/%s from %d to %d replicas would exceed %s",
+					namespace, deploymentName, oldReplicas, reqBody.Replicas, strings.Join(descriptions, "; ")),
+				Code: http.StatusUnprocessableEntity,
+			})
+		}
+		for _, description := range descriptions {
+			warnings = append(warnings, "Exceeds "+description)
+		}
+	}
+
+	audit.SetReplicas(ctx, oldReplicas, reqBody.Replicas)
+
 	// Create the scale object
 	scale := &autoscalingv1.Scale{
 		ObjectMeta: metav1.ObjectMeta{
@@ -109,59 +537,263 @@ func PostReplicaCount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update the deployment scale
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	requestCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, h.updateScaleTimeout)
 	defer cancel()
 
-	_, err := clientset.AppsV1().Deployments(namespace).UpdateScale(ctx, deploymentName, scale, metav1.UpdateOptions{})
+	// Pin the HPA first, so it can't scale the deployment back in between
+	var pinned *autoscalingv2.HorizontalPodAutoscaler
+	if hpa != nil {
+		var err error
+		pinned, err = h.pinHPA(ctx, hpa, reqBody.Replicas)
+		if err != nil {
+			h.logger.ErrorContext(requestCtx, "Failed to pin HorizontalPodAutoscaler",
+				"namespace", namespace, "deployment", deploymentName, "hpa", hpa.Name, "error", err)
+			h.metrics.ObserveScale(namespace, metrics.OutcomeFailed)
+			h.recordScaleFailed(deployment, actor, oldReplicas, reqBody.Replicas, err)
+			apiErr := kubernetesError(err, fmt.Sprintf("HorizontalPodAutoscaler %s not found", hpa.Name))
+			return nil, &apiErr
+		}
+		h.logger.InfoContext(requestCtx, "Pinned HorizontalPodAutoscaler",
+			"namespace", namespace, "deployment", deploymentName, "hpa", hpa.Name, "replicas", reqBody.Replicas)
+	}
+
+	updateCtx, span := tracing.Start(ctx, "kubernetes.UpdateScale",
+		semconv.K8SNamespaceName(namespace), semconv.K8SDeploymentName(deploymentName))
+	updateStart := h.clock.Now()
+	updated, err := h.clientset.AppsV1().Deployments(namespace).UpdateScale(updateCtx, deploymentName, scale, metav1.UpdateOptions{})
+	h.metrics.ObserveUpdateScale(h.clock.Since(updateStart))
+	tracing.End(span, err)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			writeJSONError(w, apiError{
-				Message: "Deployment not found",
-				Code:    http.StatusNotFound,
-			})
+		apiErr := kubernetesError(err, "Deployment not found")
+		if apiErr.Code == http.StatusNotFound {
+			h.metrics.ObserveScale(namespace, metrics.OutcomeNotFound)
 		} else {
-			log.Printf("Failed to update deployment scale: %v", err)
-			writeJSONError(w, apiError{
-				Message: "Failed to update deployment scale",
-				Code:    http.StatusInternalServerError,
-			})
+			h.logger.ErrorContext(requestCtx, "Failed to update deployment scale",
+				"namespace", namespace, "deployment", deploymentName, "error", err)
+			h.metrics.ObserveScale(namespace, metrics.OutcomeFailed)
+			h.recordScaleFailed(deployment, actor, oldReplicas, reqBody.Replicas, err)
 		}
-		return
+		if pinned != nil {
+			h.rollBackHPAPin(requestCtx, hpa)
+		}
+		return nil, &apiErr
+	}
+	applied = true
+	h.metrics.ObserveScale(namespace, metrics.OutcomeSuccess)
+	h.recordScaled(deployment, actor, oldReplicas, reqBody.Replicas)
+	if len(forced) > 0 {
+		h.logger.WarnContext(requestCtx, "Scale forced past safety checks",
+			"namespace", namespace, "deployment", deploymentName, "actor", actor, "forced", forced)
 	}
+	if len(warnings) > 0 {
+		h.logger.WarnContext(requestCtx, "Scale exceeds ResourceQuotas",
+			"namespace", namespace, "deployment", deploymentName, "warnings", warnings)
+	}
+	scaledAt := h.clock.Now().UTC()
 
-	// Return the response
-	response := map[string]interface{}{
-		"replicaCount": reqBody.Replicas,
+	// Record the change on the deployment and in the history; neither may fail the scale itself.
+	// The annotations change the deployment again, so its resource version is
+	// the one a client waiting for the next change must send.
+	resourceVersion := updated.ResourceVersion
+	if annotated, err := h.annotateLastScale(ctx, namespace, deploymentName, actor, reqBody.Reason, reqBody.Ticket, scaledAt); err != nil {
+		h.logger.WarnContext(requestCtx, "Failed to annotate deployment",
+			"namespace", namespace, "deployment", deploymentName, "error", err)
+	} else {
+		resourceVersion = annotated
 	}
-	if err := encodeAndWriteJSON(w, response); err != nil {
-		writeInternalServerError(w, err)
+
+	entry := history.Entry{
+		Timestamp:   scaledAt,
+		Actor:       actor,
+		OldReplicas: oldReplicas,
+		NewReplicas: reqBody.Replicas,
+		Reason:      reqBody.Reason,
+		Ticket:      reqBody.Ticket,
+		RequestID:   logging.RequestID(requestCtx),
+	}
+	if err := h.history.Append(ctx, namespace, deploymentName, entry); err != nil {
+		h.logger.WarnContext(requestCtx, "Failed to record scale history",
+			"namespace", namespace, "deployment", deploymentName, "error", err)
 	}
+
+	return &apiv1.Scale{
+		Namespace:       namespace,
+		Name:            deploymentName,
+		Replicas:        reqBody.Replicas,
+		ResourceVersion: resourceVersion,
+		HPA:             hpaStatus(pinned),
+		Forced:          forced,
+		Warnings:        warnings,
+		CostDelta:       h.costDelta(deployment, oldReplicas, reqBody.Replicas),
+	}, nil
 }
 
-// listDeployments handles the /deployments endpoint to list deployments
-func ListDeployments(w http.ResponseWriter, r *http.Request, deploymentLister appslisters.DeploymentLister) {
-	namespace := r.URL.Query().Get("namespace")
+// rollBackHPAPin puts back the HPA as it was before a scale pinned it, once the
+// scale has failed. The scale's own deadline may be what failed it, so this gets
+// a fresh one.
+func (h *Handlers) rollBackHPAPin(requestCtx context.Context, before *autoscalingv2.HorizontalPodAutoscaler) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(requestCtx), h.updateScaleTimeout)
+	defer cancel()
 
-	list, err := deploymentLister.Deployments(namespace).List(labels.Everything())
+	if _, err := h.unpinHPA(ctx, before); err != nil {
+		h.logger.ErrorContext(requestCtx, "Failed to roll back HorizontalPodAutoscaler pin; its bounds stay pinned",
+			"namespace", before.Namespace, "hpa", before.Name, "error", err)
+		return
+	}
+	h.logger.InfoContext(requestCtx, "Rolled back HorizontalPodAutoscaler pin",
+		"namespace", before.Namespace, "hpa", before.Name)
+}
+
+// listDeployments returns the cached deployments in the namespace, or in every
+// served namespace when it is empty, ordered by namespace and name
+func (h *Handlers) listDeployments(ctx context.Context, namespace string) ([]*appsv1.Deployment, *apiError) {
+	if namespace != "" {
+		if apiErr := h.checkNamespace(namespace); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	_, span := tracing.Start(ctx, "lister.ListDeployments", semconv.K8SNamespaceName(namespace))
+	list, err := h.deploymentLister.Deployments(namespace).List(labels.Everything())
+	tracing.End(span, err)
 	if err != nil {
-		log.Printf("Error listing deployments: %v", err)
-		writeJSONError(w, apiError{
+		h.logger.ErrorContext(ctx, "Error listing deployments", "namespace", namespace, "error", err)
+		return nil, &apiError{
 			Message: "Failed to list deployments",
 			Code:    http.StatusInternalServerError,
-		})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Namespace != list[j].Namespace {
+			return list[i].Namespace < list[j].Namespace
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// GetScaleHistory handles GET /api/v1/namespaces/{namespace}/deployments/{name}/history
+// and its legacy alias /deployments/{namespace}/{name}/history
+func (h *Handlers) GetScaleHistory(w http.ResponseWriter, r *http.Request) {
+	namespace := r.PathValue("namespace")
+	deploymentName := r.PathValue("name")
+	audit.SetTarget(r.Context(), namespace, deploymentName)
+
+	if apiErr := h.checkNamespace(namespace); apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+	limit, offset, apiErr := parsePagination(r)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
 		return
 	}
 
-	deployments := make([]string, 0, len(list))
-	for _, deployment := range list {
-		deployments = append(deployments, fmt.Sprintf("%s/%s", deployment.Namespace, deployment.Name))
+	entries, err := h.history.List(r.Context(), namespace, deploymentName)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Error reading scale history",
+			"namespace", namespace, "deployment", deploymentName, "error", err)
+		writeProblem(w, kubernetesError(err, "Scale history not found"))
+		return
 	}
 
-	response := map[string]interface{}{
-		"deployments": deployments,
+	response := apiv1.ScaleHistory{
+		Entries: []apiv1.ScaleHistoryEntry{},
+	}
+	if offset < len(entries) {
+		end := offset + limit
+		if end < len(entries) {
+			response.Continue = strconv.Itoa(end)
+		} else {
+			end = len(entries)
+		}
+		for _, entry := range entries[offset:end] {
+			response.Entries = append(response.Entries, apiv1.ScaleHistoryEntry(entry))
+		}
 	}
 
 	if err := encodeAndWriteJSON(w, response); err != nil {
 		writeInternalServerError(w, err)
 	}
 }
+
+// scaleOf returns the scale of a cached deployment
+func scaleOf(deployment *appsv1.Deployment) *apiv1.Scale {
+	return &apiv1.Scale{
+		Namespace:       deployment.Namespace,
+		Name:            deployment.Name,
+		Replicas:        replicasOf(deployment),
+		ResourceVersion: deployment.ResourceVersion,
+	}
+}
+
+// replicasOf returns the deployment's desired replicas, which the API server
+// defaults to 1 when unset
+func replicasOf(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// AdminOnly serves handler only to the given client identities, refusing others
+// with 403 Forbidden
+func AdminOnly(identities []string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if identity := clientIdentity(r); !slices.Contains(identities, identity) {
+			writeProblem(w, apiError{
+				Message: fmt.Sprintf("Client %s is not an admin identity", identity),
+				Code:    http.StatusForbidden,
+			})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// LogLevel handles the /debug/loglevel endpoint, reporting the current level on GET
+// and changing it on PUT with a body such as {"level":"debug"}
+func LogLevel(w http.ResponseWriter, r *http.Request, level *slog.LevelVar) {
+	if r.Method == http.MethodPut {
+		var reqBody struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			writeProblem(w, apiError{
+				Message: "Invalid request body",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+
+		parsed, err := logging.ParseLevel(reqBody.Level)
+		if err != nil {
+			writeProblem(w, apiError{
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+
+		previous := level.Level()
+		level.Set(parsed)
+		slog.InfoContext(r.Context(), "Log level changed", "from", previous.String(), "to", parsed.String(), "by", clientIdentity(r))
+	}
+
+	response := map[string]string{
+		"level": strings.ToLower(level.Level().String()),
+	}
+	if err := encodeAndWriteJSON(w, response); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// EffectiveConfig handles the /debug/config endpoint, reporting the configuration
+// the server started with, with secrets redacted
+func EffectiveConfig(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	if err := encodeAndWriteJSON(w, cfg.Redacted()); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
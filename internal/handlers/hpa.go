@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"k8s-deployment-scaler/internal/audit"
+	"k8s-deployment-scaler/internal/tracing"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// hpaFor returns the HorizontalPodAutoscaler scaling the deployment, or nil if
+// there is none or HPAs aren't watched. Should several target it, the first by
+// name is returned.
+func (h *Handlers) hpaFor(ctx context.Context, namespace, deploymentName string) *autoscalingv2.HorizontalPodAutoscaler {
+	if h.hpaLister == nil {
+		return nil
+	}
+
+	_, span := tracing.Start(ctx, "lister.ListHorizontalPodAutoscalers", semconv.K8SNamespaceName(namespace))
+	list, err := h.hpaLister.HorizontalPodAutoscalers(namespace).List(labels.Everything())
+	tracing.End(span, err)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Error listing HorizontalPodAutoscalers", "namespace", namespace, "error", err)
+		return nil
+	}
+
+	var found *autoscalingv2.HorizontalPodAutoscaler
+	for _, hpa := range list {
+		if targetsDeployment(hpa, deploymentName) && (found == nil || hpa.Name < found.Name) {
+			found = hpa
+		}
+	}
+	return found
+}
+
+// targetsDeployment reports whether the HPA scales the named deployment
+func targetsDeployment(hpa *autoscalingv2.HorizontalPodAutoscaler, deploymentName string) bool {
+	ref := hpa.Spec.ScaleTargetRef
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err == nil && gv.Group == appsv1.GroupName && ref.Kind == "Deployment" && ref.Name == deploymentName
+}
+
+// hpaStatus describes the HPA for the API, or returns nil for a nil HPA
+func hpaStatus(hpa *autoscalingv2.HorizontalPodAutoscaler) *apiv1.HorizontalPodAutoscaler {
+	if hpa == nil {
+		return nil
+	}
+	status := &apiv1.HorizontalPodAutoscaler{
+		Name:            hpa.Name,
+		MinReplicas:     minReplicasOf(hpa),
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+	}
+	if bounds, ok := pinnedFrom(hpa); ok {
+		status.PinnedFrom = &bounds
+	}
+	return status
+}
+
+// minReplicasOf returns the HPA's minimum, which the API server defaults to 1
+func minReplicasOf(hpa *autoscalingv2.HorizontalPodAutoscaler) int32 {
+	if hpa.Spec.MinReplicas == nil {
+		return 1
+	}
+	return *hpa.Spec.MinReplicas
+}
+
+// pinnedFrom returns the original bounds recorded on an HPA pinned by mode=hpa
+func pinnedFrom(hpa *autoscalingv2.HorizontalPodAutoscaler) (apiv1.HPABounds, bool) {
+	minReplicas, minErr := strconv.ParseInt(hpa.Annotations[AnnotationOriginalMinReplicas], 10, 32)
+	maxReplicas, maxErr := strconv.ParseInt(hpa.Annotations[AnnotationOriginalMaxReplicas], 10, 32)
+	if minErr != nil || maxErr != nil {
+		return apiv1.HPABounds{}, false
+	}
+	return apiv1.HPABounds{MinReplicas: int32(minReplicas), MaxReplicas: int32(maxReplicas)}, true
+}
+
+// pinHPA sets both of the HPA's bounds to replicas. The bounds it had are
+// recorded in annotations first, unless an earlier pin already recorded them.
+func (h *Handlers) pinHPA(ctx context.Context, hpa *autoscalingv2.HorizontalPodAutoscaler, replicas int32) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	annotations := map[string]interface{}{}
+	if _, pinned := pinnedFrom(hpa); !pinned {
+		annotations[AnnotationOriginalMinReplicas] = strconv.Itoa(int(minReplicasOf(hpa)))
+		annotations[AnnotationOriginalMaxReplicas] = strconv.Itoa(int(hpa.Spec.MaxReplicas))
+	}
+	return h.patchHPA(ctx, hpa, apiv1.HPABounds{MinReplicas: replicas, MaxReplicas: replicas}, annotations)
+}
+
+// unpinHPA undoes pinHPA after the scale it was pinned for failed, putting back
+// the bounds and annotations of the HPA as it was before
+func (h *Handlers) unpinHPA(ctx context.Context, before *autoscalingv2.HorizontalPodAutoscaler) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	annotations := map[string]interface{}{}
+	if _, pinned := pinnedFrom(before); !pinned {
+		annotations[AnnotationOriginalMinReplicas] = nil
+		annotations[AnnotationOriginalMaxReplicas] = nil
+	}
+	return h.patchHPA(ctx, before, apiv1.HPABounds{MinReplicas: minReplicasOf(before), MaxReplicas: before.Spec.MaxReplicas}, annotations)
+}
+
+// restoreHPA hands the deployment back to a pinned HPA, restoring the bounds
+// recorded when it was first pinned and removing the record
+func (h *Handlers) restoreHPA(ctx context.Context, hpa *autoscalingv2.HorizontalPodAutoscaler, original apiv1.HPABounds) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	return h.patchHPA(ctx, hpa, original, map[string]interface{}{
+		AnnotationOriginalMinReplicas: nil,
+		AnnotationOriginalMaxReplicas: nil,
+	})
+}
+
+// patchHPA sets the HPA's bounds and merges the annotations, nil values removing them
+func (h *Handlers) patchHPA(ctx context.Context, hpa *autoscalingv2.HorizontalPodAutoscaler, bounds apiv1.HPABounds, annotations map[string]interface{}) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+		"spec": map[string]interface{}{
+			"minReplicas": bounds.MinReplicas,
+			"maxReplicas": bounds.MaxReplicas,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := tracing.Start(ctx, "kubernetes.PatchHorizontalPodAutoscaler", semconv.K8SNamespaceName(hpa.Namespace))
+	patched, err := h.clientset.AutoscalingV2().HorizontalPodAutoscalers(hpa.Namespace).Patch(ctx, hpa.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	tracing.End(span, err)
+	return patched, err
+}
+
+// RestoreHPA handles POST /api/v1/namespaces/{namespace}/deployments/{name}/hpa/restore,
+// restoring the bounds of an HPA pinned by a scale with mode=hpa
+func (h *Handlers) RestoreHPA(w http.ResponseWriter, r *http.Request) {
+	hpa, apiErr := h.restorePinnedHPA(r.Context(), clientIdentity(r), r.PathValue("namespace"), r.PathValue("name"))
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+	if err := encodeAndWriteJSON(w, hpa); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// restorePinnedHPA restores the original bounds of the pinned HPA scaling the
+// deployment on behalf of actor. Protected deployments' HPAs are left alone.
+func (h *Handlers) restorePinnedHPA(ctx context.Context, actor, namespace, deploymentName string) (*apiv1.HorizontalPodAutoscaler, *apiError) {
+	audit.SetTarget(ctx, namespace, deploymentName)
+	if apiErr := h.checkNamespace(namespace); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var deploymentLabels map[string]string
+	if deployment, exists := h.getDeploymentFromCache(ctx, namespace, deploymentName); exists {
+		deploymentLabels = deployment.Labels
+	}
+	if err := h.protection.Check(namespace, deploymentName, deploymentLabels); err != nil {
+		return nil, &apiError{
+			Message: fmt.Sprintf("Deployment %s/%s is protected: %v", namespace, deploymentName, err),
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	hpa := h.hpaFor(ctx, namespace, deploymentName)
+	if hpa == nil {
+		return nil, &apiError{
+			Message: fmt.Sprintf("Deployment %s/%s has no HorizontalPodAutoscaler", namespace, deploymentName),
+			Code:    http.StatusNotFound,
+		}
+	}
+	original, pinned := pinnedFrom(hpa)
+	if !pinned {
+		return nil, &apiError{
+			Message: fmt.Sprintf("HorizontalPodAutoscaler %s is not pinned", hpa.Name),
+			Code:    http.StatusConflict,
+		}
+	}
+
+	restored, err := h.restoreHPA(ctx, hpa, original)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to restore HorizontalPodAutoscaler",
+			"namespace", namespace, "deployment", deploymentName, "hpa", hpa.Name, "error", err)
+		apiErr := kubernetesError(err, fmt.Sprintf("HorizontalPodAutoscaler %s not found", hpa.Name))
+		return nil, &apiErr
+	}
+	h.logger.InfoContext(ctx, "Restored HorizontalPodAutoscaler",
+		"namespace", namespace, "deployment", deploymentName, "hpa", hpa.Name, "actor", actor,
+		"minReplicas", original.MinReplicas, "maxReplicas", original.MaxReplicas)
+	return hpaStatus(restored), nil
+}
@@ -0,0 +1,338 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/handlers"
+	"k8s-deployment-scaler/internal/server"
+	scalerv1 "k8s-deployment-scaler/pkg/api/scaler/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newHPA returns an HPA scaling the named resource between minReplicas and maxReplicas
+func newHPA(name, kind, target string, minReplicas, maxReplicas int32) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: kind, Name: target},
+			MinReplicas:    int32Ptr(minReplicas),
+			MaxReplicas:    maxReplicas,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 3, DesiredReplicas: 4},
+	}
+}
+
+func TestHorizontalPodAutoscalers(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset := fake.NewSimpleClientset(
+		newDeployment("default", "web", 3, nil),
+		newDeployment("default", "db", 1, nil),
+		newHPA("web", "Deployment", "web", 2, 10),
+		newHPA("db", "StatefulSet", "db", 1, 3),
+	)
+	addScaleReactors(fakeClientset)
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	hpaInformer := factory.Autoscaling().V2().HorizontalPodAutoscalers()
+	hpaInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(), server.WithHPAInformer(hpaInformer))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "The HPA is reported",
+			method:         "GET",
+			url:            "/replica-count?namespace=default&deployment=web",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":3,"hpa":{"name":"web","minReplicas":2,"maxReplicas":10,"currentReplicas":3,"desiredReplicas":4}}`,
+		},
+		{
+			name:           "Scaling against the HPA",
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web",
+			body:           `{"replicas":5}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:Conflict","title":"Conflict","status":409,"detail":"Deployment default/web is scaled by HorizontalPodAutoscaler web (min 2, max 10); set mode=hpa to pin its bounds to the requested count","instance":"test-request-id","reason":"Conflict"}`,
+		},
+		{
+			name:           "Unknown mode",
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web&mode=force",
+			body:           `{"replicas":5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:ValidationFailed","title":"Validation failed","status":400,"detail":"Unknown mode \"force\"; the only mode is hpa","instance":"test-request-id","reason":"ValidationFailed"}`,
+		},
+		{
+			name:           "Pinning to zero",
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web&mode=hpa",
+			body:           `{"replicas":0}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:ValidationFailed","title":"Validation failed","status":400,"detail":"HorizontalPodAutoscaler web can't be pinned to 0 replicas","instance":"test-request-id","reason":"ValidationFailed"}`,
+		},
+		{
+			name:           "Pinning the HPA",
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web&mode=hpa",
+			body:           `{"replicas":5}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":5,"hpa":{"name":"web","minReplicas":5,"maxReplicas":5,"currentReplicas":3,"desiredReplicas":4,"pinnedFrom":{"minReplicas":2,"maxReplicas":10}}}`,
+		},
+		{
+			name:           "Pinning again keeps the original bounds",
+			method:         "PUT",
+			url:            "/api/v1/namespaces/default/deployments/web/scale?mode=hpa",
+			body:           `{"replicas":6}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":6,"hpa":{"name":"web","minReplicas":6,"maxReplicas":6,"currentReplicas":3,"desiredReplicas":4,"pinnedFrom":{"minReplicas":2,"maxReplicas":10}}}`,
+		},
+		{
+			name:           "HPAs of other kinds are ignored",
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=db",
+			body:           `{"replicas":2}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":2}`,
+		},
+	}
+
+	// Run in order: the pins affect later responses
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		req.Header.Set("X-Request-ID", testRequestID)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rr.Code, tt.expectedStatus, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tt.expectedBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.expectedBody)
+		}
+	}
+
+	hpa, err := fakeClientset.AutoscalingV2().HorizontalPodAutoscalers("default").Get(context.TODO(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *hpa.Spec.MinReplicas != 6 || hpa.Spec.MaxReplicas != 6 {
+		t.Errorf("HPA bounds = %d-%d, want 6-6", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	if hpa.Annotations[handlers.AnnotationOriginalMinReplicas] != "2" || hpa.Annotations[handlers.AnnotationOriginalMaxReplicas] != "10" {
+		t.Errorf("HPA annotations = %v, want the original bounds 2 and 10", hpa.Annotations)
+	}
+	for name, want := range map[string]int32{"web": 6, "db": 2} {
+		deployment, err := fakeClientset.AppsV1().Deployments("default").Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil || *deployment.Spec.Replicas != want {
+			t.Errorf("%s = %v, %v; want %d replicas", name, deployment, err, want)
+		}
+	}
+}
+
+func TestHorizontalPodAutoscalerRollbackAndRestore(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset := fake.NewSimpleClientset(
+		newDeployment("default", "web", 3, nil),
+		newDeployment("default", "db", 1, nil),
+		newHPA("web", "Deployment", "web", 2, 10),
+	)
+	addScaleReactors(fakeClientset)
+
+	// The first scale fails after the HPA is pinned
+	var attempts atomic.Int32
+	fakeClientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "scale" && attempts.Add(1) == 1 {
+			return true, nil, k8serrors.NewServiceUnavailable("api server unavailable")
+		}
+		return false, nil, nil
+	})
+
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	hpaInformer := factory.Autoscaling().V2().HorizontalPodAutoscalers()
+	hpaInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(), server.WithHPAInformer(hpaInformer))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	getHPA := func() *autoscalingv2.HorizontalPodAutoscaler {
+		t.Helper()
+		hpa, err := fakeClientset.AutoscalingV2().HorizontalPodAutoscalers("default").Get(context.TODO(), "web", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hpa
+	}
+	checkUnpinned := func(step string) {
+		t.Helper()
+		hpa := getHPA()
+		if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 10 {
+			t.Errorf("%s: HPA bounds = %d-%d, want 2-10", step, *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+		}
+		if _, ok := hpa.Annotations[handlers.AnnotationOriginalMinReplicas]; ok {
+			t.Errorf("%s: HPA annotations = %v, want the original bounds removed", step, hpa.Annotations)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		check          func(step string)
+	}{
+		{
+			name:           "A failed scale rolls back the pin",
+			method:         "PUT",
+			url:            "/api/v1/namespaces/default/deployments/web/scale?mode=hpa",
+			body:           `{"replicas":5}`,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:UpstreamUnavailable","title":"Kubernetes API unavailable","status":503,"detail":"api server unavailable","instance":"test-request-id","reason":"UpstreamUnavailable"}`,
+			check:          checkUnpinned,
+		},
+		{
+			name:           "Restoring an HPA that isn't pinned",
+			method:         "POST",
+			url:            "/api/v1/namespaces/default/deployments/web/hpa/restore",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:Conflict","title":"Conflict","status":409,"detail":"HorizontalPodAutoscaler web is not pinned","instance":"test-request-id","reason":"Conflict"}`,
+		},
+		{
+			name:           "Restoring without an HPA",
+			method:         "POST",
+			url:            "/api/v1/namespaces/default/deployments/db/hpa/restore",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:NotFound","title":"Not found","status":404,"detail":"Deployment default/db has no HorizontalPodAutoscaler","instance":"test-request-id","reason":"NotFound"}`,
+		},
+		{
+			name:           "Pinning the HPA",
+			method:         "PUT",
+			url:            "/api/v1/namespaces/default/deployments/web/scale?mode=hpa",
+			body:           `{"replicas":5}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":5,"hpa":{"name":"web","minReplicas":5,"maxReplicas":5,"currentReplicas":3,"desiredReplicas":4,"pinnedFrom":{"minReplicas":2,"maxReplicas":10}}}`,
+		},
+		{
+			name:           "Restoring the HPA",
+			method:         "POST",
+			url:            "/api/v1/namespaces/default/deployments/web/hpa/restore",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"web","minReplicas":2,"maxReplicas":10,"currentReplicas":3,"desiredReplicas":4}`,
+			check:          checkUnpinned,
+		},
+	}
+
+	// Run in order: each step sees the HPA as the one before left it
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		req.Header.Set("X-Request-ID", testRequestID)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rr.Code, tt.expectedStatus, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tt.expectedBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.expectedBody)
+		}
+		if tt.check != nil {
+			tt.check(tt.name)
+		}
+
+		// Let the informer catch up with the change
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestGRPCPinAndRestoreHPA(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset := fake.NewSimpleClientset(
+		newDeployment("default", "web", 3, nil),
+		newHPA("web", "Deployment", "web", 2, 10),
+	)
+	addScaleReactors(fakeClientset)
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	hpaInformer := factory.Autoscaling().V2().HorizontalPodAutoscalers()
+	hpaInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(), server.WithHPAInformer(hpaInformer))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	client := dialBufconn(t, srv.GRPC, insecure.NewCredentials())
+	ctx := context.Background()
+
+	_, err = client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "web", Replicas: 5})
+	if code := status.Code(err); code != codes.Aborted {
+		t.Errorf("SetScale() without pin_hpa: code = %v, want %v (error %v)", code, codes.Aborted, err)
+	}
+
+	set, err := client.SetScale(ctx, &scalerv1.SetScaleRequest{Namespace: "default", Name: "web", Replicas: 5, PinHpa: true})
+	if err != nil {
+		t.Fatalf("SetScale() with pin_hpa error = %v", err)
+	}
+	if hpa := set.Scale.Hpa; hpa.GetMinReplicas() != 5 || hpa.GetMaxReplicas() != 5 || hpa.GetPinnedFrom().GetMinReplicas() != 2 || hpa.GetPinnedFrom().GetMaxReplicas() != 10 {
+		t.Errorf("SetScale() hpa = %v, want pinned to 5 from 2-10", hpa)
+	}
+
+	// Let the informer catch up with the pin
+	time.Sleep(100 * time.Millisecond)
+
+	restored, err := client.RestoreHPA(ctx, &scalerv1.RestoreHPARequest{Namespace: "default", Name: "web"})
+	if err != nil {
+		t.Fatalf("RestoreHPA() error = %v", err)
+	}
+	if hpa := restored.Hpa; hpa.GetMinReplicas() != 2 || hpa.GetMaxReplicas() != 10 || hpa.GetPinnedFrom() != nil {
+		t.Errorf("RestoreHPA() hpa = %v, want 2-10 and no longer pinned", hpa)
+	}
+}
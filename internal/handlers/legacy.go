@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+)
+
+// Responses of the legacy routes, which predate /api/v1 and keep their original shapes
+
+type replicaCountResponse struct {
+	ReplicaCount int32                          `json:"replicaCount"`
+	HPA          *apiv1.HorizontalPodAutoscaler `json:"hpa,omitempty"`
+	Forced       []string                       `json:"forced,omitempty"`
+	Warnings     []string                       `json:"warnings,omitempty"`
+	CostDelta    *apiv1.Cost                    `json:"costDelta,omitempty"`
+}
+
+type replicaCountWaitResponse struct {
+	ReplicaCount    int32                          `json:"replicaCount"`
+	ResourceVersion string                         `json:"resourceVersion"`
+	TimedOut        bool                           `json:"timedOut"`
+	HPA             *apiv1.HorizontalPodAutoscaler `json:"hpa,omitempty"`
+}
+
+type deploymentNamesResponse struct {
+	Deployments []string `json:"deployments"`
+}
+
+// GetReplicaCount handles GET /replica-count, the deprecated alias of GetScale
+func (h *Handlers) GetReplicaCount(w http.ResponseWriter, r *http.Request) {
+	namespace, deploymentName, apiErr := validateQueryParams(r)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+
+	scale, ok := h.getScale(w, r, namespace, deploymentName)
+	if !ok {
+		return
+	}
+
+	var response interface{} = replicaCountResponse{ReplicaCount: scale.Replicas, HPA: scale.HPA}
+	if scale.TimedOut != nil {
+		response = replicaCountWaitResponse{
+			ReplicaCount:    scale.Replicas,
+			ResourceVersion: scale.ResourceVersion,
+			TimedOut:        *scale.TimedOut,
+			HPA:             scale.HPA,
+		}
+	}
+	if err := encodeAndWriteJSON(w, response); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// PostReplicaCount handles POST /replica-count, the deprecated alias of PutScale
+func (h *Handlers) PostReplicaCount(w http.ResponseWriter, r *http.Request) {
+	namespace, deploymentName, apiErr := validateQueryParams(r)
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+
+	scale, ok := h.setScale(w, r, namespace, deploymentName)
+	if !ok {
+		return
+	}
+	if err := encodeAndWriteJSON(w, replicaCountResponse{ReplicaCount: scale.Replicas, HPA: scale.HPA, Forced: scale.Forced, Warnings: scale.Warnings, CostDelta: scale.CostDelta}); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
+
+// ListDeployments handles GET /deployments, the deprecated alias of GetDeploymentList
+func (h *Handlers) ListDeployments(w http.ResponseWriter, r *http.Request) {
+	list, apiErr := h.listDeployments(r.Context(), r.URL.Query().Get("namespace"))
+	if apiErr != nil {
+		writeProblem(w, *apiErr)
+		return
+	}
+
+	response := deploymentNamesResponse{Deployments: make([]string, 0, len(list))}
+	for _, deployment := range list {
+		response.Deployments = append(response.Deployments, deployment.Namespace+"/"+deployment.Name)
+	}
+	if err := encodeAndWriteJSON(w, response); err != nil {
+		writeInternalServerError(w, err)
+	}
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+// operation documents one route of the API
+type operation struct {
+	pattern     string
+	id          string
+	summary     string
+	query       []*openapi3.Parameter
+	request     interface{}
+	responses   []interface{}
+	errors      []int
+	deprecated  bool
+	description string
+}
+
+// Query parameters shared by several operations
+var (
+	waitParameters = []*openapi3.Parameter{
+		openapi3.NewQueryParameter("waitForChange").
+			WithDescription("Wait until the deployment's resourceVersion differs from this one").
+			WithSchema(openapi3.NewStringSchema()),
+		openapi3.NewQueryParameter("until").
+			WithDescription("Wait until the deployment has this many replicas").
+			WithSchema(openapi3.NewInt32Schema().WithMin(0)),
+		openapi3.NewQueryParameter("timeout").
+			WithDescription(fmt.Sprintf("How long to wait, as a Go duration; at most %v, %v by default", maxWaitTimeout, defaultWaitTimeout)).
+			WithSchema(openapi3.NewStringSchema()),
+	}
+	scaleParameters = []*openapi3.Parameter{
+		openapi3.NewQueryParameter("force").
+			WithDescription("Scale during the cooldown or past a PodDisruptionBudget, as listed in the response's forced; only clients listed in scale.forceIdentities may set it during the cooldown, and only those in scale.pdbForceIdentities past a PodDisruptionBudget").
+			WithSchema(openapi3.NewBoolSchema()),
+		openapi3.NewQueryParameter("mode").
+			WithDescription("hpa pins the bounds of the HorizontalPodAutoscaler scaling the deployment to the requested count, recording the original bounds in its annotations; without it, such scales are refused with 409").
+			WithSchema(openapi3.NewStringSchema().WithEnum("hpa")),
+	}
+	paginationParameters = []*openapi3.Parameter{
+		openapi3.NewQueryParameter("limit").
+			WithDescription("Maximum number of entries to return").
+			WithSchema(openapi3.NewIntegerSchema().WithMin(1).WithMax(maxPageLimit).WithDefault(defaultPageLimit)),
+		openapi3.NewQueryParameter("continue").
+			WithDescription("The continue token from the previous page").
+			WithSchema(openapi3.NewStringSchema()),
+	}
+	legacyTargetParameters = []*openapi3.Parameter{
+		openapi3.NewQueryParameter("namespace").WithRequired(true).WithSchema(openapi3.NewStringSchema()),
+		openapi3.NewQueryParameter("deployment").WithRequired(true).WithSchema(openapi3.NewStringSchema()),
+	}
+)
+
+// operations lists every API route; the server registers each of these patterns
+var operations = []operation{
+	{
+		pattern:   "GET /healthz",
+		id:        "healthCheck",
+		summary:   "Check connectivity to the Kubernetes API server",
+		responses: []interface{}{apiv1.Status{}},
+		errors:    []int{http.StatusServiceUnavailable},
+	},
+	{
+		pattern:   "GET /api/v1/deployments",
+		id:        "listAllDeployments",
+		summary:   "List deployments in all namespaces",
+		responses: []interface{}{apiv1.DeploymentList{}},
+	},
+	{
+		pattern:   "GET /api/v1/namespaces/{namespace}/deployments",
+		id:        "listDeployments",
+		summary:   "List deployments in a namespace",
+		responses: []interface{}{apiv1.DeploymentList{}},
+	},
+	{
+		pattern:     "GET /api/v1/namespaces/{namespace}/deployments/{name}/scale",
+		id:          "getScale",
+		summary:     "Get a deployment's replica count",
+		description: "With waitForChange or until, the request blocks until the deployment meets every condition or the timeout expires.",
+		query:       waitParameters,
+		responses:   []interface{}{apiv1.Scale{}},
+		errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		pattern:   "PUT /api/v1/namespaces/{namespace}/deployments/{name}/scale",
+		id:        "updateScale",
+		summary:   "Set a deployment's replica count",
+		query:     scaleParameters,
+		request:   apiv1.ScaleRequest{},
+		responses: []interface{}{apiv1.Scale{}},
+		errors:    kubernetesErrors,
+	},
+	{
+		pattern:     "POST /api/v1/namespaces/{namespace}/deployments/{name}/hpa/restore",
+		id:          "restoreHPA",
+		summary:     "Hand a deployment back to its HorizontalPodAutoscaler",
+		description: "Restores the bounds the HPA had before a scale with mode=hpa pinned them, and removes the annotations recording them. Answers 404 when the deployment has no HPA and 409 when the HPA isn't pinned.",
+		responses:   []interface{}{apiv1.HorizontalPodAutoscaler{}},
+		errors:      kubernetesErrors,
+	},
+	{
+		pattern:   "GET /api/v1/namespaces/{namespace}/deployments/{name}/history",
+		id:        "getScaleHistory",
+		summary:   "Get a deployment's scale history, newest entry first",
+		query:     paginationParameters,
+		responses: []interface{}{apiv1.ScaleHistory{}},
+		errors:    kubernetesErrors,
+	},
+	{
+		pattern:     "GET /api/v1/namespaces/{namespace}/deployments/{name}/cost",
+		id:          "getDeploymentCost",
+		summary:     "Estimate a deployment's cost, and the change scaling it would make",
+		description: "Prices the CPU and memory requests of the deployment's pod template at the prices of the node pool its node selector picks. Answers 404 when no price table is configured.",
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("replicas").
+				WithDescription("Also estimate the cost at this many replicas, and the change from the current count").
+				WithSchema(openapi3.NewInt32Schema().WithMin(0)),
+		},
+		responses: []interface{}{apiv1.DeploymentCost{}},
+		errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		pattern:     "GET /api/v1/namespaces/{namespace}/cost",
+		id:          "getNamespaceCost",
+		summary:     "Estimate the cost of every deployment in a namespace, and their total",
+		description: "Answers 404 when no price table is configured.",
+		responses:   []interface{}{apiv1.NamespaceCost{}},
+		errors:      []int{http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		pattern:    "GET /replica-count",
+		id:         "legacyGetReplicaCount",
+		summary:    "Get a deployment's replica count; use getScale instead",
+		query:      append(append([]*openapi3.Parameter{}, legacyTargetParameters...), waitParameters...),
+		responses:  []interface{}{replicaCountResponse{}, replicaCountWaitResponse{}},
+		errors:     []int{http.StatusBadRequest, http.StatusNotFound},
+		deprecated: true,
+	},
+	{
+		pattern:    "POST /replica-count",
+		id:         "legacySetReplicaCount",
+		summary:    "Set a deployment's replica count; use updateScale instead",
+		query:      append(append([]*openapi3.Parameter{}, legacyTargetParameters...), scaleParameters...),
+		request:    apiv1.ScaleRequest{},
+		responses:  []interface{}{replicaCountResponse{}},
+		errors:     kubernetesErrors,
+		deprecated: true,
+	},
+	{
+		pattern: "GET /deployments",
+		id:      "legacyListDeployments",
+		summary: "List deployments as namespace/name strings; use listDeployments instead",
+		query: []*openapi3.Parameter{
+			openapi3.NewQueryParameter("namespace").WithSchema(openapi3.NewStringSchema()),
+		},
+		responses:  []interface{}{deploymentNamesResponse{}},
+		deprecated: true,
+	},
+	{
+		pattern:    "GET /deployments/{namespace}/{name}/history",
+		id:         "legacyGetScaleHistory",
+		summary:    "Get a deployment's scale history; use getScaleHistory instead",
+		query:      paginationParameters,
+		responses:  []interface{}{apiv1.ScaleHistory{}},
+		errors:     kubernetesErrors,
+		deprecated: true,
+	},
+}
+
+// clusterOperations documents the routes spanning clusters: GET /clusters, the
+// /global routes, and every current route of operations under /clusters/{cluster}
+var clusterOperations = func() []operation {
+	ops := []operation{{
+		pattern:   "GET /clusters",
+		id:        "listClusters",
+		summary:   "List the clusters the scaler serves",
+		responses: []interface{}{apiv1.ClusterList{}},
+	}}
+	ops = append(ops,
+		operation{
+			pattern:     "GET /global/deployments",
+			id:          "listGlobalDeployments",
+			summary:     "List matching deployments across every cluster",
+			description: "Clusters that can't be queried are listed in errors and set partial; the others' deployments are still returned.",
+			query: []*openapi3.Parameter{
+				openapi3.NewQueryParameter("name").WithDescription("Only deployments of this name").WithSchema(openapi3.NewStringSchema()),
+				openapi3.NewQueryParameter("namespace").WithDescription("Only deployments in this namespace").WithSchema(openapi3.NewStringSchema()),
+				openapi3.NewQueryParameter("labelSelector").WithDescription("Only deployments whose labels match this Kubernetes label selector").WithSchema(openapi3.NewStringSchema()),
+			},
+			responses: []interface{}{apiv1.GlobalDeploymentList{}},
+			errors:    []int{http.StatusBadRequest},
+		},
+		operation{
+			pattern:     "PUT /global/namespaces/{namespace}/deployments/{name}/scale",
+			id:          "updateGlobalScale",
+			summary:     "Set a deployment's replica count in several clusters",
+			description: "Each cluster is scaled as by updateScale. Clusters where that fails are listed in errors and set partial; the others stay scaled. When every cluster fails, the request fails with their shared status, or 502 when they differ.",
+			query:       scaleParameters,
+			request:     apiv1.GlobalScaleRequest{},
+			responses:   []interface{}{apiv1.GlobalScale{}},
+			errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusBadGateway},
+		},
+	)
+	for _, op := range operations {
+		if op.deprecated {
+			continue
+		}
+		method, path, _ := strings.Cut(op.pattern, " ")
+		op.pattern = method + " /clusters/{cluster}" + path
+		op.id = "cluster" + strings.ToUpper(op.id[:1]) + op.id[1:]
+		op.summary += " in a cluster"
+		// The cluster may be unknown or still syncing
+		op.errors = append(append([]int{}, op.errors...), http.StatusNotFound, http.StatusServiceUnavailable)
+		ops = append(ops, op)
+	}
+	return ops
+}()
+
+// commonErrors may be returned by any route
+var commonErrors = []int{http.StatusTooManyRequests, http.StatusInternalServerError}
+
+// kubernetesErrors may be returned by routes that call the Kubernetes API, as
+// mapped by kubernetesError
+var kubernetesErrors = []int{
+	http.StatusBadRequest,
+	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusConflict,
+	http.StatusUnprocessableEntity,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// pathParameter matches the {name} wildcards of a mux pattern
+var pathParameter = regexp.MustCompile(`{([^}]+)}`)
+
+// openAPIDocument is built on first use and then served as is
+var openAPIDocument = sync.OnceValues(func() ([]byte, error) {
+	doc, err := OpenAPIDocument()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+})
+
+// OpenAPI handles the /api/v1/openapi.json endpoint
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := openAPIDocument()
+	if err != nil {
+		writeInternalServerError(w, err)
+		return
+	}
+	w.Write(doc)
+}
+
+// OpenAPIDocument describes the API as an OpenAPI 3 document, with schemas
+// generated from the request and response types
+func OpenAPIDocument() (*openapi3.T, error) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   "k8s-deployment-scaler",
+			Version: "v1",
+		},
+		Paths: openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			Schemas: openapi3.Schemas{},
+		},
+	}
+
+	problemRef, err := schemaRef(doc, apiv1.Problem{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range slices.Concat(operations, clusterOperations) {
+		method, path, _ := strings.Cut(op.pattern, " ")
+
+		operation := &openapi3.Operation{
+			OperationID: op.id,
+			Summary:     op.summary,
+			Description: op.description,
+			Deprecated:  op.deprecated,
+			Responses:   openapi3.NewResponsesWithCapacity(1 + len(op.errors) + len(commonErrors)),
+		}
+		for _, match := range pathParameter.FindAllStringSubmatch(path, -1) {
+			operation.AddParameter(openapi3.NewPathParameter(match[1]).WithSchema(openapi3.NewStringSchema()))
+		}
+		for _, parameter := range op.query {
+			operation.AddParameter(parameter)
+		}
+
+		if op.request != nil {
+			ref, err := schemaRef(doc, op.request)
+			if err != nil {
+				return nil, err
+			}
+			operation.RequestBody = &openapi3.RequestBodyRef{
+				Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(ref),
+			}
+		}
+
+		// Routes with several response bodies return exactly one of them
+		var refs openapi3.SchemaRefs
+		for _, response := range op.responses {
+			ref, err := schemaRef(doc, response)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, ref)
+		}
+		body := refs[0]
+		if len(refs) > 1 {
+			body = &openapi3.SchemaRef{Value: &openapi3.Schema{OneOf: refs}}
+		}
+		operation.Responses.Set("200", &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().WithDescription(http.StatusText(http.StatusOK)).WithJSONSchemaRef(body),
+		})
+		for _, code := range append(op.errors, commonErrors...) {
+			operation.Responses.Set(strconv.Itoa(code), &openapi3.ResponseRef{
+				Value: openapi3.NewResponse().WithDescription(http.StatusText(code)).
+					WithContent(openapi3.NewContentWithSchemaRef(problemRef, []string{apiv1.ProblemContentType})),
+			})
+		}
+
+		item := doc.Paths.Value(path)
+		if item == nil {
+			item = &openapi3.PathItem{}
+			doc.Paths.Set(path, item)
+		}
+		item.SetOperation(method, operation)
+	}
+	return doc, nil
+}
+
+// schemaRef adds the schema of value's type to the document's components, named
+// after the type, and returns a reference to it
+func schemaRef(doc *openapi3.T, value interface{}) (*openapi3.SchemaRef, error) {
+	name := schemaName(reflect.TypeOf(value))
+	if _, ok := doc.Components.Schemas[name]; !ok {
+		ref, err := openapi3gen.NewSchemaRefForValue(value, nil, openapi3gen.SchemaCustomizer(strictObject))
+		if err != nil {
+			return nil, fmt.Errorf("generating schema for %s: %w", name, err)
+		}
+		doc.Components.Schemas[name] = ref
+	}
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, nil), nil
+}
+
+// schemaName exports the names of the legacy response types
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// strictObject requires every field serialised without omitempty and forbids
+// properties the type doesn't declare. Error reasons are listed as an enum.
+func strictObject(_ string, t reflect.Type, _ reflect.StructTag, schema *openapi3.Schema) error {
+	if t == reflect.TypeOf(apiv1.Reason("")) {
+		for _, reason := range problemReasons {
+			schema.Enum = append(schema.Enum, reason)
+		}
+		return nil
+	}
+	if t.Kind() != reflect.Struct || !schema.Type.Is(openapi3.TypeObject) {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name, options, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	schema.WithoutAdditionalProperties()
+	return nil
+}
@@ -0,0 +1,155 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/cost"
+	"k8s-deployment-scaler/internal/server"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestOpenAPI checks the served document is valid and describes the responses
+// every route actually returns
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset, deploymentInformer, stopCh := setupTestEnvironment()
+	defer close(stopCh)
+
+	_, err := fakeClientset.AppsV1().Deployments("default").Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating test deployment: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	prices := &cost.PriceTable{Currency: "USD", Default: cost.Prices{CPUHour: 0.04, MemoryGiBHour: 0.005}}
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(), server.WithPriceTable(prices))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/openapi.json returned %d: %s", rr.Code, rr.Body.String())
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromData(rr.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to load the document: %v", err)
+	}
+	if err := doc.Validate(context.TODO()); err != nil {
+		t.Fatalf("Invalid document: %v", err)
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("Failed to route the document: %v", err)
+	}
+
+	// Run in order: the scales feed the history and later reads
+	requests := []struct {
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+	}{
+		{"GET", "/healthz", "", http.StatusOK},
+		{"GET", "/api/v1/deployments", "", http.StatusOK},
+		{"GET", "/api/v1/namespaces/default/deployments", "", http.StatusOK},
+		{"GET", "/api/v1/namespaces/default/deployments/web/scale", "", http.StatusOK},
+		{"GET", "/api/v1/namespaces/default/deployments/web/scale?until=99&timeout=1ms", "", http.StatusOK},
+		{"GET", "/api/v1/namespaces/default/deployments/missing/scale", "", http.StatusNotFound},
+		{"PUT", "/api/v1/namespaces/default/deployments/web/scale", `{"replicas":5,"reason":"load test"}`, http.StatusOK},
+		{"PUT", "/api/v1/namespaces/default/deployments/web/scale", `{"replicas":-1}`, http.StatusBadRequest},
+		{"POST", "/api/v1/namespaces/default/deployments/web/hpa/restore", "", http.StatusNotFound},
+		{"GET", "/api/v1/namespaces/default/deployments/web/history", "", http.StatusOK},
+		{"GET", "/api/v1/namespaces/default/deployments/web/history?limit=0", "", http.StatusBadRequest},
+		{"GET", "/api/v1/namespaces/default/deployments/web/cost?replicas=10", "", http.StatusOK},
+		{"GET", "/api/v1/namespaces/default/deployments/web/cost?replicas=-1", "", http.StatusBadRequest},
+		{"GET", "/api/v1/namespaces/default/deployments/missing/cost", "", http.StatusNotFound},
+		{"GET", "/api/v1/namespaces/default/cost", "", http.StatusOK},
+		{"GET", "/replica-count?namespace=default&deployment=web", "", http.StatusOK},
+		{"GET", "/replica-count?namespace=default&deployment=web&until=99&timeout=1ms", "", http.StatusOK},
+		{"GET", "/replica-count?namespace=default", "", http.StatusBadRequest},
+		{"POST", "/replica-count?namespace=default&deployment=web", `{"replicas":3}`, http.StatusOK},
+		{"GET", "/deployments?namespace=default", "", http.StatusOK},
+		{"GET", "/deployments/default/web/history?limit=1", "", http.StatusOK},
+		{"GET", "/clusters", "", http.StatusOK},
+		{"GET", "/clusters/local/healthz", "", http.StatusOK},
+		{"GET", "/clusters/local/api/v1/deployments", "", http.StatusOK},
+		{"GET", "/clusters/local/api/v1/namespaces/default/deployments/web/scale", "", http.StatusOK},
+		{"PUT", "/clusters/local/api/v1/namespaces/default/deployments/web/scale", `{"replicas":4}`, http.StatusOK},
+		{"GET", "/clusters/local/api/v1/namespaces/default/deployments/web/history", "", http.StatusOK},
+		{"GET", "/clusters/local/api/v1/namespaces/default/deployments/web/cost", "", http.StatusOK},
+		{"GET", "/clusters/local/api/v1/namespaces/default/cost", "", http.StatusOK},
+		{"GET", "/clusters/north/api/v1/deployments", "", http.StatusNotFound},
+		{"GET", "/global/deployments?name=web&labelSelector=app%3Dweb", "", http.StatusOK},
+		{"PUT", "/global/namespaces/default/deployments/web/scale", `{"replicas":5,"clusters":["local"]}`, http.StatusOK},
+		{"PUT", "/global/namespaces/default/deployments/web/scale", `{"replicas":5,"clusters":["north"]}`, http.StatusNotFound},
+	}
+
+	for _, tt := range requests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		req.Header.Set("X-Request-ID", testRequestID)
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		route, pathParams, err := router.FindRoute(req)
+		if err != nil {
+			t.Errorf("%s %s is not documented: %v", tt.method, tt.url, err)
+			continue
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		}
+		// Successful requests must be valid as documented
+		if tt.expectedStatus == http.StatusOK {
+			if err := openapi3filter.ValidateRequest(context.TODO(), input); err != nil {
+				t.Errorf("%s %s does not match the document: %v", tt.method, tt.url, err)
+			}
+			req.Body = io.NopCloser(strings.NewReader(tt.body))
+		}
+
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s %s returned %d, want %d: %s", tt.method, tt.url, rr.Code, tt.expectedStatus, rr.Body.String())
+			continue
+		}
+
+		err = openapi3filter.ValidateResponse(context.TODO(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rr.Code,
+			Header:                 rr.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(rr.Body.Bytes())),
+		})
+		if err != nil {
+			t.Errorf("%s %s response does not match the document: %v", tt.method, tt.url, err)
+		}
+
+		// Only the legacy routes are deprecated
+		deprecated := route.Operation.Deprecated
+		if got := rr.Header().Get("Deprecation") == "true"; got != deprecated {
+			t.Errorf("%s %s: Deprecation header set = %v, want %v", tt.method, tt.url, got, deprecated)
+		}
+	}
+}
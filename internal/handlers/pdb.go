@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s-deployment-scaler/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// pdbViolation is a PodDisruptionBudget a scale-down would violate
+type pdbViolation struct {
+	name string
+	// budget describes the bound violated, e.g. "minAvailable 4"
+	budget string
+}
+
+// violatedPDBs returns the PodDisruptionBudgets selecting the deployment's pods
+// that scaling it from oldReplicas down to newReplicas would violate, ordered by
+// name. Percentages are of oldReplicas, and only the deployment's own pods are
+// counted. Nothing is returned for scale-ups or when PDBs aren't watched.
+func (h *Handlers) violatedPDBs(ctx context.Context, deployment *appsv1.Deployment, oldReplicas, newReplicas int32) []pdbViolation {
+	if h.pdbLister == nil || deployment == nil || newReplicas >= oldReplicas {
+		return nil
+	}
+
+	_, span := tracing.Start(ctx, "lister.ListPodDisruptionBudgets", semconv.K8SNamespaceName(deployment.Namespace))
+	list, err := h.pdbLister.PodDisruptionBudgets(deployment.Namespace).List(labels.Everything())
+	tracing.End(span, err)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Error listing PodDisruptionBudgets", "namespace", deployment.Namespace, "error", err)
+		return nil
+	}
+
+	podLabels := labels.Set(deployment.Spec.Template.Labels)
+	var violations []pdbViolation
+	for _, pdb := range list {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || !selector.Matches(podLabels) {
+			continue
+		}
+		if budget, violated := violatesPDB(pdb, int(oldReplicas), int(newReplicas)); violated {
+			violations = append(violations, pdbViolation{name: pdb.Name, budget: budget})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].name < violations[j].name })
+	return violations
+}
+
+// violatesPDB reports whether scaling from oldReplicas down to newReplicas
+// breaks the PDB's minAvailable or maxUnavailable, describing the bound broken
+func violatesPDB(pdb *policyv1.PodDisruptionBudget, oldReplicas, newReplicas int) (string, bool) {
+	if minAvailable := pdb.Spec.MinAvailable; minAvailable != nil {
+		required, err := intstr.GetScaledValueFromIntOrPercent(minAvailable, oldReplicas, true)
+		if err == nil && newReplicas < required {
+			return budgetOf("minAvailable", minAvailable, required), true
+		}
+	}
+	if maxUnavailable := pdb.Spec.MaxUnavailable; maxUnavailable != nil {
+		allowed, err := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, oldReplicas, true)
+		if err == nil && oldReplicas-newReplicas > allowed {
+			return budgetOf("maxUnavailable", maxUnavailable, allowed), true
+		}
+	}
+	return "", false
+}
+
+// budgetOf describes a PDB bound, with the count a percentage resolved to
+func budgetOf(field string, value *intstr.IntOrString, resolved int) string {
+	if value.Type == intstr.String {
+		return fmt.Sprintf("%s %s, %d pods", field, value.StrVal, resolved)
+	}
+	return fmt.Sprintf("%s %d", field, resolved)
+}
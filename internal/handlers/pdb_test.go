@@ -0,0 +1,229 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/handlers"
+	"k8s-deployment-scaler/internal/server"
+	scalerv1 "k8s-deployment-scaler/pkg/api/scaler/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newPDB returns a PodDisruptionBudget selecting the pods labelled app
+func newPDB(name, app string, minAvailable, maxUnavailable *intstr.IntOrString) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			MinAvailable:   minAvailable,
+			MaxUnavailable: maxUnavailable,
+		},
+	}
+}
+
+func intOrString(value string) *intstr.IntOrString {
+	parsed := intstr.Parse(value)
+	return &parsed
+}
+
+func TestPodDisruptionBudgets(t *testing.T) {
+	t.Parallel()
+
+	web := newDeployment("default", "web", 5, nil)
+	web.Spec.Template = corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}}
+	fakeClientset := fake.NewSimpleClientset(
+		web,
+		newPDB("web-min", "web", intOrString("3"), nil),
+		newPDB("web-max", "web", nil, intOrString("50%")),
+		newPDB("db", "db", intOrString("10"), nil),
+	)
+	addScaleReactors(fakeClientset)
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	pdbInformer := factory.Policy().V1().PodDisruptionBudgets()
+	pdbInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	cfg := testConfig()
+	cfg.Scale.ForceIdentities = []string{"release-bot"}
+	cfg.Scale.PDBForceIdentities = []string{"sre-oncall"}
+	srv, err := server.New(fakeClientset, deploymentInformer, cfg, server.WithPDBInformer(pdbInformer))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		client         string
+		query          string
+		replicas       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Within both budgets",
+			replicas:       "4",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":4}`,
+		},
+		{
+			name:           "Past maxUnavailable",
+			replicas:       "1",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:Conflict","title":"Conflict","status":409,"detail":"Scaling default/web from 4 to 1 replicas would violate PodDisruptionBudget web-max (maxUnavailable 50%, 2 pods); set force=true to override","instance":"test-request-id","reason":"Conflict"}`,
+		},
+		{
+			name:           "Past minAvailable",
+			replicas:       "2",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:Conflict","title":"Conflict","status":409,"detail":"Scaling default/web from 4 to 2 replicas would violate PodDisruptionBudget web-min (minAvailable 3); set force=true to override","instance":"test-request-id","reason":"Conflict"}`,
+		},
+		{
+			name:           "Force without the privilege",
+			client:         "ci",
+			query:          "&force=true",
+			replicas:       "1",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:Forbidden","title":"Forbidden","status":403,"detail":"Client ci may not force a scale past a PodDisruptionBudget","instance":"test-request-id","reason":"Forbidden"}`,
+		},
+		{
+			name:           "Force by a client trusted only with the cooldown",
+			client:         "release-bot",
+			query:          "&force=true",
+			replicas:       "1",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:Forbidden","title":"Forbidden","status":403,"detail":"Client release-bot may not force a scale past a PodDisruptionBudget","instance":"test-request-id","reason":"Forbidden"}`,
+		},
+		{
+			name:           "Force by a privileged client",
+			client:         "sre-oncall",
+			query:          "&force=true",
+			replicas:       "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":1,"forced":["PodDisruptionBudget web-max","PodDisruptionBudget web-min"]}`,
+		},
+		{
+			name:           "Scaling up is never checked",
+			replicas:       "6",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":6}`,
+		},
+	}
+
+	// Run in order: each scale changes the count the next is checked from
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/replica-count?namespace=default&deployment=web"+tt.query, strings.NewReader(`{"replicas":`+tt.replicas+`}`))
+		req.Header.Set("X-Request-ID", testRequestID)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, withClientCert(req, tt.client))
+
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rr.Code, tt.expectedStatus, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tt.expectedBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.expectedBody)
+		}
+
+		// Wait for the cache to see the scale
+		err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+			stored, err := fakeClientset.AppsV1().Deployments("default").Get(context.TODO(), "web", metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			cached, err := deploymentInformer.Lister().Deployments("default").Get("web")
+			return err == nil && *cached.Spec.Replicas == *stored.Spec.Replicas, nil
+		})
+		if err != nil {
+			t.Fatalf("%s: cache never caught up: %v", tt.name, err)
+		}
+	}
+}
+
+// Deployments without matching pod labels aren't covered by a PDB
+func TestPodDisruptionBudgetsOtherPods(t *testing.T) {
+	t.Parallel()
+
+	fakeClientset := fake.NewSimpleClientset(
+		newDeployment("default", "api", 3, nil),
+		newPDB("web", "web", intOrString("100%"), nil),
+	)
+	addScaleReactors(fakeClientset)
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	pdbInformer := factory.Policy().V1().PodDisruptionBudgets()
+	pdbInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(), server.WithPDBInformer(pdbInformer))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/namespaces/default/deployments/api/scale", strings.NewReader(`{"replicas":0}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+}
+
+func TestGRPCForcedPodDisruptionBudget(t *testing.T) {
+	t.Parallel()
+
+	web := newDeployment("default", "web", 4, nil)
+	web.Spec.Template = corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}}
+	fakeClientset := fake.NewSimpleClientset(web, newPDB("web-min", "web", intOrString("3"), nil))
+	addScaleReactors(fakeClientset)
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	pdbInformer := factory.Policy().V1().PodDisruptionBudgets()
+	pdbInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	h, err := handlers.New(fakeClientset, deploymentInformer,
+		handlers.WithPodDisruptionBudgets(pdbInformer.Lister()),
+		handlers.WithForceIdentities([]string{"sre-oncall"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create handlers: %v", err)
+	}
+	serverTLS, clientTLS := newTestMTLS(t, "sre-oncall")
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverTLS)))
+	scalerv1.RegisterScalerServiceServer(s, h.GRPCService())
+	client := dialBufconn(t, s, credentials.NewTLS(clientTLS))
+
+	set, err := client.SetScale(context.Background(), &scalerv1.SetScaleRequest{Namespace: "default", Name: "web", Replicas: 1, Force: true})
+	if err != nil {
+		t.Fatalf("SetScale() error = %v", err)
+	}
+	if !slices.Equal(set.Scale.Forced, []string{"PodDisruptionBudget web-min"}) {
+		t.Errorf("SetScale() forced = %q, want the overridden PodDisruptionBudget", set.Scale.Forced)
+	}
+}
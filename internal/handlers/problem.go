@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"k8s-deployment-scaler/internal/logging"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// problemTypePrefix prefixes the reason to form a problem's type URI
+const problemTypePrefix = "urn:k8s-deployment-scaler:problem:"
+
+// problemReasons lists every reason, in documentation order
+var problemReasons = []apiv1.Reason{
+	apiv1.ReasonNotFound,
+	apiv1.ReasonConflict,
+	apiv1.ReasonForbidden,
+	apiv1.ReasonValidationFailed,
+	apiv1.ReasonUpstreamUnavailable,
+	apiv1.ReasonTooManyRequests,
+	apiv1.ReasonInternalError,
+}
+
+// problemTitles holds the fixed, human-readable summary of each reason
+var problemTitles = map[apiv1.Reason]string{
+	apiv1.ReasonNotFound:            "Not found",
+	apiv1.ReasonConflict:            "Conflict",
+	apiv1.ReasonForbidden:           "Forbidden",
+	apiv1.ReasonValidationFailed:    "Validation failed",
+	apiv1.ReasonUpstreamUnavailable: "Kubernetes API unavailable",
+	apiv1.ReasonTooManyRequests:     "Too many requests",
+	apiv1.ReasonInternalError:       "Internal error",
+}
+
+// apiError describes an error response. Reason defaults to the one implied by Code.
+type apiError struct {
+	Message string
+	Code    int
+	Reason  apiv1.Reason
+	// RetryAfter, when positive, is sent as the Retry-After header
+	RetryAfter time.Duration
+}
+
+// reason returns the error's reason, defaulting it from the status code
+func (e apiError) reason() apiv1.Reason {
+	if e.Reason != "" {
+		return e.Reason
+	}
+	switch e.Code {
+	case http.StatusNotFound:
+		return apiv1.ReasonNotFound
+	case http.StatusConflict:
+		return apiv1.ReasonConflict
+	case http.StatusForbidden:
+		return apiv1.ReasonForbidden
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return apiv1.ReasonValidationFailed
+	case http.StatusTooManyRequests:
+		return apiv1.ReasonTooManyRequests
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return apiv1.ReasonUpstreamUnavailable
+	default:
+		return apiv1.ReasonInternalError
+	}
+}
+
+// writeProblem writes the error as an RFC 7807 problem, tagged with the request ID
+func writeProblem(w http.ResponseWriter, err apiError) {
+	reason := err.reason()
+	problem := apiv1.Problem{
+		Type:     problemTypePrefix + string(reason),
+		Title:    problemTitles[reason],
+		Status:   err.Code,
+		Detail:   err.Message,
+		Instance: w.Header().Get(logging.RequestIDHeader),
+		Reason:   reason,
+	}
+	if err.RetryAfter > 0 {
+		setRetryAfter(w, err.RetryAfter)
+	}
+	w.Header().Set("Content-Type", apiv1.ProblemContentType)
+	w.WriteHeader(err.Code)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// kubernetesError maps an error from the Kubernetes API to the response for it,
+// keeping the API server's reason rather than reporting every failure as a 500.
+// notFound is the detail used when the object doesn't exist.
+func kubernetesError(err error, notFound string) apiError {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return apiError{Message: "Timed out waiting for the Kubernetes API", Code: http.StatusGatewayTimeout}
+		}
+		return apiError{Message: "Kubernetes API request failed: " + err.Error(), Code: http.StatusServiceUnavailable}
+	}
+
+	message := status.Status().Message
+	switch apierrors.ReasonForError(err) {
+	case metav1.StatusReasonNotFound:
+		return apiError{Message: notFound, Code: http.StatusNotFound}
+	case metav1.StatusReasonConflict, metav1.StatusReasonAlreadyExists:
+		return apiError{Message: message, Code: http.StatusConflict}
+	case metav1.StatusReasonForbidden:
+		return apiError{Message: message, Code: http.StatusForbidden}
+	case metav1.StatusReasonInvalid:
+		return apiError{Message: message, Code: http.StatusUnprocessableEntity}
+	case metav1.StatusReasonBadRequest, metav1.StatusReasonRequestEntityTooLarge:
+		return apiError{Message: message, Code: http.StatusBadRequest}
+	case metav1.StatusReasonTimeout, metav1.StatusReasonServerTimeout:
+		return apiError{Message: message, Code: http.StatusGatewayTimeout}
+	case metav1.StatusReasonTooManyRequests, metav1.StatusReasonServiceUnavailable:
+		// The API server is throttling or overloaded; pass on its suggested delay
+		apiErr := apiError{Message: message, Code: http.StatusServiceUnavailable}
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	default:
+		// Unauthorized means the scaler's own credentials were refused, and
+		// InternalError or an unknown reason is the API server's failure
+		return apiError{Message: message, Code: http.StatusBadGateway, Reason: apiv1.ReasonUpstreamUnavailable}
+	}
+}
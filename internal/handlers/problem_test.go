@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/logging"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestWriteProblem(t *testing.T) {
+	tests := []struct {
+		name               string
+		apiErr             apiError
+		expectedCode       int
+		expectedBody       string
+		expectedRetryAfter string
+	}{
+		{
+			name:         "Reason from status",
+			apiErr:       apiError{Message: "Deployment not found", Code: http.StatusNotFound},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"type":"urn:k8s-deployment-scaler:problem:NotFound","title":"Not found","status":404,"detail":"Deployment not found","instance":"req-1","reason":"NotFound"}`,
+		},
+		{
+			name:         "Explicit reason",
+			apiErr:       apiError{Message: "Unauthorized", Code: http.StatusBadGateway, Reason: apiv1.ReasonUpstreamUnavailable},
+			expectedCode: http.StatusBadGateway,
+			expectedBody: `{"type":"urn:k8s-deployment-scaler:problem:UpstreamUnavailable","title":"Kubernetes API unavailable","status":502,"detail":"Unauthorized","instance":"req-1","reason":"UpstreamUnavailable"}`,
+		},
+		{
+			name:               "Retry after",
+			apiErr:             apiError{Message: "Slow down", Code: http.StatusTooManyRequests, RetryAfter: 1500 * time.Millisecond},
+			expectedCode:       http.StatusTooManyRequests,
+			expectedBody:       `{"type":"urn:k8s-deployment-scaler:problem:TooManyRequests","title":"Too many requests","status":429,"detail":"Slow down","instance":"req-1","reason":"TooManyRequests"}`,
+			expectedRetryAfter: "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			w.Header().Set(logging.RequestIDHeader, "req-1")
+			writeProblem(w, tt.apiErr)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("writeProblem() status code = %v, want %v", w.Code, tt.expectedCode)
+			}
+			if w.Body.String() != tt.expectedBody+"\n" {
+				t.Errorf("writeProblem() body = %v, want %v", w.Body.String(), tt.expectedBody)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("writeProblem() Content-Type = %v, want application/problem+json", got)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.expectedRetryAfter {
+				t.Errorf("writeProblem() Retry-After = %q, want %q", got, tt.expectedRetryAfter)
+			}
+		})
+	}
+}
+
+func TestKubernetesError(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name               string
+		err                error
+		expectedCode       int
+		expectedReason     apiv1.Reason
+		expectedRetryAfter time.Duration
+	}{
+		{
+			name:           "Not found",
+			err:            apierrors.NewNotFound(deployments, "web"),
+			expectedCode:   http.StatusNotFound,
+			expectedReason: apiv1.ReasonNotFound,
+		},
+		{
+			name:           "Conflict",
+			err:            apierrors.NewConflict(deployments, "web", errors.New("the object has been modified")),
+			expectedCode:   http.StatusConflict,
+			expectedReason: apiv1.ReasonConflict,
+		},
+		{
+			name:           "Forbidden",
+			err:            apierrors.NewForbidden(deployments, "web", errors.New("RBAC denied")),
+			expectedCode:   http.StatusForbidden,
+			expectedReason: apiv1.ReasonForbidden,
+		},
+		{
+			name: "Invalid",
+			err: apierrors.NewInvalid(schema.GroupKind{Group: "autoscaling", Kind: "Scale"}, "web", field.ErrorList{
+				field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+			}),
+			expectedCode:   http.StatusUnprocessableEntity,
+			expectedReason: apiv1.ReasonValidationFailed,
+		},
+		{
+			name:           "Bad request",
+			err:            apierrors.NewBadRequest("bad"),
+			expectedCode:   http.StatusBadRequest,
+			expectedReason: apiv1.ReasonValidationFailed,
+		},
+		{
+			name:               "Throttled",
+			err:                apierrors.NewTooManyRequests("slow down", 5),
+			expectedCode:       http.StatusServiceUnavailable,
+			expectedReason:     apiv1.ReasonUpstreamUnavailable,
+			expectedRetryAfter: 5 * time.Second,
+		},
+		{
+			name:           "Server timeout",
+			err:            apierrors.NewServerTimeout(deployments, "update", 0),
+			expectedCode:   http.StatusGatewayTimeout,
+			expectedReason: apiv1.ReasonUpstreamUnavailable,
+		},
+		{
+			name:           "Unauthorized",
+			err:            apierrors.NewUnauthorized("token expired"),
+			expectedCode:   http.StatusBadGateway,
+			expectedReason: apiv1.ReasonUpstreamUnavailable,
+		},
+		{
+			name:           "Internal error",
+			err:            apierrors.NewInternalError(errors.New("etcd unavailable")),
+			expectedCode:   http.StatusBadGateway,
+			expectedReason: apiv1.ReasonUpstreamUnavailable,
+		},
+		{
+			name:           "Wrapped status error",
+			err:            fmt.Errorf("getting history ConfigMap: %w", apierrors.NewForbidden(deployments, "web", errors.New("RBAC denied"))),
+			expectedCode:   http.StatusForbidden,
+			expectedReason: apiv1.ReasonForbidden,
+		},
+		{
+			name:           "Deadline exceeded",
+			err:            fmt.Errorf("update: %w", context.DeadlineExceeded),
+			expectedCode:   http.StatusGatewayTimeout,
+			expectedReason: apiv1.ReasonUpstreamUnavailable,
+		},
+		{
+			name:           "Connection refused",
+			err:            errors.New("dial tcp 10.0.0.1:443: connect: connection refused"),
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedReason: apiv1.ReasonUpstreamUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := kubernetesError(tt.err, "Deployment not found")
+			if apiErr.Code != tt.expectedCode || apiErr.reason() != tt.expectedReason {
+				t.Errorf("kubernetesError() = %d %s, want %d %s", apiErr.Code, apiErr.reason(), tt.expectedCode, tt.expectedReason)
+			}
+			if apiErr.RetryAfter != tt.expectedRetryAfter {
+				t.Errorf("kubernetesError() RetryAfter = %v, want %v", apiErr.RetryAfter, tt.expectedRetryAfter)
+			}
+		})
+	}
+}
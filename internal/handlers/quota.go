@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s-deployment-scaler/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// quotaExcess is a ResourceQuota limit a scale-up would exceed
+type quotaExcess struct {
+	quota    string
+	resource corev1.ResourceName
+	// requested is what the new pods add; available is what the quota has left
+	requested resource.Quantity
+	available resource.Quantity
+	hard      resource.Quantity
+}
+
+func (e quotaExcess) String() string {
+	return fmt.Sprintf("ResourceQuota %s %s: %s more requested, %s of %s available",
+		e.quota, e.resource, e.requested.String(), e.available.String(), e.hard.String())
+}
+
+// exceededQuotas returns the ResourceQuota limits in the deployment's namespace
+// that the pods added by scaling it from oldReplicas up to newReplicas would
+// exceed, ordered by quota and resource. Only CPU, memory and pod counts are
+// compared, and quotas limited to scopes are skipped. Nothing is returned for
+// scale-downs or when quotas aren't watched.
+func (h *Handlers) exceededQuotas(ctx context.Context, deployment *appsv1.Deployment, oldReplicas, newReplicas int32) []quotaExcess {
+	if h.quotaLister == nil || deployment == nil || newReplicas <= oldReplicas {
+		return nil
+	}
+
+	_, span := tracing.Start(ctx, "lister.ListResourceQuotas", semconv.K8SNamespaceName(deployment.Namespace))
+	list, err := h.quotaLister.ResourceQuotas(deployment.Namespace).List(labels.Everything())
+	tracing.End(span, err)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Error listing ResourceQuotas", "namespace", deployment.Namespace, "error", err)
+		return nil
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	added := addedRequests(&deployment.Spec.Template.Spec, int64(newReplicas-oldReplicas))
+	var excesses []quotaExcess
+	for _, quota := range list {
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		// The status lags a new quota until the quota controller fills it in
+		hard := quota.Status.Hard
+		if len(hard) == 0 {
+			hard = quota.Spec.Hard
+		}
+		names := make([]string, 0, len(hard))
+		for name := range hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			requested, ok := added[chargedResource(corev1.ResourceName(name))]
+			if !ok || requested.IsZero() {
+				continue
+			}
+			limit := hard[corev1.ResourceName(name)]
+			used := quota.Status.Used[corev1.ResourceName(name)]
+			total := used.DeepCopy()
+			total.Add(requested)
+			if total.Cmp(limit) <= 0 {
+				continue
+			}
+			available := limit.DeepCopy()
+			available.Sub(used)
+			if available.Sign() < 0 {
+				available = resource.Quantity{Format: limit.Format}
+			}
+			excesses = append(excesses, quotaExcess{
+				quota:     quota.Name,
+				resource:  corev1.ResourceName(name),
+				requested: requested,
+				available: available,
+				hard:      limit,
+			})
+		}
+	}
+	return excesses
+}
+
+// chargedResource returns the resource a quota limit counts, so that
+// requests.cpu and cpu both count CPU requests
+func chargedResource(name corev1.ResourceName) corev1.ResourceName {
+	switch name {
+	case corev1.ResourceRequestsCPU:
+		return corev1.ResourceCPU
+	case corev1.ResourceRequestsMemory:
+		return corev1.ResourceMemory
+	case "count/pods":
+		return corev1.ResourcePods
+	}
+	return name
+}
+
+// addedRequests returns the CPU, memory and pod count added by that many more
+// pods of the spec
+func addedRequests(spec *corev1.PodSpec, pods int64) corev1.ResourceList {
+	perPod := podRequests(spec)
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(perPod.Cpu().MilliValue()*pods, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(perPod.Memory().Value()*pods, resource.BinarySI),
+		corev1.ResourcePods:   *resource.NewQuantity(pods, resource.DecimalSI),
+	}
+}
+
+// podRequests returns the CPU and memory a pod of the spec requests as quotas
+// count it: the sum of its containers' requests, or the largest init
+// container's if more. A container's limit stands in for a request it omits,
+// as the API server defaults it.
+func podRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var sum resource.Quantity
+		for _, container := range spec.Containers {
+			sum.Add(containerRequest(container, name))
+		}
+		for _, container := range spec.InitContainers {
+			if request := containerRequest(container, name); request.Cmp(sum) > 0 {
+				sum = request
+			}
+		}
+		requests[name] = sum
+	}
+	return requests
+}
+
+// containerRequest returns the container's request of the resource, or its limit
+// if it sets no request
+func containerRequest(container corev1.Container, name corev1.ResourceName) resource.Quantity {
+	if request, ok := container.Resources.Requests[name]; ok {
+		return request
+	}
+	return container.Resources.Limits[name]
+}
@@ -0,0 +1,185 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/server"
+	scalerv1 "k8s-deployment-scaler/pkg/api/scaler/v1"
+
+	"google.golang.org/grpc/credentials/insecure"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newQuota returns a ResourceQuota with the given hard limits and usage
+func newQuota(name string, hard, used corev1.ResourceList) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+		Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func TestResourceQuotas(t *testing.T) {
+	t.Parallel()
+
+	// Each pod requests 1 CPU, for its init container, and 384Mi of memory,
+	// the sidecar's from its limit
+	web := newDeployment("default", "web", 2, nil)
+	web.Spec.Template.Spec = corev1.PodSpec{
+		InitContainers: []corev1.Container{{
+			Name:      "migrate",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		}},
+		Containers: []corev1.Container{
+			{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				}},
+			},
+			{
+				Name: "proxy",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("128Mi"),
+				}},
+			},
+		},
+	}
+	bestEffort := newQuota("best-effort", corev1.ResourceList{corev1.ResourcePods: resource.MustParse("0")}, nil)
+	bestEffort.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+	fakeClientset := fake.NewSimpleClientset(
+		web,
+		newQuota("compute", corev1.ResourceList{
+			corev1.ResourceRequestsCPU:    resource.MustParse("4"),
+			corev1.ResourceRequestsMemory: resource.MustParse("2Gi"),
+			corev1.ResourceLimitsCPU:      resource.MustParse("1"),
+		}, corev1.ResourceList{
+			corev1.ResourceRequestsCPU:    resource.MustParse("2"),
+			corev1.ResourceRequestsMemory: resource.MustParse("768Mi"),
+		}),
+		newQuota("count", corev1.ResourceList{corev1.ResourcePods: resource.MustParse("5")}, corev1.ResourceList{corev1.ResourcePods: resource.MustParse("2")}),
+		bestEffort,
+	)
+	addScaleReactors(fakeClientset)
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	quotaInformer := factory.Core().V1().ResourceQuotas()
+	quotaInformer.Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	srv, err := server.New(fakeClientset, deploymentInformer, testConfig(), server.WithQuotaInformer(quotaInformer))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	warnConfig := testConfig()
+	warnConfig.Scale.QuotaCheck = config.QuotaCheckWarn
+	warnSrv, err := server.New(fakeClientset, deploymentInformer, warnConfig, server.WithQuotaInformer(quotaInformer))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		srv            *server.Server
+		method         string
+		url            string
+		replicas       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Within the quotas",
+			srv:            srv,
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web",
+			replicas:       "3",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":3}`,
+		},
+		{
+			name:           "Past the quotas",
+			srv:            srv,
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web",
+			replicas:       "7",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:k8s-deployment-scaler:problem:ValidationFailed","title":"Validation failed","status":422,"detail":"Scaling default/web from 3 to 7 replicas would exceed ResourceQuota compute requests.cpu: 4 more requested, 2 of 4 available; ResourceQuota compute requests.memory: 1536Mi more requested, 1280Mi of 2Gi available; ResourceQuota count pods: 4 more requested, 3 of 5 available","instance":"test-request-id","reason":"ValidationFailed"}`,
+		},
+		{
+			name:           "Scaling down is never checked",
+			srv:            srv,
+			method:         "POST",
+			url:            "/replica-count?namespace=default&deployment=web",
+			replicas:       "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"replicaCount":1}`,
+		},
+		{
+			name:           "Warning only",
+			srv:            warnSrv,
+			method:         "PUT",
+			url:            "/api/v1/namespaces/default/deployments/web/scale",
+			replicas:       "4",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"namespace":"default","name":"web","replicas":4,"warnings":["Exceeds ResourceQuota compute requests.cpu: 3 more requested, 2 of 4 available"]}`,
+		},
+	}
+
+	// Run in order: each scale changes the count the next is checked from
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(`{"replicas":`+tt.replicas+`}`))
+		req.Header.Set("X-Request-ID", testRequestID)
+		rr := httptest.NewRecorder()
+		tt.srv.Handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rr.Code, tt.expectedStatus, rr.Body.String())
+		}
+		if got := strings.TrimSpace(rr.Body.String()); got != tt.expectedBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, got, tt.expectedBody)
+		}
+
+		// Wait for the cache to see the scale
+		err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+			stored, err := fakeClientset.AppsV1().Deployments("default").Get(context.TODO(), "web", metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			cached, err := deploymentInformer.Lister().Deployments("default").Get("web")
+			return err == nil && *cached.Spec.Replicas == *stored.Spec.Replicas, nil
+		})
+		if err != nil {
+			t.Fatalf("%s: cache never caught up: %v", tt.name, err)
+		}
+	}
+
+	// gRPC scales carry the same warnings
+	client := dialBufconn(t, warnSrv.GRPC, insecure.NewCredentials())
+	set, err := client.SetScale(context.Background(), &scalerv1.SetScaleRequest{Namespace: "default", Name: "web", Replicas: 7})
+	if err != nil {
+		t.Fatalf("SetScale() error = %v", err)
+	}
+	want := []string{"Exceeds ResourceQuota compute requests.cpu: 3 more requested, 2 of 4 available"}
+	if !slices.Equal(set.Scale.Warnings, want) {
+		t.Errorf("SetScale() warnings = %q, want %q", set.Scale.Warnings, want)
+	}
+}
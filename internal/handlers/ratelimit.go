@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/ratelimit"
+
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// routeLimiters holds the token buckets for one route
+type routeLimiters struct {
+	perIdentity   *ratelimit.Keyed
+	perDeployment *ratelimit.Keyed
+}
+
+// cooldown tracks when each deployment was last scaled by this server
+type cooldown struct {
+	period          time.Duration
+	forceIdentities map[string]bool
+
+	mu         sync.Mutex
+	lastScaled map[string]time.Time
+}
+
+// WithRateLimits applies token bucket limits to the routes named by their mux
+// patterns, e.g. "POST /replica-count"
+func WithRateLimits(limits map[string]config.RouteRateLimit) Option {
+	return func(h *Handlers) {
+		h.rateLimits = limits
+	}
+}
+
+// WithScaleCooldown rejects a second scale of the same deployment within period
+// unless one of forceIdentities sets force=true
+func WithScaleCooldown(period time.Duration, forceIdentities []string) Option {
+	return func(h *Handlers) {
+		h.cooldown.period = period
+		h.cooldown.forceIdentities = make(map[string]bool)
+		for _, identity := range forceIdentities {
+			h.cooldown.forceIdentities[identity] = true
+		}
+	}
+}
+
+// buildRateLimiters creates the buckets for every configured route
+func (h *Handlers) buildRateLimiters() {
+	h.limiters = make(map[string]*routeLimiters)
+	for route, limits := range h.rateLimits {
+		limiters := &routeLimiters{}
+		if limits.PerIdentity != nil {
+			limiters.perIdentity = ratelimit.NewKeyed(*limits.PerIdentity, h.clock.Now)
+		}
+		if limits.PerDeployment != nil {
+			limiters.perDeployment = ratelimit.NewKeyed(*limits.PerDeployment, h.clock.Now)
+		}
+		h.limiters[route] = limiters
+	}
+}
+
+// RateLimited wraps the handler for the route pattern with the route's limits,
+// answering 429 with Retry-After once the caller's or the target deployment's
+// bucket is empty. Routes without limits are returned unchanged.
+func (h *Handlers) RateLimited(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	limiters, ok := h.limiters[pattern]
+	if !ok {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		target, _ := requestTarget(r)
+		if apiErr := limiters.reserve(clientIdentity(r), target); apiErr != nil {
+			writeProblem(w, *apiErr)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// reserve takes a token from the identity's bucket and then from the target
+// deployment's, if the route limits them. An empty target skips the deployment
+// bucket. It returns a 429 error once either bucket is empty.
+func (l *routeLimiters) reserve(identity, target string) *apiError {
+	refund := func() {}
+	if l.perIdentity != nil {
+		delay, giveBack := l.perIdentity.Reserve(identity)
+		if delay > 0 {
+			return tooManyRequests(delay, fmt.Sprintf("Rate limit exceeded for client %s", identity))
+		}
+		refund = giveBack
+	}
+
+	if l.perDeployment != nil && target != "" {
+		if delay, _ := l.perDeployment.Reserve(target); delay > 0 {
+			// Don't charge the caller for a request that was never served
+			refund()
+			return tooManyRequests(delay, fmt.Sprintf("Rate limit exceeded for deployment %s", target))
+		}
+	}
+	return nil
+}
+
+// requestTarget returns the namespace/name of the deployment a request addresses,
+// from either the path or the namespace and deployment query parameters. Routes
+// under /clusters/{cluster} prefix it with the cluster.
+func requestTarget(r *http.Request) (string, bool) {
+	namespace, name := r.PathValue("namespace"), r.PathValue("name")
+	if namespace == "" || name == "" {
+		query := r.URL.Query()
+		namespace, name = query.Get("namespace"), query.Get("deployment")
+	}
+	if namespace == "" || name == "" {
+		return "", false
+	}
+	if cluster := r.PathValue("cluster"); cluster != "" {
+		return cluster + "/" + namespace + "/" + name, true
+	}
+	return namespace + "/" + name, true
+}
+
+// tooManyRequests returns a 429 telling the client when to retry
+func tooManyRequests(retryAfter time.Duration, message string) *apiError {
+	return &apiError{
+		Message:    message,
+		Code:       http.StatusTooManyRequests,
+		RetryAfter: retryAfter,
+	}
+}
+
+// setRetryAfter sets the Retry-After header, rounding up to whole seconds
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+// parseForce reads the force query parameter
+func parseForce(r *http.Request) (bool, *apiError) {
+	value := r.URL.Query().Get("force")
+	if value == "" {
+		return false, nil
+	}
+	force, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &apiError{
+			Message: "force must be true or false",
+			Code:    http.StatusBadRequest,
+		}
+	}
+	return force, nil
+}
+
+// reserveCooldown checks the cooldown since the deployment was last scaled, by
+// this server or, going by its annotation, any other replica, and starts a new
+// one unless time remains and force is false. Starting it before the scale is
+// applied keeps concurrent requests from all passing the check. It returns the
+// time that remained and, when the cooldown was started, a release func that
+// undoes it if the scale isn't applied after all; release is nil otherwise.
+func (h *Handlers) reserveCooldown(namespace, name string, deployment *appsv1.Deployment, force bool) (time.Duration, func()) {
+	if h.cooldown.period <= 0 {
+		return 0, func() {}
+	}
+
+	key := namespace + "/" + name
+	h.cooldown.mu.Lock()
+	defer h.cooldown.mu.Unlock()
+
+	previous := h.cooldown.lastScaled[key]
+	last := previous
+	if deployment != nil {
+		if annotated, err := time.Parse(time.RFC3339, deployment.Annotations[AnnotationLastScaleTime]); err == nil && annotated.After(last) {
+			last = annotated
+		}
+	}
+	var remaining time.Duration
+	if !last.IsZero() {
+		remaining = h.cooldown.period - h.clock.Since(last)
+	}
+	if remaining > 0 && !force {
+		return remaining, nil
+	}
+
+	if h.cooldown.lastScaled == nil {
+		h.cooldown.lastScaled = make(map[string]time.Time)
+	}
+	reserved := h.clock.Now()
+	h.cooldown.lastScaled[key] = reserved
+	return remaining, func() {
+		h.cooldown.mu.Lock()
+		defer h.cooldown.mu.Unlock()
+		// A later reservation owns the slot now; leave it alone
+		if !h.cooldown.lastScaled[key].Equal(reserved) {
+			return
+		}
+		if previous.IsZero() {
+			delete(h.cooldown.lastScaled, key)
+		} else {
+			h.cooldown.lastScaled[key] = previous
+		}
+	}
+}
+
+// mayForce reports whether the identity may bypass the cooldown
+func (c *cooldown) mayForce(identity string) bool {
+	return c.forceIdentities[identity]
+}
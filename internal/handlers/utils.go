@@ -1,35 +1,52 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+
+	"k8s-deployment-scaler/internal/logging"
+	"k8s-deployment-scaler/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
 
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	appslisters "k8s.io/client-go/listers/apps/v1"
 )
 
-// apiError represents an error response in JSON format
-type apiError struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
-}
-
 // getDeploymentFromCache retrieves a deployment from the lister
-func getDeploymentFromCache(namespace, name string, deploymentLister appslisters.DeploymentLister) (*appsv1.Deployment, bool) {
-	deployment, err := deploymentLister.Deployments(namespace).Get(name)
+func (h *Handlers) getDeploymentFromCache(ctx context.Context, namespace, name string) (*appsv1.Deployment, bool) {
+	_, span := tracing.Start(ctx, "lister.GetDeployment",
+		semconv.K8SNamespaceName(namespace), semconv.K8SDeploymentName(name))
+	deployment, err := h.deploymentLister.Deployments(namespace).Get(name)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			span.End()
 			return nil, false
 		}
-		log.Printf("Error getting deployment: %v", err)
+		tracing.End(span, err)
+		h.logger.ErrorContext(ctx, "Error getting deployment", "namespace", namespace, "deployment", name, "error", err)
 		return nil, false
 	}
+	span.End()
 	return deployment, true
 }
 
+// checkNamespace refuses namespaces outside those the handlers serve, whose
+// deployments the informer doesn't watch
+func (h *Handlers) checkNamespace(namespace string) *apiError {
+	if h.namespaces == nil || h.namespaces[namespace] {
+		return nil
+	}
+	return &apiError{
+		Message: fmt.Sprintf("Namespace %s is not served by this scaler", namespace),
+		Code:    http.StatusForbidden,
+	}
+}
+
 // validateQueryParams checks if both namespace and deployment are provided
 func validateQueryParams(r *http.Request) (string, string, *apiError) {
 	namespace := r.URL.Query().Get("namespace")
@@ -45,27 +62,84 @@ func validateQueryParams(r *http.Request) (string, string, *apiError) {
 	return namespace, deploymentName, nil
 }
 
+// parseScaleOptions reads the force and mode query parameters of a scale
+func parseScaleOptions(r *http.Request) (scaleOptions, *apiError) {
+	force, apiErr := parseForce(r)
+	if apiErr != nil {
+		return scaleOptions{}, apiErr
+	}
+	opts := scaleOptions{force: force}
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "":
+	case "hpa":
+		opts.pinHPA = true
+	default:
+		return scaleOptions{}, &apiError{
+			Message: fmt.Sprintf("Unknown mode %q; the only mode is hpa", mode),
+			Code:    http.StatusBadRequest,
+		}
+	}
+	return opts, nil
+}
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePagination reads the limit and continue query parameters.
+// The continue token is the offset of the next page returned by a previous call.
+func parsePagination(r *http.Request) (int, int, *apiError) {
+	limit, offset := defaultPageLimit, 0
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxPageLimit {
+			return 0, 0, &apiError{
+				Message: fmt.Sprintf("limit must be an integer between 1 and %d", maxPageLimit),
+				Code:    http.StatusBadRequest,
+			}
+		}
+		limit = parsed
+	}
+
+	if value := r.URL.Query().Get("continue"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, &apiError{
+				Message: "Invalid continue token",
+				Code:    http.StatusBadRequest,
+			}
+		}
+		offset = parsed
+	}
+
+	return limit, offset, nil
+}
+
+// clientIdentity returns the common name of the verified client certificate,
+// or "unknown" when the request was not made over mTLS
+func clientIdentity(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return "unknown"
+}
+
 // encodeAndWriteJSON serializes the given data to JSON and writes it to the http.ResponseWriter.
 func encodeAndWriteJSON(w http.ResponseWriter, data interface{}) error {
 	err := json.NewEncoder(w).Encode(data)
 	if err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+		slog.Error("Error encoding JSON response", "request_id", w.Header().Get(logging.RequestIDHeader), "error", err)
 		return fmt.Errorf("JSON encoding failed: %w", err)
 	}
 	return nil
 }
 
-// writeJSONError writes an error response in JSON format
-func writeJSONError(w http.ResponseWriter, err apiError) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(err.Code)
-	json.NewEncoder(w).Encode(err)
-}
-
-// writeInternalServerError writes an internal server error response in JSON format
+// writeInternalServerError logs err and writes an internal server error response
 func writeInternalServerError(w http.ResponseWriter, err error) {
-	log.Printf("Internal server error: %v", err)
-	writeJSONError(w, apiError{
+	slog.Error("Internal server error", "request_id", w.Header().Get(logging.RequestIDHeader), "error", err)
+	writeProblem(w, apiError{
 		Message: "Internal server error",
 		Code:    http.StatusInternalServerError,
 	})
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -60,6 +62,8 @@ func TestGetDeploymentFromCache(t *testing.T) {
 		},
 	}
 
+	h := &Handlers{deploymentLister: mockLister, logger: slog.Default()}
+
 	tests := []struct {
 		name           string
 		namespace      string
@@ -82,7 +86,7 @@ func TestGetDeploymentFromCache(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, found := getDeploymentFromCache(tt.namespace, tt.deploymentName, mockLister)
+			_, found := h.getDeploymentFromCache(context.Background(), tt.namespace, tt.deploymentName)
 			if found != tt.wantFound {
 				t.Errorf("getDeploymentFromCache() found = %v, want %v", found, tt.wantFound)
 			}
@@ -173,50 +177,12 @@ func TestEncodeAndWriteJSON(t *testing.T) {
 	}
 }
 
-func TestWriteJSONError(t *testing.T) {
-	tests := []struct {
-		name         string
-		apiErr       apiError
-		expectedCode int
-		expectedBody string
-	}{
-		{
-			name:         "Bad Request",
-			apiErr:       apiError{Message: "Bad Request", Code: http.StatusBadRequest},
-			expectedCode: http.StatusBadRequest,
-			expectedBody: `{"message":"Bad Request","code":400}`,
-		},
-		{
-			name:         "Not Found",
-			apiErr:       apiError{Message: "Not Found", Code: http.StatusNotFound},
-			expectedCode: http.StatusNotFound,
-			expectedBody: `{"message":"Not Found","code":404}`,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			w := httptest.NewRecorder()
-			writeJSONError(w, tt.apiErr)
-			if w.Code != tt.expectedCode {
-				t.Errorf("writeJSONError() status code = %v, want %v", w.Code, tt.expectedCode)
-			}
-			if w.Body.String() != tt.expectedBody+"\n" {
-				t.Errorf("writeJSONError() body = %v, want %v", w.Body.String(), tt.expectedBody)
-			}
-			if w.Header().Get("Content-Type") != "application/json" {
-				t.Errorf("writeJSONError() Content-Type = %v, want application/json", w.Header().Get("Content-Type"))
-			}
-		})
-	}
-}
-
 func TestWriteInternalServerError(t *testing.T) {
 	w := httptest.NewRecorder()
 	writeInternalServerError(w, fmt.Errorf("test error"))
 
 	expectedCode := http.StatusInternalServerError
-	expectedBody := `{"message":"Internal server error","code":500}`
+	expectedBody := `{"type":"urn:k8s-deployment-scaler:problem:InternalError","title":"Internal error","status":500,"detail":"Internal server error","reason":"InternalError"}`
 
 	if w.Code != expectedCode {
 		t.Errorf("writeInternalServerError() status code = %v, want %v", w.Code, expectedCode)
@@ -224,7 +190,7 @@ func TestWriteInternalServerError(t *testing.T) {
 	if w.Body.String() != expectedBody+"\n" {
 		t.Errorf("writeInternalServerError() body = %v, want %v", w.Body.String(), expectedBody)
 	}
-	if w.Header().Get("Content-Type") != "application/json" {
-		t.Errorf("writeInternalServerError() Content-Type = %v, want application/json", w.Header().Get("Content-Type"))
+	if w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("writeInternalServerError() Content-Type = %v, want application/problem+json", w.Header().Get("Content-Type"))
 	}
 }
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DeploymentWatcher fans out deployment informer events to long-poll requests
+// waiting on a specific deployment and to streams watching whole namespaces.
+type DeploymentWatcher struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+	// watchers maps each namespace watch to its namespace, empty for all namespaces
+	watchers map[chan deploymentEvent]string
+}
+
+// deploymentEvent is a change to a cached deployment
+type deploymentEvent struct {
+	Type       watch.EventType
+	Deployment *appsv1.Deployment
+}
+
+// watchBufferSize is how many events a namespace watch may fall behind by
+// before it is closed
+const watchBufferSize = 100
+
+// NewDeploymentWatcher registers an event handler on the given deployment informer
+// and returns a watcher that notifies subscribers whenever their deployment changes.
+func NewDeploymentWatcher(informer cache.SharedIndexInformer) (*DeploymentWatcher, error) {
+	w := &DeploymentWatcher{
+		subscribers: make(map[string]map[chan struct{}]struct{}),
+		watchers:    make(map[chan deploymentEvent]string),
+	}
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.notify(watch.Added, obj) },
+		UpdateFunc: func(_, newObj interface{}) { w.notify(watch.Modified, newObj) },
+		DeleteFunc: func(obj interface{}) { w.notify(watch.Deleted, obj) },
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// subscribe returns a channel that receives a signal each time the deployment
+// identified by namespace and name changes, and a function to unsubscribe.
+func (w *DeploymentWatcher) subscribe(namespace, name string) (<-chan struct{}, func()) {
+	key := namespace + "/" + name
+	ch := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	if w.subscribers[key] == nil {
+		w.subscribers[key] = make(map[chan struct{}]struct{})
+	}
+	w.subscribers[key][ch] = struct{}{}
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers[key], ch)
+		if len(w.subscribers[key]) == 0 {
+			delete(w.subscribers, key)
+		}
+	}
+}
+
+// watchNamespace returns a channel receiving every change to the deployments in
+// the namespace, or in all namespaces when it is empty, and a function to stop
+// watching. The channel is closed if the receiver falls watchBufferSize events
+// behind, after which it must list and watch again.
+func (w *DeploymentWatcher) watchNamespace(namespace string) (<-chan deploymentEvent, func()) {
+	ch := make(chan deploymentEvent, watchBufferSize)
+
+	w.mu.Lock()
+	w.watchers[ch] = namespace
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.watchers, ch)
+	}
+}
+
+// notify signals all subscribers of the object's key and sends the event to the
+// watchers of its namespace, without blocking the informer.
+func (w *DeploymentWatcher) notify(eventType watch.EventType, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	deployment, _ := obj.(*appsv1.Deployment)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ch := range w.subscribers[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+
+	if deployment == nil {
+		return
+	}
+	for ch, namespace := range w.watchers {
+		if namespace != "" && namespace != deployment.Namespace {
+			continue
+		}
+		select {
+		case ch <- deploymentEvent{Type: eventType, Deployment: deployment}:
+		default:
+			// Drop the slow watcher rather than the event
+			close(ch)
+			delete(w.watchers, ch)
+		}
+	}
+}
+
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 5 * time.Minute
+)
+
+// waitOptions describes the conditions a long-poll GET /replica-count request waits for.
+// When both a resource version and a target count are given, both must hold.
+type waitOptions struct {
+	resourceVersion string
+	until           *int32
+	timeout         time.Duration
+}
+
+// parseWaitOptions reads the waitForChange, until and timeout query parameters.
+// It returns nil options when the request is not a long-poll request.
+func parseWaitOptions(r *http.Request) (*waitOptions, *apiError) {
+	query := r.URL.Query()
+	resourceVersion := query.Get("waitForChange")
+	until := query.Get("until")
+	if resourceVersion == "" && until == "" {
+		return nil, nil
+	}
+
+	opts := &waitOptions{
+		resourceVersion: resourceVersion,
+		timeout:         defaultWaitTimeout,
+	}
+
+	if until != "" {
+		target, err := strconv.ParseInt(until, 10, 32)
+		if err != nil || target < 0 {
+			return nil, &apiError{
+				Message: "until must be a non-negative integer",
+				Code:    http.StatusBadRequest,
+			}
+		}
+		count := int32(target)
+		opts.until = &count
+	}
+
+	if timeout := query.Get("timeout"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 || d > maxWaitTimeout {
+			return nil, &apiError{
+				Message: fmt.Sprintf("timeout must be a positive duration no greater than %v", maxWaitTimeout),
+				Code:    http.StatusBadRequest,
+			}
+		}
+		opts.timeout = d
+	}
+
+	return opts, nil
+}
+
+// satisfied reports whether the deployment meets every condition of the wait.
+func (o *waitOptions) satisfied(deployment *appsv1.Deployment) bool {
+	if o.resourceVersion != "" && deployment.ResourceVersion == o.resourceVersion {
+		return false
+	}
+	if o.until != nil && replicasOf(deployment) != *o.until {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerSynced fails until the informer's initial list has been delivered
+func InformerSynced(name string, informer cache.SharedIndexInformer) Check {
+	return Check{
+		Name: name,
+		Run: func(context.Context) error {
+			if informer.IsStopped() {
+				return errors.New("informer stopped")
+			}
+			if !informer.HasSynced() {
+				return errors.New("informer not synced")
+			}
+			return nil
+		},
+	}
+}
+
+// informerActivity tracks when an informer last showed its watch was alive
+type informerActivity struct {
+	informer cache.SharedIndexInformer
+	maxAge   time.Duration
+	now      func() time.Time
+
+	mu              sync.Mutex
+	lastActivity    time.Time
+	resourceVersion string
+}
+
+// InformerActivity fails when the informer has neither delivered an event nor
+// advanced its resource version (e.g. from a watch bookmark) within maxAge,
+// which points at a watch that is silently stuck.
+func InformerActivity(name string, informer cache.SharedIndexInformer, maxAge time.Duration) (Check, error) {
+	a := &informerActivity{
+		informer:     informer,
+		maxAge:       maxAge,
+		now:          time.Now,
+		lastActivity: time.Now(),
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { a.touch() },
+		UpdateFunc: func(interface{}, interface{}) { a.touch() },
+		DeleteFunc: func(interface{}) { a.touch() },
+	}); err != nil {
+		return Check{}, err
+	}
+	return Check{Name: name, Run: a.check}, nil
+}
+
+func (a *informerActivity) touch() {
+	a.mu.Lock()
+	a.lastActivity = a.now()
+	a.mu.Unlock()
+}
+
+func (a *informerActivity) check(context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if rv := a.informer.LastSyncResourceVersion(); rv != a.resourceVersion {
+		a.resourceVersion = rv
+		a.lastActivity = a.now()
+	}
+	if age := a.now().Sub(a.lastActivity); age > a.maxAge {
+		return fmt.Errorf("no watch activity for %v", age.Round(time.Second))
+	}
+	return nil
+}
+
+// TLSCertificates fails unless the config holds at least one certificate and
+// none of them has expired
+func TLSCertificates(name string, config *tls.Config) Check {
+	return Check{
+		Name: name,
+		Run: func(context.Context) error {
+			if config == nil || len(config.Certificates) == 0 {
+				return errors.New("no serving certificate loaded")
+			}
+			for _, cert := range config.Certificates {
+				if len(cert.Certificate) == 0 {
+					return errors.New("empty serving certificate")
+				}
+				leaf, err := x509.ParseCertificate(cert.Certificate[0])
+				if err != nil {
+					return fmt.Errorf("parsing serving certificate: %v", err)
+				}
+				if time.Now().After(leaf.NotAfter) {
+					return fmt.Errorf("serving certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// ServerVersioner is the part of the discovery client used to reach the API server
+type ServerVersioner interface {
+	RESTClient() rest.Interface
+	ServerVersion() (*version.Info, error)
+}
+
+// apiServer caches the result of API server reachability checks
+type apiServer struct {
+	ping             func(context.Context) error
+	interval         time.Duration
+	timeout          time.Duration
+	failureThreshold int
+	now              func() time.Time
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	failures  int
+	lastErr   error
+	// refreshing is closed when the call to the API server in flight returns;
+	// nil when there is none
+	refreshing chan struct{}
+}
+
+// APIServer checks that the Kubernetes API server is reachable. The API server is
+// contacted at most once per interval, with results cached in between, and the check
+// only fails after failureThreshold consecutive failed calls so a brief API server
+// blip doesn't take every replica out of the Service. Each call is bounded by
+// timeout and shared by the checks running meanwhile; a check whose context ends
+// first answers from the previous result.
+func APIServer(name string, discovery ServerVersioner, interval, timeout time.Duration, failureThreshold int) Check {
+	a := &apiServer{
+		ping:             pingVersion(discovery),
+		interval:         interval,
+		timeout:          timeout,
+		failureThreshold: failureThreshold,
+		now:              time.Now,
+	}
+	return Check{Name: name, Run: a.check}
+}
+
+// pingVersion gets the API server's /version, falling back to ServerVersion,
+// which can't be cancelled, for discovery clients without a REST client
+func pingVersion(discovery ServerVersioner) func(context.Context) error {
+	return func(ctx context.Context) error {
+		client := discovery.RESTClient()
+		if client == nil {
+			_, err := discovery.ServerVersion()
+			return err
+		}
+		return client.Get().AbsPath("/version").Do(ctx).Error()
+	}
+}
+
+func (a *apiServer) check(ctx context.Context) error {
+	a.mu.Lock()
+	if a.refreshing == nil && (a.checkedAt.IsZero() || a.now().Sub(a.checkedAt) >= a.interval) {
+		a.refreshing = make(chan struct{})
+		go a.refresh(a.refreshing)
+	}
+	refreshing := a.refreshing
+	a.mu.Unlock()
+
+	if refreshing != nil {
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failures >= a.failureThreshold {
+		return fmt.Errorf("%d consecutive failures reaching the API server: %v", a.failures, a.lastErr)
+	}
+	return nil
+}
+
+// refresh calls the API server and records the result, without holding the
+// lock meanwhile, then closes done
+func (a *apiServer) refresh(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	err := a.ping(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checkedAt = a.now()
+	if err != nil {
+		a.failures++
+		a.lastErr = err
+	} else {
+		a.failures = 0
+		a.lastErr = nil
+	}
+	a.refreshing = nil
+	close(done)
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkTimeout bounds how long a single probe request may spend running checks
+const checkTimeout = 5 * time.Second
+
+// Check reports whether one aspect of the server is healthy
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Ping is a check that always passes, showing the process is serving requests
+var Ping = Check{
+	Name: "ping",
+	Run:  func(context.Context) error { return nil },
+}
+
+// Handler serves a kube-apiserver style health endpoint such as /livez or /readyz.
+// It responds 200 "ok" when every check passes and 500 listing each check otherwise.
+// ?verbose lists each check on success too, and ?exclude=<name> skips a check.
+func Handler(endpoint string, checks ...Check) http.Handler {
+	return DynamicHandler(endpoint, func() []Check { return checks })
+}
+
+// DynamicHandler is like Handler, running the checks returned by checks on each
+// request, for checks that come and go at runtime
+func DynamicHandler(endpoint string, checks func() []Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		excluded := make(map[string]bool)
+		for _, name := range query["exclude"] {
+			excluded[name] = true
+		}
+		_, verbose := query["verbose"]
+
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		var report strings.Builder
+		failed := false
+		for _, check := range checks() {
+			if excluded[check.Name] {
+				fmt.Fprintf(&report, "[+]%s excluded: ok\n", check.Name)
+				continue
+			}
+			if err := check.Run(ctx); err != nil {
+				failed = true
+				fmt.Fprintf(&report, "[-]%s failed: %v\n", check.Name, err)
+			} else {
+				fmt.Fprintf(&report, "[+]%s ok\n", check.Name)
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if failed {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "%s%s check failed\n", report.String(), endpoint)
+			return
+		}
+		if verbose {
+			fmt.Fprintf(w, "%s%s check passed\n", report.String(), endpoint)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+}
@@ -0,0 +1,254 @@
+package health
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func failing(name string) Check {
+	return Check{Name: name, Run: func(context.Context) error { return errors.New("broken") }}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		checks         []Check
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "All checks pass",
+			checks:         []Check{Ping},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+		{
+			name:           "Verbose",
+			checks:         []Check{Ping},
+			query:          "?verbose",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[+]ping ok\nreadyz check passed\n",
+		},
+		{
+			name:           "Failing check",
+			checks:         []Check{Ping, failing("informer-sync")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "[+]ping ok\n[-]informer-sync failed: broken\nreadyz check failed\n",
+		},
+		{
+			name:           "Excluded failing check",
+			checks:         []Check{Ping, failing("kubernetes-api")},
+			query:          "?exclude=kubernetes-api",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			Handler("readyz", tt.checks...).ServeHTTP(rr, httptest.NewRequest("GET", "/readyz"+tt.query, nil))
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.expectedStatus)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("body = %q, want %q", rr.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestInformerSynced(t *testing.T) {
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	informer := factory.Apps().V1().Deployments().Informer()
+	check := InformerSynced("informer-sync", informer)
+
+	if err := check.Run(context.Background()); err == nil {
+		t.Error("check passed before the informer started")
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	if err := check.Run(context.Background()); err != nil {
+		t.Errorf("check failed after sync: %v", err)
+	}
+}
+
+func TestInformerActivity(t *testing.T) {
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	informer := factory.Apps().V1().Deployments().Informer()
+	if _, err := InformerActivity("informer-watch", informer, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	a := &informerActivity{
+		informer:     informer,
+		maxAge:       time.Minute,
+		now:          func() time.Time { return now },
+		lastActivity: now,
+	}
+
+	if err := a.check(context.Background()); err != nil {
+		t.Errorf("fresh informer reported stale: %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := a.check(context.Background()); err == nil {
+		t.Error("check passed without activity for longer than maxAge")
+	}
+
+	a.touch()
+	if err := a.check(context.Background()); err != nil {
+		t.Errorf("check failed right after an event: %v", err)
+	}
+}
+
+type fakeDiscovery struct {
+	calls int
+	err   error
+}
+
+func (d *fakeDiscovery) RESTClient() rest.Interface { return nil }
+
+func (d *fakeDiscovery) ServerVersion() (*version.Info, error) {
+	d.calls++
+	return &version.Info{}, d.err
+}
+
+func TestAPIServer(t *testing.T) {
+	discovery := &fakeDiscovery{err: errors.New("connection refused")}
+	now := time.Now()
+	a := &apiServer{
+		ping:             pingVersion(discovery),
+		interval:         10 * time.Second,
+		timeout:          time.Second,
+		failureThreshold: 2,
+		now:              func() time.Time { return now },
+	}
+
+	// The first failure is tolerated
+	if err := a.check(context.Background()); err != nil {
+		t.Errorf("failed below the threshold: %v", err)
+	}
+
+	// Results are cached within the interval
+	a.check(context.Background())
+	if discovery.calls != 1 {
+		t.Errorf("API server called %d times within one interval, want 1", discovery.calls)
+	}
+
+	now = now.Add(10 * time.Second)
+	if err := a.check(context.Background()); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("check error = %v, want failure after reaching the threshold", err)
+	}
+
+	// One success resets the failure count
+	discovery.err = nil
+	now = now.Add(10 * time.Second)
+	if err := a.check(context.Background()); err != nil {
+		t.Errorf("check failed after recovery: %v", err)
+	}
+}
+
+func TestAPIServerHung(t *testing.T) {
+	// An API server that accepts requests but never answers them
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer hung.Close()
+	defer close(release)
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: hung.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := APIServer("kubernetes-api", clientset.Discovery(), time.Millisecond, 200*time.Millisecond, 1)
+
+	// Probes give up on their own deadline rather than queueing behind the
+	// call in flight, answering from the last result
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if err := check.Run(ctx); err != nil {
+				t.Errorf("check failed before any call completed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("concurrent checks took %v, want them bounded by their own deadline", elapsed)
+	}
+
+	// The call itself times out and counts as a failure
+	if err := check.Run(context.Background()); err == nil {
+		t.Error("check passed against a hung API server")
+	}
+}
+
+func TestTLSCertificates(t *testing.T) {
+	if err := TLSCertificates("tls", &tls.Config{}).Run(context.Background()); err == nil {
+		t.Error("check passed without certificates")
+	}
+
+	valid := &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t, time.Now().Add(time.Hour))}}
+	if err := TLSCertificates("tls", valid).Run(context.Background()); err != nil {
+		t.Errorf("check failed for a valid certificate: %v", err)
+	}
+
+	expired := &tls.Config{Certificates: []tls.Certificate{selfSignedCert(t, time.Now().Add(-time.Hour))}}
+	if err := TLSCertificates("tls", expired).Run(context.Background()); err == nil {
+		t.Error("check passed for an expired certificate")
+	}
+}
+
+func selfSignedCert(t *testing.T, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -0,0 +1,201 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap holding scale history in each namespace
+	ConfigMapName = "k8s-deployment-scaler-history"
+
+	// DefaultMaxEntries is the number of entries kept per deployment when no limit is given
+	DefaultMaxEntries = 50
+
+	// MaxDataBytes bounds the serialized history kept in a namespace's ConfigMap,
+	// leaving headroom below the 1 MiB object size limit for its metadata
+	MaxDataBytes = 900 * 1024
+)
+
+// Entry records a single successful scale operation
+type Entry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Actor       string    `json:"actor"`
+	OldReplicas int32     `json:"oldReplicas"`
+	NewReplicas int32     `json:"newReplicas"`
+	Reason      string    `json:"reason,omitempty"`
+	Ticket      string    `json:"ticket,omitempty"`
+	RequestID   string    `json:"requestId,omitempty"`
+}
+
+// Store persists a bounded scale history per deployment in a ConfigMap per namespace.
+// Each deployment's history is kept under its own key, oldest entry first.
+type Store struct {
+	clientset  kubernetes.Interface
+	maxEntries int
+	maxBytes   int
+}
+
+// NewStore creates a Store that keeps at most maxEntries entries per deployment
+func NewStore(clientset kubernetes.Interface, maxEntries int) *Store {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Store{
+		clientset:  clientset,
+		maxEntries: maxEntries,
+		maxBytes:   MaxDataBytes,
+	}
+}
+
+// Append adds an entry to the deployment's history, dropping the oldest entries
+// once the limit is reached. When the namespace's ConfigMap would outgrow
+// MaxDataBytes, the oldest entries across all its deployments are dropped too.
+// Concurrent writers are handled by retrying on conflict.
+func (s *Store) Append(ctx context.Context, namespace, deployment string, entry Entry) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := s.clientset.CoreV1().ConfigMaps(namespace)
+
+		cm, err := configMaps.Get(ctx, ConfigMapName, metav1.GetOptions{})
+		create := errors.IsNotFound(err)
+		if err != nil && !create {
+			return fmt.Errorf("getting history ConfigMap: %w", err)
+		}
+		if create {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ConfigMapName,
+					Namespace: namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/managed-by": "k8s-deployment-scaler",
+					},
+				},
+			}
+		}
+
+		entries, err := decodeEntries(cm, deployment)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		if len(entries) > s.maxEntries {
+			entries = entries[len(entries)-s.maxEntries:]
+		}
+
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("encoding history: %w", err)
+		}
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[deployment] = string(data)
+		if err := trimToSize(cm.Data, s.maxBytes); err != nil {
+			return err
+		}
+
+		if create {
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+			if errors.IsAlreadyExists(err) {
+				// Another writer created it first; retry as an update
+				return errors.NewConflict(corev1.Resource("configmaps"), ConfigMapName, err)
+			}
+		} else {
+			_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		}
+		return err
+	})
+}
+
+// List returns the deployment's history, newest entry first
+func (s *Store) List(ctx context.Context, namespace, deployment string) ([]Entry, error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("getting history ConfigMap: %w", err)
+	}
+
+	entries, err := decodeEntries(cm, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// dataSize returns the serialized size of the ConfigMap's data
+func dataSize(data map[string]string) int {
+	size := 0
+	for key, value := range data {
+		size += len(key) + len(value)
+	}
+	return size
+}
+
+// trimToSize drops the oldest entries across all deployments until data fits in
+// maxBytes. Histories that cannot be decoded are dropped first.
+func trimToSize(data map[string]string, maxBytes int) error {
+	if dataSize(data) <= maxBytes {
+		return nil
+	}
+
+	histories := make(map[string][]Entry, len(data))
+	for key, raw := range data {
+		var entries []Entry
+		if err := json.Unmarshal([]byte(raw), &entries); err != nil || len(entries) == 0 {
+			delete(data, key)
+			continue
+		}
+		histories[key] = entries
+	}
+
+	for dataSize(data) > maxBytes && len(histories) > 0 {
+		oldest := ""
+		for key, entries := range histories {
+			if oldest == "" || entries[0].Timestamp.Before(histories[oldest][0].Timestamp) {
+				oldest = key
+			}
+		}
+
+		entries := histories[oldest][1:]
+		if len(entries) == 0 {
+			delete(histories, oldest)
+			delete(data, oldest)
+			continue
+		}
+		histories[oldest] = entries
+		encoded, err := json.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("encoding history: %w", err)
+		}
+		data[oldest] = string(encoded)
+	}
+	return nil
+}
+
+// decodeEntries reads the deployment's entries from the ConfigMap
+func decodeEntries(cm *corev1.ConfigMap, deployment string) ([]Entry, error) {
+	raw, ok := cm.Data[deployment]
+	if !ok || raw == "" {
+		return []Entry{}, nil
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("decoding history for %s: %w", deployment, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,123 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStoreAppendAndList(t *testing.T) {
+	store := NewStore(fake.NewSimpleClientset(), 3)
+	ctx := context.TODO()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := int32(1); i <= 5; i++ {
+		err := store.Append(ctx, "default", "my-deployment", Entry{
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			Actor:       "client",
+			OldReplicas: i - 1,
+			NewReplicas: i,
+		})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if err := store.Append(ctx, "default", "other-deployment", Entry{Actor: "client", NewReplicas: 1}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	entries, err := store.List(ctx, "default", "my-deployment")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	want := []int32{5, 4, 3}
+	if len(entries) != len(want) {
+		t.Fatalf("List() returned %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.NewReplicas != want[i] {
+			t.Errorf("List()[%d].NewReplicas = %d, want %d", i, entry.NewReplicas, want[i])
+		}
+	}
+
+	other, err := store.List(ctx, "default", "other-deployment")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(other) != 1 {
+		t.Errorf("List() returned %d entries for other-deployment, want 1", len(other))
+	}
+}
+
+func TestStoreListWithoutHistory(t *testing.T) {
+	store := NewStore(fake.NewSimpleClientset(), 0)
+
+	entries, err := store.List(context.TODO(), "default", "my-deployment")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("List() returned %d entries, want 0", len(entries))
+	}
+}
+
+func TestStoreAppendBoundsConfigMapSize(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	store := NewStore(clientset, 50)
+	store.maxBytes = 4096
+	ctx := context.TODO()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Many deployments, each well under the per-deployment entry limit
+	for i := 0; i < 200; i++ {
+		deployment := fmt.Sprintf("deployment-%d", i%20)
+		err := store.Append(ctx, "default", deployment, Entry{
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			Actor:       "client",
+			NewReplicas: int32(i),
+		})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps("default").Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if size := dataSize(cm.Data); size > store.maxBytes {
+		t.Errorf("history ConfigMap data is %d bytes, want at most %d", size, store.maxBytes)
+	}
+
+	// The newest entry survives
+	latest, err := store.List(ctx, "default", "deployment-19")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(latest) == 0 || latest[0].NewReplicas != 199 {
+		t.Fatalf("List() newest entry missing, got %+v", latest)
+	}
+
+	// Entries are dropped oldest first across deployments, leaving the newest ones
+	kept := 0
+	oldest := int32(200)
+	for _, raw := range cm.Data {
+		var entries []Entry
+		if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+			t.Fatalf("decoding history: %v", err)
+		}
+		kept += len(entries)
+		for _, entry := range entries {
+			oldest = min(oldest, entry.NewReplicas)
+		}
+	}
+	if kept == 0 || oldest != int32(200-kept) {
+		t.Errorf("kept %d entries, oldest %d; want the newest %d", kept, oldest, kept)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"k8s-deployment-scaler/internal/tracing"
+
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -16,6 +18,31 @@ func NewClientset() (kubernetes.Interface, error) {
 		return nil, fmt.Errorf("error building kubeconfig: %v", err)
 	}
 
+	// Trace API calls made while serving a request as children of its span
+	config.Wrap(tracing.WrapTransport)
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Kubernetes client: %v", err)
+	}
+
+	return clientset, nil
+}
+
+// NewClientsetForKubeconfig creates a clientset for a context of a kubeconfig file.
+// An empty path uses the default loading rules, such as $KUBECONFIG, and an empty
+// context the kubeconfig's current context.
+func NewClientsetForKubeconfig(path, context string) (kubernetes.Interface, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = path
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error building kubeconfig: %v", err)
+	}
+
+	config.Wrap(tracing.WrapTransport)
+
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("error creating Kubernetes client: %v", err)
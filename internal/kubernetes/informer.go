@@ -0,0 +1,406 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/informers"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	autoscalinginformers "k8s.io/client-go/informers/autoscaling/v2"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	policyinformers "k8s.io/client-go/informers/policy/v1"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	autoscalinglisters "k8s.io/client-go/listers/autoscaling/v2"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DeploymentInformer watches the deployments in every namespace, or only in the
+// given namespaces with one informer each, so that the scaler needs no
+// cluster-wide permission to list and watch them
+type DeploymentInformer struct {
+	appsinformers.DeploymentInformer
+	factories []informers.SharedInformerFactory
+}
+
+// NewDeploymentInformer returns an informer of the deployments in namespaces,
+// or in every namespace when there are none. Start it once set up.
+func NewDeploymentInformer(clientset kubernetes.Interface, resync time.Duration, namespaces []string) *DeploymentInformer {
+	if len(namespaces) == 0 {
+		factory := informers.NewSharedInformerFactory(clientset, resync)
+		informer := factory.Apps().V1().Deployments()
+		informer.Informer()
+		return &DeploymentInformer{DeploymentInformer: informer, factories: []informers.SharedInformerFactory{factory}}
+	}
+
+	i := &DeploymentInformer{}
+	multi := &multiInformer{}
+	for _, namespace := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(clientset, resync, informers.WithNamespace(namespace))
+		multi.informers = append(multi.informers, factory.Apps().V1().Deployments().Informer())
+		i.factories = append(i.factories, factory)
+	}
+	i.DeploymentInformer = &namespacedInformer{
+		informer: multi,
+		lister:   appslisters.NewDeploymentLister(multi.GetIndexer()),
+	}
+	return i
+}
+
+// HorizontalPodAutoscalers returns an informer of the HorizontalPodAutoscalers
+// in the same namespaces, started along with the deployments. Call it before Start.
+func (i *DeploymentInformer) HorizontalPodAutoscalers() autoscalinginformers.HorizontalPodAutoscalerInformer {
+	if _, namespaced := i.DeploymentInformer.(*namespacedInformer); !namespaced {
+		informer := i.factories[0].Autoscaling().V2().HorizontalPodAutoscalers()
+		informer.Informer()
+		return informer
+	}
+
+	multi := &multiInformer{}
+	for _, factory := range i.factories {
+		multi.informers = append(multi.informers, factory.Autoscaling().V2().HorizontalPodAutoscalers().Informer())
+	}
+	return &namespacedHPAInformer{
+		informer: multi,
+		lister:   autoscalinglisters.NewHorizontalPodAutoscalerLister(multi.GetIndexer()),
+	}
+}
+
+// PodDisruptionBudgets returns an informer of the PodDisruptionBudgets in the
+// same namespaces, started along with the deployments. Call it before Start.
+func (i *DeploymentInformer) PodDisruptionBudgets() policyinformers.PodDisruptionBudgetInformer {
+	if _, namespaced := i.DeploymentInformer.(*namespacedInformer); !namespaced {
+		informer := i.factories[0].Policy().V1().PodDisruptionBudgets()
+		informer.Informer()
+		return informer
+	}
+
+	multi := &multiInformer{}
+	for _, factory := range i.factories {
+		multi.informers = append(multi.informers, factory.Policy().V1().PodDisruptionBudgets().Informer())
+	}
+	return &namespacedPDBInformer{
+		informer: multi,
+		lister:   policylisters.NewPodDisruptionBudgetLister(multi.GetIndexer()),
+	}
+}
+
+// ResourceQuotas returns an informer of the ResourceQuotas in the same
+// namespaces, started along with the deployments. Call it before Start.
+func (i *DeploymentInformer) ResourceQuotas() coreinformers.ResourceQuotaInformer {
+	if _, namespaced := i.DeploymentInformer.(*namespacedInformer); !namespaced {
+		informer := i.factories[0].Core().V1().ResourceQuotas()
+		informer.Informer()
+		return informer
+	}
+
+	multi := &multiInformer{}
+	for _, factory := range i.factories {
+		multi.informers = append(multi.informers, factory.Core().V1().ResourceQuotas().Informer())
+	}
+	return &namespacedQuotaInformer{
+		informer: multi,
+		lister:   corelisters.NewResourceQuotaLister(multi.GetIndexer()),
+	}
+}
+
+// Start starts watching until stopCh is closed
+func (i *DeploymentInformer) Start(stopCh <-chan struct{}) {
+	for _, factory := range i.factories {
+		factory.Start(stopCh)
+	}
+}
+
+// Shutdown waits for the informers to stop once stopCh is closed
+func (i *DeploymentInformer) Shutdown() {
+	for _, factory := range i.factories {
+		factory.Shutdown()
+	}
+}
+
+// namespacedInformer serves a multiInformer as a DeploymentInformer
+type namespacedInformer struct {
+	informer *multiInformer
+	lister   appslisters.DeploymentLister
+}
+
+func (n *namespacedInformer) Informer() cache.SharedIndexInformer  { return n.informer }
+func (n *namespacedInformer) Lister() appslisters.DeploymentLister { return n.lister }
+
+// namespacedHPAInformer serves a multiInformer as a HorizontalPodAutoscalerInformer
+type namespacedHPAInformer struct {
+	informer *multiInformer
+	lister   autoscalinglisters.HorizontalPodAutoscalerLister
+}
+
+func (n *namespacedHPAInformer) Informer() cache.SharedIndexInformer { return n.informer }
+func (n *namespacedHPAInformer) Lister() autoscalinglisters.HorizontalPodAutoscalerLister {
+	return n.lister
+}
+
+// namespacedPDBInformer serves a multiInformer as a PodDisruptionBudgetInformer
+type namespacedPDBInformer struct {
+	informer *multiInformer
+	lister   policylisters.PodDisruptionBudgetLister
+}
+
+func (n *namespacedPDBInformer) Informer() cache.SharedIndexInformer { return n.informer }
+func (n *namespacedPDBInformer) Lister() policylisters.PodDisruptionBudgetLister {
+	return n.lister
+}
+
+// namespacedQuotaInformer serves a multiInformer as a ResourceQuotaInformer
+type namespacedQuotaInformer struct {
+	informer *multiInformer
+	lister   corelisters.ResourceQuotaLister
+}
+
+func (n *namespacedQuotaInformer) Informer() cache.SharedIndexInformer { return n.informer }
+func (n *namespacedQuotaInformer) Lister() corelisters.ResourceQuotaLister {
+	return n.lister
+}
+
+// errReadOnly is returned by writes to a multiIndexer, which only its
+// informers fill
+var errReadOnly = errors.New("the merged informer cache is read-only")
+
+// multiInformer presents informers of disjoint sets of objects, such as one per
+// namespace, as one informer: handlers are added to each, it has synced once
+// each has, and its cache holds the objects of all of them
+type multiInformer struct {
+	informers []cache.SharedIndexInformer
+}
+
+// registrations is the handler registration returned by a multiInformer
+type registrations []cache.ResourceEventHandlerRegistration
+
+func (r registrations) HasSynced() bool {
+	for _, registration := range r {
+		if !registration.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *multiInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	return m.AddEventHandlerWithResyncPeriod(handler, 0)
+}
+
+func (m *multiInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) (cache.ResourceEventHandlerRegistration, error) {
+	var added registrations
+	for _, informer := range m.informers {
+		registration, err := informer.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+		if err != nil {
+			m.RemoveEventHandler(added)
+			return nil, err
+		}
+		added = append(added, registration)
+	}
+	return added, nil
+}
+
+func (m *multiInformer) RemoveEventHandler(handle cache.ResourceEventHandlerRegistration) error {
+	added, ok := handle.(registrations)
+	if !ok {
+		return fmt.Errorf("registration %v was not added by this informer", handle)
+	}
+	var errs []error
+	for i, registration := range added {
+		errs = append(errs, m.informers[i].RemoveEventHandler(registration))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *multiInformer) GetStore() cache.Store { return m.GetIndexer() }
+
+func (m *multiInformer) GetIndexer() cache.Indexer {
+	indexers := make(multiIndexer, 0, len(m.informers))
+	for _, informer := range m.informers {
+		indexers = append(indexers, informer.GetIndexer())
+	}
+	return indexers
+}
+
+// GetController returns the informer itself, which runs and syncs its informers
+func (m *multiInformer) GetController() cache.Controller { return m }
+
+func (m *multiInformer) Run(stopCh <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, informer := range m.informers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			informer.Run(stopCh)
+		}()
+	}
+	wg.Wait()
+}
+
+func (m *multiInformer) HasSynced() bool {
+	for _, informer := range m.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// LastSyncResourceVersion joins the informers' resource versions, changing
+// whenever any of them does
+func (m *multiInformer) LastSyncResourceVersion() string {
+	versions := make([]string, 0, len(m.informers))
+	for _, informer := range m.informers {
+		versions = append(versions, informer.LastSyncResourceVersion())
+	}
+	return strings.Join(versions, ",")
+}
+
+func (m *multiInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	var errs []error
+	for _, informer := range m.informers {
+		errs = append(errs, informer.SetWatchErrorHandler(handler))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *multiInformer) SetTransform(handler cache.TransformFunc) error {
+	var errs []error
+	for _, informer := range m.informers {
+		errs = append(errs, informer.SetTransform(handler))
+	}
+	return errors.Join(errs...)
+}
+
+// IsStopped reports whether any of the informers has stopped
+func (m *multiInformer) IsStopped() bool {
+	for _, informer := range m.informers {
+		if informer.IsStopped() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *multiInformer) AddIndexers(indexers cache.Indexers) error {
+	var errs []error
+	for _, informer := range m.informers {
+		errs = append(errs, informer.AddIndexers(indexers))
+	}
+	return errors.Join(errs...)
+}
+
+// multiIndexer reads the caches of a multiInformer's informers as one. Since
+// they hold disjoint objects, the results of each are simply combined.
+type multiIndexer []cache.Indexer
+
+func (m multiIndexer) Add(interface{}) error               { return errReadOnly }
+func (m multiIndexer) Update(interface{}) error            { return errReadOnly }
+func (m multiIndexer) Delete(interface{}) error            { return errReadOnly }
+func (m multiIndexer) Replace([]interface{}, string) error { return errReadOnly }
+func (m multiIndexer) Resync() error                       { return errReadOnly }
+
+func (m multiIndexer) AddIndexers(indexers cache.Indexers) error {
+	var errs []error
+	for _, indexer := range m {
+		errs = append(errs, indexer.AddIndexers(indexers))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiIndexer) List() []interface{} {
+	var items []interface{}
+	for _, indexer := range m {
+		items = append(items, indexer.List()...)
+	}
+	return items
+}
+
+func (m multiIndexer) ListKeys() []string {
+	var keys []string
+	for _, indexer := range m {
+		keys = append(keys, indexer.ListKeys()...)
+	}
+	return keys
+}
+
+func (m multiIndexer) Get(obj interface{}) (interface{}, bool, error) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil, false, err
+	}
+	return m.GetByKey(key)
+}
+
+func (m multiIndexer) GetByKey(key string) (interface{}, bool, error) {
+	for _, indexer := range m {
+		item, exists, err := indexer.GetByKey(key)
+		if err != nil || exists {
+			return item, exists, err
+		}
+	}
+	return nil, false, nil
+}
+
+func (m multiIndexer) Index(indexName string, obj interface{}) ([]interface{}, error) {
+	var items []interface{}
+	for _, indexer := range m {
+		found, err := indexer.Index(indexName, obj)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+func (m multiIndexer) IndexKeys(indexName, indexedValue string) ([]string, error) {
+	var keys []string
+	for _, indexer := range m {
+		found, err := indexer.IndexKeys(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, found...)
+	}
+	return keys, nil
+}
+
+func (m multiIndexer) ListIndexFuncValues(indexName string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, indexer := range m {
+		for _, value := range indexer.ListIndexFuncValues(indexName) {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func (m multiIndexer) ByIndex(indexName, indexedValue string) ([]interface{}, error) {
+	var items []interface{}
+	for _, indexer := range m {
+		found, err := indexer.ByIndex(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+// GetIndexers returns the indexers, which every informer shares
+func (m multiIndexer) GetIndexers() cache.Indexers {
+	if len(m) == 0 {
+		return cache.Indexers{}
+	}
+	return m[0].GetIndexers()
+}
@@ -0,0 +1,222 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newDeployment(namespace, name string) *appsv1.Deployment {
+	return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+// names returns the sorted namespace/name of each deployment
+func names(deployments []*appsv1.Deployment) string {
+	var keys []string
+	for _, d := range deployments {
+		keys = append(keys, d.Namespace+"/"+d.Name)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func TestNewDeploymentInformer(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		want       string
+	}{
+		{
+			name: "Every namespace",
+			want: "default/web,payments/api,payments/worker,staging/web",
+		},
+		{
+			name:       "Some namespaces",
+			namespaces: []string{"payments", "staging"},
+			want:       "payments/api,payments/worker,staging/web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(
+				newDeployment("default", "web"),
+				newDeployment("payments", "api"),
+				newDeployment("payments", "worker"),
+				newDeployment("staging", "web"),
+			)
+			informer := NewDeploymentInformer(clientset, 0, tt.namespaces)
+
+			var mu sync.Mutex
+			var added []string
+			registration, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					mu.Lock()
+					added = append(added, obj.(*appsv1.Deployment).Name)
+					mu.Unlock()
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stopCh := make(chan struct{})
+			informer.Start(stopCh)
+			defer informer.Shutdown()
+			defer close(stopCh)
+			if !cache.WaitForCacheSync(stopCh, informer.Informer().HasSynced, registration.HasSynced) {
+				t.Fatal("informer did not sync")
+			}
+
+			list, err := informer.Lister().List(labels.Everything())
+			if err != nil || names(list) != tt.want {
+				t.Errorf("List() = %s, %v; want %s", names(list), err, tt.want)
+			}
+			list, err = informer.Lister().Deployments("payments").List(labels.Everything())
+			if err != nil || names(list) != "payments/api,payments/worker" {
+				t.Errorf("List(payments) = %s, %v", names(list), err)
+			}
+			if d, err := informer.Lister().Deployments("staging").Get("web"); err != nil || d.Namespace != "staging" {
+				t.Errorf("Get(staging/web) = %v, %v", d, err)
+			}
+			if got := len(informer.Informer().GetStore().ListKeys()); got != strings.Count(tt.want, ",")+1 {
+				t.Errorf("ListKeys() has %d keys", got)
+			}
+			mu.Lock()
+			if len(added) != strings.Count(tt.want, ",")+1 {
+				t.Errorf("handler saw %v", added)
+			}
+			mu.Unlock()
+
+			// Deployments created later are seen in watched namespaces only
+			for _, namespace := range []string{"payments", "default"} {
+				if _, err := clientset.AppsV1().Deployments(namespace).Create(context.TODO(), newDeployment(namespace, "new"), metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			appeared := func(namespace string) bool {
+				err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, time.Second, true, func(context.Context) (bool, error) {
+					_, err := informer.Lister().Deployments(namespace).Get("new")
+					return err == nil, nil
+				})
+				return err == nil
+			}
+			if !appeared("payments") {
+				t.Error("payments/new never appeared")
+			}
+			if watched := tt.namespaces == nil; appeared("default") != watched {
+				t.Errorf("default/new cached = %v, want %v", !watched, watched)
+			}
+		})
+	}
+}
+
+func TestDeploymentInformerUnwatchedNamespace(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newDeployment("default", "web"))
+	informer := NewDeploymentInformer(clientset, 0, []string{"payments"})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informer.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.Informer().HasSynced) {
+		t.Fatal("informer did not sync")
+	}
+
+	if _, err := informer.Lister().Deployments("default").Get("web"); err == nil {
+		t.Error("unwatched namespace's deployment is cached")
+	}
+	if err := informer.Informer().GetStore().Add(newDeployment("payments", "api")); err == nil {
+		t.Error("the merged cache accepted a write")
+	}
+}
+
+func TestRelatedInformers(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		want       []string
+	}{
+		{name: "Every namespace", want: []string{"default/web", "payments/api"}},
+		{name: "Some namespaces", namespaces: []string{"payments", "staging"}, want: []string{"payments/api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(
+				&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}},
+				&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "api"}},
+				&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}},
+				&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "api"}},
+				&corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}},
+				&corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "api"}},
+			)
+			informer := NewDeploymentInformer(clientset, 0, tt.namespaces)
+			hpas := informer.HorizontalPodAutoscalers()
+			pdbs := informer.PodDisruptionBudgets()
+			quotas := informer.ResourceQuotas()
+
+			stopCh := make(chan struct{})
+			informer.Start(stopCh)
+			defer informer.Shutdown()
+			defer close(stopCh)
+			if !cache.WaitForCacheSync(stopCh, informer.Informer().HasSynced, hpas.Informer().HasSynced, pdbs.Informer().HasSynced, quotas.Informer().HasSynced) {
+				t.Fatal("informers did not sync")
+			}
+
+			list, err := hpas.Lister().List(labels.Everything())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, hpa := range list {
+				got = append(got, hpa.Namespace+"/"+hpa.Name)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+			if _, err := hpas.Lister().HorizontalPodAutoscalers("payments").Get("api"); err != nil {
+				t.Errorf("Get(payments/api) error = %v", err)
+			}
+
+			budgets, err := pdbs.Lister().List(labels.Everything())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = nil
+			for _, pdb := range budgets {
+				got = append(got, pdb.Namespace+"/"+pdb.Name)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("PodDisruptionBudgets List() = %v, want %v", got, tt.want)
+			}
+
+			quotaList, err := quotas.Lister().List(labels.Everything())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = nil
+			for _, quota := range quotaList {
+				got = append(got, quota.Namespace+"/"+quota.Name)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ResourceQuotas List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s-deployment-scaler/internal/middleware"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/tools/cache"
+)
+
+const metricsNamespace = "scaler"
+
+// Scale operation outcomes
+const (
+	OutcomeSuccess  = "success"
+	OutcomeRejected = "rejected"
+	OutcomeNotFound = "not_found"
+	OutcomeFailed   = "failed"
+)
+
+// Metrics holds the Prometheus collectors for the API and scaling activity.
+// All methods are safe to call on a nil *Metrics, which records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests             *prometheus.CounterVec
+	requestDuration      *prometheus.HistogramVec
+	scaleOperations      *prometheus.CounterVec
+	updateScaleDuration  prometheus.Histogram
+	tlsHandshakeFailures *prometheus.CounterVec
+}
+
+// New creates and registers all collectors on a dedicated registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		scaleOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "scale_operations_total",
+			Help:      "Scale requests by target namespace and outcome.",
+		}, []string{"namespace", "outcome"}),
+		updateScaleDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "update_scale_duration_seconds",
+			Help:      "Latency of UpdateScale calls to the Kubernetes API.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		tlsHandshakeFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tls_handshake_failures_total",
+			Help:      "Failed TLS handshakes by reason.",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.scaleOperations,
+		m.updateScaleDuration,
+		m.tlsHandshakeFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records request counts and latency for next. Routes are labelled with
+// the mux pattern that matched the request rather than the raw path, keeping
+// label cardinality bounded.
+func (m *Metrics) Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := middleware.NewStatusRecorder(w)
+		next.ServeHTTP(recorder, r)
+
+		route := routeLabel(mux, r)
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.Status)).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeLabel returns the path part of the mux pattern matching the request
+func routeLabel(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if pattern == "" {
+		return "unmatched"
+	}
+	// Patterns may be prefixed with a method, e.g. "GET /replica-count"
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = pattern[i+1:]
+	}
+	return pattern
+}
+
+// ObserveScale counts a scale request against the target namespace
+func (m *Metrics) ObserveScale(namespace, outcome string) {
+	if m == nil {
+		return
+	}
+	m.scaleOperations.WithLabelValues(namespace, outcome).Inc()
+}
+
+// ObserveUpdateScale records the latency of an UpdateScale call
+func (m *Metrics) ObserveUpdateScale(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.updateScaleDuration.Observe(d.Seconds())
+}
+
+// TLSHandshakeFailed counts a failed TLS handshake
+func (m *Metrics) TLSHandshakeFailed(reason string) {
+	if m == nil {
+		return
+	}
+	m.tlsHandshakeFailures.WithLabelValues(reason).Inc()
+}
+
+// RegisterInformer exposes the informer's cache size and the age of its last
+// delivered event (including periodic resyncs) under the given resource label
+func (m *Metrics) RegisterInformer(resource string, informer cache.SharedIndexInformer) error {
+	if m == nil {
+		return nil
+	}
+
+	var mu sync.Mutex
+	lastSync := time.Now()
+	touch := func() {
+		mu.Lock()
+		lastSync = time.Now()
+		mu.Unlock()
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { touch() },
+		UpdateFunc: func(interface{}, interface{}) { touch() },
+		DeleteFunc: func(interface{}) { touch() },
+	}); err != nil {
+		return err
+	}
+
+	labels := prometheus.Labels{"resource": resource}
+	return registerAll(m.registry,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "informer_cache_objects",
+			Help:        "Number of objects in the informer cache.",
+			ConstLabels: labels,
+		}, func() float64 {
+			return float64(len(informer.GetStore().ListKeys()))
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   metricsNamespace,
+			Name:        "informer_last_sync_age_seconds",
+			Help:        "Seconds since the informer last delivered an event or resync.",
+			ConstLabels: labels,
+		}, func() float64 {
+			mu.Lock()
+			defer mu.Unlock()
+			return time.Since(lastSync).Seconds()
+		}),
+	)
+}
+
+func registerAll(registry *prometheus.Registry, collectors ...prometheus.Collector) error {
+	for _, c := range collectors {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
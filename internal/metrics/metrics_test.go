@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// scrape returns the exposition text served by the metrics handler
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rr.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMiddlewareUsesRoutePatterns(t *testing.T) {
+	m := New()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /deployments/{namespace}/{name}/history", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /replica-count", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	handler := m.Middleware(mux, mux)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/deployments/default/a/history", nil),
+		httptest.NewRequest("GET", "/deployments/default/b/history", nil),
+		httptest.NewRequest("POST", "/replica-count?namespace=default&deployment=a", nil),
+		httptest.NewRequest("GET", "/unknown/path", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	output := scrape(t, m)
+	expected := []string{
+		`scaler_http_requests_total{code="200",method="GET",route="/deployments/{namespace}/{name}/history"} 2`,
+		`scaler_http_requests_total{code="400",method="POST",route="/replica-count"} 1`,
+		`scaler_http_requests_total{code="404",method="GET",route="unmatched"} 1`,
+		`scaler_http_request_duration_seconds_count{method="GET",route="/deployments/{namespace}/{name}/history"} 2`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("metrics output missing %q", line)
+		}
+	}
+}
+
+func TestScaleAndTLSMetrics(t *testing.T) {
+	m := New()
+	m.ObserveScale("default", OutcomeSuccess)
+	m.ObserveScale("default", OutcomeSuccess)
+	m.ObserveScale("payments", OutcomeRejected)
+	m.ObserveUpdateScale(20 * time.Millisecond)
+	m.TLSHandshakeFailed("bad_certificate")
+
+	output := scrape(t, m)
+	expected := []string{
+		`scaler_scale_operations_total{namespace="default",outcome="success"} 2`,
+		`scaler_scale_operations_total{namespace="payments",outcome="rejected"} 1`,
+		`scaler_update_scale_duration_seconds_count 1`,
+		`scaler_tls_handshake_failures_total{reason="bad_certificate"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("metrics output missing %q", line)
+		}
+	}
+}
+
+func TestRegisterInformer(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-deployment", Namespace: "default"},
+	})
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	informer := factory.Apps().V1().Deployments().Informer()
+
+	m := New()
+	if err := m.RegisterInformer("deployments", informer); err != nil {
+		t.Fatalf("RegisterInformer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	output := scrape(t, m)
+	for _, line := range []string{
+		`scaler_informer_cache_objects{resource="deployments"} 1`,
+		`scaler_informer_last_sync_age_seconds{resource="deployments"}`,
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("metrics output missing %q", line)
+		}
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveScale("default", OutcomeSuccess)
+	m.ObserveUpdateScale(time.Second)
+	m.TLSHandshakeFailed("eof")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	if handler := m.Middleware(http.NewServeMux(), next); handler == nil {
+		t.Error("Middleware() on nil metrics returned nil handler")
+	}
+}
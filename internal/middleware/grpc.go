@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s-deployment-scaler/internal/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDMetadata is the gRPC metadata key carrying the request ID
+var requestIDMetadata = strings.ToLower(logging.RequestIDHeader)
+
+// UnaryRequestID is the gRPC counterpart of RequestID: it accepts the caller's
+// x-request-id metadata or generates an ID, and returns it as a response header
+func UnaryRequestID(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	requestID := incomingRequestID(ctx)
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+	return handler(logging.WithRequestID(ctx, requestID), req)
+}
+
+// StreamRequestID is UnaryRequestID for streaming calls
+func StreamRequestID(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	requestID := incomingRequestID(stream.Context())
+	stream.SetHeader(metadata.Pairs(requestIDMetadata, requestID))
+	return handler(srv, WithStreamContext(stream, logging.WithRequestID(stream.Context(), requestID)))
+}
+
+// incomingRequestID returns the caller's valid request ID or a new one
+func incomingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(requestIDMetadata); len(values) > 0 && validRequestID(values[0]) {
+		return values[0]
+	}
+	return newRequestID()
+}
+
+// UnaryLogging is the gRPC counterpart of Logging
+func UnaryLogging(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	slog.DebugContext(ctx, "Request started", "method", info.FullMethod)
+	resp, err := handler(ctx, req)
+	logCompleted(ctx, info.FullMethod, err, start)
+	return resp, err
+}
+
+// StreamLogging is UnaryLogging for streaming calls, logging when the stream ends
+func StreamLogging(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	slog.DebugContext(stream.Context(), "Request started", "method", info.FullMethod)
+	err := handler(srv, stream)
+	logCompleted(stream.Context(), info.FullMethod, err, start)
+	return err
+}
+
+func logCompleted(ctx context.Context, method string, err error, start time.Time) {
+	code := status.Code(err)
+	slog.InfoContext(ctx, "Request completed",
+		"method", method,
+		"code", code.String(),
+		"status", HTTPStatus(code),
+		"duration", time.Since(start),
+	)
+}
+
+// httpStatuses maps gRPC codes to the HTTP statuses the REST API uses for the same errors
+var httpStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499, // client closed request, as nginx reports it
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.Aborted:            http.StatusConflict,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+}
+
+// HTTPStatus returns the HTTP status equivalent to a gRPC code, so gRPC calls can
+// be logged, audited and counted alongside REST requests
+func HTTPStatus(code codes.Code) int {
+	if status, ok := httpStatuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// contextStream overrides the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// WithStreamContext returns the stream with its context replaced, so stream
+// interceptors can pass values to the handler as unary ones do
+func WithStreamContext(stream grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return contextStream{ServerStream: stream, ctx: ctx}
+}
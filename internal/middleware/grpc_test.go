@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"k8s-deployment-scaler/internal/logging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "Accepts caller ID", incoming: "abc-123", wantSame: true},
+		{name: "Generates when missing", incoming: ""},
+		{name: "Replaces unsafe ID", incoming: "bad id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.incoming != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-request-id", tt.incoming))
+			}
+
+			var seen string
+			UnaryRequestID(ctx, nil, nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				seen = logging.RequestID(ctx)
+				return nil, nil
+			})
+
+			if seen == "" {
+				t.Fatal("request ID missing from context")
+			}
+			if (seen == tt.incoming) != tt.wantSame {
+				t.Errorf("request ID = %q, incoming %q, want reuse %v", seen, tt.incoming, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for code, want := range map[codes.Code]int{
+		codes.OK:                http.StatusOK,
+		codes.NotFound:          http.StatusNotFound,
+		codes.ResourceExhausted: http.StatusTooManyRequests,
+		codes.DeadlineExceeded:  http.StatusGatewayTimeout,
+		codes.DataLoss:          http.StatusInternalServerError,
+	} {
+		if got := HTTPStatus(code); got != want {
+			t.Errorf("HTTPStatus(%v) = %d, want %d", code, got, want)
+		}
+	}
+}
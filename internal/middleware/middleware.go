@@ -1,7 +1,8 @@
 package middleware
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -10,9 +11,15 @@ import (
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		log.Printf("Started %s %s", r.Method, r.URL.Path)
-		next.ServeHTTP(w, r)
-		log.Printf("Completed %s %s in %v", r.Method, r.URL.Path, time.Since(start))
+		slog.DebugContext(r.Context(), "Request started", "method", r.Method, "path", r.URL.Path)
+		recorder := NewStatusRecorder(w)
+		next.ServeHTTP(recorder, r)
+		slog.InfoContext(r.Context(), "Request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.Status,
+			"duration", time.Since(start),
+		)
 	})
 }
 
@@ -23,3 +30,37 @@ func JSONContentType(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// StatusRecorder wraps an http.ResponseWriter to remember the status code written
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status int
+}
+
+// NewStatusRecorder returns a StatusRecorder defaulting to 200 OK
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+// WriteHeader records the status code before writing it
+func (r *StatusRecorder) WriteHeader(status int) {
+	r.Status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Deprecated marks responses of a legacy route with a Deprecation header and,
+// when successor returns a path, a Link header naming the route that replaces it
+func Deprecated(successor func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if path := successor(r); path != "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, path))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
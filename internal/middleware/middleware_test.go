@@ -2,49 +2,109 @@ package middleware
 
 import (
 	"bytes"
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"k8s-deployment-scaler/internal/logging"
 )
 
 func TestLogging(t *testing.T) {
-	// Create a buffer to capture log output
+	// Capture structured log output
 	var buf bytes.Buffer
-	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, level))
+	defer slog.SetDefault(previous)
 
 	// Create a test handler
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+		w.WriteHeader(http.StatusAccepted)
 	})
 
-	// Wrap the test handler with the Logging middleware
-	loggingHandler := Logging(testHandler)
+	// Wrap the test handler with the Logging and RequestID middleware
+	loggingHandler := RequestID(Logging(testHandler))
 
 	// Create a test request
 	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "req-1")
 	rr := httptest.NewRecorder()
 
 	// Serve the request
 	loggingHandler.ServeHTTP(rr, req)
 
 	// Check the response
-	if status := rr.Code; status != http.StatusOK {
-		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	if status := rr.Code; status != http.StatusAccepted {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusAccepted)
 	}
 
 	// Check the log output
-	logOutput := buf.String()
-	expectedParts := []string{"Started GET /test", "Completed GET /test in"}
-	for _, part := range expectedParts {
-		if !strings.Contains(logOutput, part) {
-			t.Errorf("log output doesn't contain expected part: %s", part)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var completed map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &completed); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	expected := map[string]interface{}{
+		"msg":        "Request completed",
+		"method":     "GET",
+		"path":       "/test",
+		"status":     float64(http.StatusAccepted),
+		"request_id": "req-1",
+	}
+	for key, want := range expected {
+		if completed[key] != want {
+			t.Errorf("log field %s = %v, want %v", key, completed[key], want)
 		}
 	}
 }
 
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "Accepts caller ID", incoming: "abc-123", wantSame: true},
+		{name: "Generates when missing", incoming: ""},
+		{name: "Replaces unsafe ID", incoming: "bad id\n"},
+		{name: "Replaces oversized ID", incoming: strings.Repeat("a", 200)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = logging.RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if seen == "" {
+				t.Fatal("request ID missing from context")
+			}
+			if got := rr.Header().Get("X-Request-ID"); got != seen {
+				t.Errorf("response header %q does not match context %q", got, seen)
+			}
+			if (seen == tt.incoming) != tt.wantSame {
+				t.Errorf("request ID = %q, incoming %q, want reuse %v", seen, tt.incoming, tt.wantSame)
+			}
+		})
+	}
+}
+
 func TestJSONContentType(t *testing.T) {
 	// Create a test handler
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -73,3 +133,80 @@ func TestJSONContentType(t *testing.T) {
 		t.Errorf("handler returned wrong Content-Type: got %v want %v", contentType, expectedContentType)
 	}
 }
+
+func TestStatusRecorder(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedStatus int
+	}{
+		{
+			name:           "Implicit OK",
+			handler:        func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Explicit status",
+			handler:        func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) },
+			expectedStatus: http.StatusTeapot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			recorder := NewStatusRecorder(rr)
+			tt.handler(recorder, httptest.NewRequest("GET", "/test", nil))
+
+			if recorder.Status != tt.expectedStatus {
+				t.Errorf("StatusRecorder.Status = %v, want %v", recorder.Status, tt.expectedStatus)
+			}
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("underlying writer status = %v, want %v", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	successor := func(r *http.Request) string {
+		if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+			return "/api/v1/namespaces/" + namespace + "/deployments"
+		}
+		return ""
+	}
+	handler := Deprecated(successor, testHandler)
+
+	tests := []struct {
+		name         string
+		url          string
+		expectedLink string
+	}{
+		{
+			name:         "With successor",
+			url:          "/deployments?namespace=default",
+			expectedLink: `</api/v1/namespaces/default/deployments>; rel="successor-version"`,
+		},
+		{
+			name: "Without successor",
+			url:  "/deployments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", tt.url, nil))
+
+			if got := rr.Header().Get("Deprecation"); got != "true" {
+				t.Errorf("Deprecation = %q, want true", got)
+			}
+			if got := rr.Header().Get("Link"); got != tt.expectedLink {
+				t.Errorf("Link = %q, want %q", got, tt.expectedLink)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ChangePolicy describes the change-management metadata a scale request must carry
+type ChangePolicy struct {
+	RequireReason bool   `json:"requireReason"`
+	RequireTicket bool   `json:"requireTicket"`
+	TicketPattern string `json:"ticketPattern,omitempty"`
+
+	ticketRegexp *regexp.Regexp
+}
+
+// ChangePolicies holds the default change policy and per-namespace overrides
+type ChangePolicies struct {
+	Default    ChangePolicy            `json:"default"`
+	Namespaces map[string]ChangePolicy `json:"namespaces"`
+}
+
+// NewChangePolicies builds change policies, compiling every ticket pattern
+func NewChangePolicies(defaultPolicy ChangePolicy, namespaces map[string]ChangePolicy) (*ChangePolicies, error) {
+	policies := &ChangePolicies{
+		Default:    defaultPolicy,
+		Namespaces: namespaces,
+	}
+	if err := policies.compile(); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// LoadChangePolicies reads change policies from a YAML or JSON file
+func LoadChangePolicies(path string) (*ChangePolicies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading change policy file: %v", err)
+	}
+
+	var policies ChangePolicies
+	if err := yaml.UnmarshalStrict(data, &policies); err != nil {
+		return nil, fmt.Errorf("parsing change policy file: %v", err)
+	}
+
+	if err := policies.compile(); err != nil {
+		return nil, err
+	}
+	return &policies, nil
+}
+
+// compile validates and compiles every ticket pattern
+func (p *ChangePolicies) compile() error {
+	if err := p.Default.compile(); err != nil {
+		return fmt.Errorf("default change policy: %v", err)
+	}
+	for namespace, policy := range p.Namespaces {
+		if err := policy.compile(); err != nil {
+			return fmt.Errorf("change policy for namespace %s: %v", namespace, err)
+		}
+		p.Namespaces[namespace] = policy
+	}
+	return nil
+}
+
+func (p *ChangePolicy) compile() error {
+	if p.TicketPattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(p.TicketPattern)
+	if err != nil {
+		return fmt.Errorf("invalid ticketPattern %q: %v", p.TicketPattern, err)
+	}
+	p.ticketRegexp = re
+	return nil
+}
+
+// For returns the policy that applies to the namespace. A nil receiver imposes no requirements.
+func (p *ChangePolicies) For(namespace string) ChangePolicy {
+	if p == nil {
+		return ChangePolicy{}
+	}
+	if policy, ok := p.Namespaces[namespace]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// Validate checks the reason and ticket of a scale request against the policy
+func (p ChangePolicy) Validate(reason, ticket string) error {
+	if p.RequireReason && reason == "" {
+		return fmt.Errorf("a reason is required")
+	}
+	if p.RequireTicket && ticket == "" {
+		return fmt.Errorf("a ticket is required")
+	}
+	if ticket != "" && p.ticketRegexp != nil && !p.ticketRegexp.MatchString(ticket) {
+		return fmt.Errorf("ticket %q does not match required pattern %s", ticket, p.TicketPattern)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePolicyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Error writing policy file: %v", err)
+	}
+	return path
+}
+
+func TestLoadChangePolicies(t *testing.T) {
+	path := writePolicyFile(t, `
+default:
+  requireReason: false
+namespaces:
+  payments:
+    requireReason: true
+    requireTicket: true
+    ticketPattern: '^CHG-[0-9]+$'
+`)
+
+	policies, err := LoadChangePolicies(path)
+	if err != nil {
+		t.Fatalf("LoadChangePolicies() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		reason    string
+		ticket    string
+		wantError string
+	}{
+		{name: "Default allows empty metadata", namespace: "default"},
+		{name: "Missing reason", namespace: "payments", ticket: "CHG-1", wantError: "a reason is required"},
+		{name: "Missing ticket", namespace: "payments", reason: "load", wantError: "a ticket is required"},
+		{name: "Malformed ticket", namespace: "payments", reason: "load", ticket: "JIRA-1", wantError: `ticket "JIRA-1" does not match required pattern ^CHG-[0-9]+$`},
+		{name: "Valid metadata", namespace: "payments", reason: "load", ticket: "CHG-42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policies.For(tt.namespace).Validate(tt.reason, tt.ticket)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantError {
+				t.Errorf("Validate() error = %v, want %s", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestLoadChangePoliciesInvalidPattern(t *testing.T) {
+	path := writePolicyFile(t, `
+namespaces:
+  payments:
+    ticketPattern: '('
+`)
+
+	if _, err := LoadChangePolicies(path); err == nil {
+		t.Error("LoadChangePolicies() expected error for invalid ticketPattern")
+	}
+}
+
+func TestNilChangePolicies(t *testing.T) {
+	var policies *ChangePolicies
+	if err := policies.For("payments").Validate("", ""); err != nil {
+		t.Errorf("Validate() unexpected error = %v", err)
+	}
+}
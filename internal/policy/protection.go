@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Protection lists the deployments the scaler must never scale: every
+// deployment in a protected namespace or matching a protected label selector,
+// and the scaler's own deployment
+type Protection struct {
+	namespaces map[string]bool
+	selectors  []labels.Selector
+	// selfNamespace and selfName identify the scaler's own deployment, if known
+	selfNamespace string
+	selfName      string
+}
+
+// NewProtection builds the protection of the namespaces and of the deployments
+// matching any of the label selectors
+func NewProtection(namespaces, selectors []string) (*Protection, error) {
+	p := &Protection{namespaces: make(map[string]bool, len(namespaces))}
+	for _, namespace := range namespaces {
+		p.namespaces[namespace] = true
+	}
+	for _, selector := range selectors {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protected selector %q: %v", selector, err)
+		}
+		if parsed.Empty() {
+			return nil, fmt.Errorf("protected selector %q matches every deployment", selector)
+		}
+		p.selectors = append(p.selectors, parsed)
+	}
+	return p, nil
+}
+
+// WithSelf returns a copy of the protection that also protects the scaler's own
+// deployment. An empty namespace or name leaves it unprotected.
+func (p *Protection) WithSelf(namespace, name string) *Protection {
+	protection := &Protection{}
+	if p != nil {
+		*protection = *p
+	}
+	protection.selfNamespace, protection.selfName = namespace, name
+	return protection
+}
+
+// Check returns why the deployment is protected, or nil if it may be scaled.
+// A nil receiver protects nothing.
+func (p *Protection) Check(namespace, name string, deploymentLabels map[string]string) error {
+	if p == nil {
+		return nil
+	}
+	if p.selfName != "" && namespace == p.selfNamespace && name == p.selfName {
+		return fmt.Errorf("it is the scaler's own deployment")
+	}
+	if p.namespaces[namespace] {
+		return fmt.Errorf("namespace %s is protected", namespace)
+	}
+	for _, selector := range p.selectors {
+		if selector.Matches(labels.Set(deploymentLabels)) {
+			return fmt.Errorf("it matches the protected selector %s", selector)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"testing"
+)
+
+func TestProtection(t *testing.T) {
+	protection, err := NewProtection([]string{"kube-system"}, []string{"scaler.example.com/protected=true", "app in (ingress-nginx, traefik)"})
+	if err != nil {
+		t.Fatalf("NewProtection() error = %v", err)
+	}
+	protection = protection.WithSelf("scaler", "k8s-deployment-scaler")
+
+	tests := []struct {
+		name      string
+		namespace string
+		labels    map[string]string
+		wantError string
+	}{
+		{name: "Unprotected", namespace: "default", labels: map[string]string{"app": "web"}},
+		{name: "Protected namespace", namespace: "kube-system", wantError: "namespace kube-system is protected"},
+		{name: "Protected label", namespace: "default", labels: map[string]string{"scaler.example.com/protected": "true"}, wantError: "it matches the protected selector scaler.example.com/protected=true"},
+		{name: "Label set to another value", namespace: "default", labels: map[string]string{"scaler.example.com/protected": "false"}},
+		{name: "Set-based selector", namespace: "ingress", labels: map[string]string{"app": "traefik"}, wantError: "it matches the protected selector app in (ingress-nginx,traefik)"},
+		{name: "The scaler itself", namespace: "scaler", wantError: "it is the scaler's own deployment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "web"
+			if tt.namespace == "scaler" {
+				name = "k8s-deployment-scaler"
+			}
+			err := protection.Check(tt.namespace, name, tt.labels)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantError {
+				t.Errorf("Check() error = %v, want %q", err, tt.wantError)
+			}
+		})
+	}
+
+	// Other deployments in the scaler's namespace aren't protected
+	if err := protection.Check("scaler", "web", nil); err != nil {
+		t.Errorf("Check(scaler/web) error = %v", err)
+	}
+	var none *Protection
+	if err := none.Check("kube-system", "coredns", nil); err != nil {
+		t.Errorf("nil protection error = %v", err)
+	}
+}
+
+func TestNewProtectionInvalidSelector(t *testing.T) {
+	for _, selector := range []string{"app in (", ""} {
+		if _, err := NewProtection(nil, []string{selector}); err == nil {
+			t.Errorf("NewProtection(%q) accepted an invalid selector", selector)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sweepInterval is how often buckets that have refilled completely are dropped
+const sweepInterval = time.Minute
+
+// Limit describes a token bucket refilling at PerMinute tokens a minute and
+// holding at most Burst tokens
+type Limit struct {
+	PerMinute float64 `json:"perMinute"`
+	Burst     int     `json:"burst"`
+}
+
+// Validate reports whether the limit admits any requests
+func (l Limit) Validate() error {
+	if l.PerMinute <= 0 {
+		return fmt.Errorf("perMinute must be positive, got %v", l.PerMinute)
+	}
+	if l.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1, got %d", l.Burst)
+	}
+	return nil
+}
+
+// Keyed holds a separate token bucket per key, such as a client identity
+type Keyed struct {
+	limit Limit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// NewKeyed returns an empty set of buckets sharing the given limit
+func NewKeyed(limit Limit, now func() time.Time) *Keyed {
+	return &Keyed{
+		limit:     limit,
+		now:       now,
+		buckets:   make(map[string]*rate.Limiter),
+		lastSweep: now(),
+	}
+}
+
+// Reserve takes a token from key's bucket and returns a function giving it back.
+// When the bucket is empty it takes nothing and returns how long until a token
+// is available.
+func (k *Keyed) Reserve(key string) (time.Duration, func()) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	k.sweep(now)
+
+	bucket, ok := k.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(k.limit.PerMinute/60), k.limit.Burst)
+		k.buckets[key] = bucket
+	}
+
+	reservation := bucket.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, func() {}
+	}
+	return 0, func() { reservation.CancelAt(k.now()) }
+}
+
+// sweep drops full buckets, which behave exactly like new ones, so idle keys
+// don't accumulate
+func (k *Keyed) sweep(now time.Time) {
+	if now.Sub(k.lastSweep) < sweepInterval {
+		return
+	}
+	k.lastSweep = now
+	for key, bucket := range k.buckets {
+		if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+			delete(k.buckets, key)
+		}
+	}
+}
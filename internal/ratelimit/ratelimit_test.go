@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyed(t *testing.T) {
+	now := time.Now()
+	k := NewKeyed(Limit{PerMinute: 6, Burst: 2}, func() time.Time { return now })
+
+	// The burst is available immediately
+	for i := 0; i < 2; i++ {
+		if delay, _ := k.Reserve("ci"); delay != 0 {
+			t.Fatalf("request %d delayed by %v within the burst", i, delay)
+		}
+	}
+
+	// Then tokens arrive every 10 seconds
+	delay, _ := k.Reserve("ci")
+	if delay != 10*time.Second {
+		t.Errorf("delay = %v, want 10s", delay)
+	}
+
+	// A rejected request takes no token, and other keys are unaffected
+	if delay, _ := k.Reserve("ci"); delay != 10*time.Second {
+		t.Errorf("delay after a rejection = %v, want 10s", delay)
+	}
+	if delay, _ := k.Reserve("alice"); delay != 0 {
+		t.Errorf("another key was delayed by %v", delay)
+	}
+
+	now = now.Add(10 * time.Second)
+	if delay, _ := k.Reserve("ci"); delay != 0 {
+		t.Errorf("delay after refill = %v, want 0", delay)
+	}
+}
+
+func TestKeyedRefund(t *testing.T) {
+	now := time.Now()
+	k := NewKeyed(Limit{PerMinute: 1, Burst: 1}, func() time.Time { return now })
+
+	_, refund := k.Reserve("ci")
+	refund()
+	if delay, _ := k.Reserve("ci"); delay != 0 {
+		t.Errorf("refunded token was not returned: delay %v", delay)
+	}
+}
+
+func TestKeyedSweep(t *testing.T) {
+	now := time.Now()
+	k := NewKeyed(Limit{PerMinute: 60, Burst: 1}, func() time.Time { return now })
+
+	k.Reserve("ci")
+	k.Reserve("alice")
+	if len(k.buckets) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(k.buckets))
+	}
+
+	// Once refilled, idle buckets are dropped on the next sweep
+	now = now.Add(sweepInterval)
+	k.Reserve("ci")
+	if len(k.buckets) != 1 {
+		t.Errorf("got %d buckets after sweep, want 1", len(k.buckets))
+	}
+}
+
+func TestLimitValidate(t *testing.T) {
+	for _, limit := range []Limit{{PerMinute: 0, Burst: 1}, {PerMinute: 1, Burst: 0}} {
+		if err := limit.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted an invalid limit", limit)
+		}
+	}
+	if err := (Limit{PerMinute: 1, Burst: 1}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
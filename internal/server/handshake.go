@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// handshakeListener accepts TLS connections and completes their handshakes before
+// handing them to the HTTP server, so a failed handshake is reported with the
+// error crypto/tls returned rather than the line net/http would log for it
+type handshakeListener struct {
+	net.Listener
+	config  *tls.Config
+	timeout time.Duration
+	failed  func(remoteAddr net.Addr, err error)
+
+	conns     chan net.Conn
+	errs      chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newHandshakeListener(inner net.Listener, config *tls.Config, timeout time.Duration, failed func(net.Addr, error)) *handshakeListener {
+	l := &handshakeListener{
+		Listener: inner,
+		config:   config,
+		timeout:  timeout,
+		failed:   failed,
+		conns:    make(chan net.Conn),
+		errs:     make(chan error),
+		done:     make(chan struct{}),
+	}
+	go l.acceptLoop()
+	return l
+}
+
+// acceptLoop accepts raw connections and handshakes each in its own goroutine,
+// so one slow client does not hold up the others
+func (l *handshakeListener) acceptLoop() {
+	for {
+		raw, err := l.Listener.Accept()
+		if err != nil {
+			select {
+			case l.errs <- err:
+			case <-l.done:
+				return
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go l.handshake(raw)
+	}
+}
+
+func (l *handshakeListener) handshake(raw net.Conn) {
+	conn := tls.Server(raw, l.config)
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		l.failed(raw.RemoteAddr(), err)
+		raw.Close()
+		return
+	}
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+// Accept returns the next connection whose handshake succeeded
+func (l *handshakeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.errs:
+		return nil, err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *handshakeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
@@ -5,67 +5,383 @@ import (
 	"crypto/x509"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"strings"
+	"slices"
+	"time"
 
+	"k8s-deployment-scaler/internal/audit"
+	"k8s-deployment-scaler/internal/cluster"
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/cost"
 	"k8s-deployment-scaler/internal/handlers"
+	"k8s-deployment-scaler/internal/health"
+	k8s "k8s-deployment-scaler/internal/kubernetes"
+	"k8s-deployment-scaler/internal/metrics"
 	"k8s-deployment-scaler/internal/middleware"
+	"k8s-deployment-scaler/internal/policy"
+	"k8s-deployment-scaler/internal/tracing"
+	scalerv1 "k8s-deployment-scaler/pkg/api/scaler/v1"
 
-	appslisters "k8s.io/client-go/listers/apps/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	autoscalinginformers "k8s.io/client-go/informers/autoscaling/v2"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	policyinformers "k8s.io/client-go/informers/policy/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 type Server struct {
 	*http.Server
+
+	// GRPC serves the ScalerService with the same handlers, TLS configuration and
+	// audit logger as the REST API. It is nil when grpc.addr is empty.
+	GRPC *grpc.Server
+
+	// Clusters holds the local cluster and those in cfg.Clusters once synced,
+	// which are served under /clusters/{cluster}
+	Clusters *cluster.Registry
+
+	// livez and readyz serve the health endpoints, also exposed on the metrics server
+	livez  http.Handler
+	readyz http.Handler
+
+	// tlsErrors counts and logs failed TLS handshakes
+	tlsErrors *errorLogWriter
+	// handshakeTimeout bounds how long a client may take to complete a handshake
+	handshakeTimeout time.Duration
+}
+
+// ListenAndServeTLS serves HTTPS on Addr like http.Server's, but completes each
+// TLS handshake before handing the connection to the server, so failed handshakes
+// are classified by the error crypto/tls returned. certFile and keyFile may be
+// empty when TLSConfig holds the certificate.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	config := &tls.Config{}
+	if s.TLSConfig != nil {
+		config = s.TLSConfig.Clone()
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+	// Serve only sets up HTTP/2 when TLSConfig offers it
+	if len(config.NextProtos) == 0 {
+		config.NextProtos = []string{"http/1.1"}
+	}
+
+	addr := s.Addr
+	if addr == "" {
+		addr = ":https"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(newHandshakeListener(listener, config, s.handshakeTimeout, s.tlsErrors.handshakeFailed))
+}
+
+// options holds optional server dependencies
+type options struct {
+	auditLogger     *audit.Logger
+	metrics         *metrics.Metrics
+	logLevel        *slog.LevelVar
+	readinessChecks []health.Check
+	eventRecorder   record.EventRecorder
+	changePolicies  *policy.ChangePolicies
+	priceTable      *cost.PriceTable
+	connectCluster  cluster.Connector
+	hpaInformer     autoscalinginformers.HorizontalPodAutoscalerInformer
+	pdbInformer     policyinformers.PodDisruptionBudgetInformer
+	quotaInformer   coreinformers.ResourceQuotaInformer
+	// protection is built from the config, for every cluster's handlers
+	protection *policy.Protection
+}
+
+// Option configures optional server behaviour
+type Option func(*options)
+
+// WithAuditLogger records every API request to the given audit logger
+func WithAuditLogger(logger *audit.Logger) Option {
+	return func(o *options) {
+		o.auditLogger = logger
+	}
 }
 
-type customLogger struct {
-	logger *log.Logger
+// WithMetrics instruments requests, scaling and TLS handshakes with the given metrics
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
 }
 
-func (l *customLogger) Write(p []byte) (n int, err error) {
-	message := string(p)
-	if strings.Contains(message, "EOF") {
-		return len(p), nil // Suppress EOF errors
+// WithLogLevel serves GET and PUT /debug/loglevel to inspect and change the level at runtime
+func WithLogLevel(level *slog.LevelVar) Option {
+	return func(o *options) {
+		o.logLevel = level
 	}
-	return l.logger.Writer().Write(p)
 }
 
-// New creates and returns a new Server instance
-func New(deploymentLister appslisters.DeploymentLister, enableTLS bool) (*Server, error) {
-	var handler http.Handler = setupHandlers(deploymentLister)
-	var srv *http.Server
+// WithEventRecorder emits Kubernetes Events on the target Deployment for scale actions
+func WithEventRecorder(recorder record.EventRecorder) Option {
+	return func(o *options) {
+		o.eventRecorder = recorder
+	}
+}
+
+// WithChangePolicies enforces per-namespace reason and ticket requirements on scale requests
+func WithChangePolicies(policies *policy.ChangePolicies) Option {
+	return func(o *options) {
+		o.changePolicies = policies
+	}
+}
+
+// WithPriceTable serves cost estimates at the table's prices, and adds the
+// estimated change in cost to scale responses
+func WithPriceTable(prices *cost.PriceTable) Option {
+	return func(o *options) {
+		o.priceTable = prices
+	}
+}
+
+// WithReadinessCheck adds a check to /readyz alongside the built-in informer and TLS checks
+func WithReadinessCheck(check health.Check) Option {
+	return func(o *options) {
+		o.readinessChecks = append(o.readinessChecks, check)
+	}
+}
+
+// WithHPAInformer detects the HorizontalPodAutoscalers scaling deployments from
+// the informer's cache, reporting them and refusing the scales they would undo.
+// Configured clusters then watch their HPAs too.
+func WithHPAInformer(informer autoscalinginformers.HorizontalPodAutoscalerInformer) Option {
+	return func(o *options) {
+		o.hpaInformer = informer
+	}
+}
+
+// WithPDBInformer checks scale-downs against the PodDisruptionBudgets in the
+// informer's cache, refusing those that would violate one unless forced.
+// Configured clusters then watch their PDBs too.
+func WithPDBInformer(informer policyinformers.PodDisruptionBudgetInformer) Option {
+	return func(o *options) {
+		o.pdbInformer = informer
+	}
+}
+
+// WithQuotaInformer checks scale-ups against the ResourceQuotas in the
+// informer's cache, refusing or warning of those exceeding one as
+// scale.quotaCheck says. Configured clusters then watch their quotas too.
+func WithQuotaInformer(informer coreinformers.ResourceQuotaInformer) Option {
+	return func(o *options) {
+		o.quotaInformer = informer
+	}
+}
+
+// WithClusterConnector replaces the kubeconfig loading that builds the clients
+// of configured clusters, for tests
+func WithClusterConnector(connect cluster.Connector) Option {
+	return func(o *options) {
+		o.connectCluster = connect
+	}
+}
+
+// New creates and returns a new Server instance listening as cfg describes,
+// serving deployments from the informer's cache and scaling them through clientset
+func New(clientset kubernetes.Interface, deploymentInformer appsinformers.DeploymentInformer, cfg *config.Config, opts ...Option) (*Server, error) {
+	o := options{
+		connectCluster: func(c config.ClusterConfig) (kubernetes.Interface, error) {
+			return k8s.NewClientsetForKubeconfig(c.Kubeconfig, c.Context)
+		},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	protection, err := policy.NewProtection(cfg.Scale.ProtectedNamespaces, cfg.Scale.ProtectedSelectors)
+	if err != nil {
+		return nil, err
+	}
+	o.protection = protection
+
+	// Rate limits apply to routes across every cluster, so only the local
+	// cluster's handlers hold them. The scaler's own deployment is in the local
+	// cluster.
+	localOpts := append(clusterHandlerOptions(cfg, o),
+		handlers.WithEventRecorder(o.eventRecorder),
+		handlers.WithRateLimits(cfg.RateLimits),
+		handlers.WithProtection(protection.WithSelf(cfg.Self.Namespace, cfg.Self.Deployment)),
+	)
+	if o.hpaInformer != nil {
+		localOpts = append(localOpts, handlers.WithHorizontalPodAutoscalers(o.hpaInformer.Lister()))
+	}
+	if o.pdbInformer != nil {
+		localOpts = append(localOpts, handlers.WithPodDisruptionBudgets(o.pdbInformer.Lister()))
+	}
+	if o.quotaInformer != nil {
+		localOpts = append(localOpts, handlers.WithResourceQuotas(o.quotaInformer.Lister(), cfg.Scale.QuotaCheck == config.QuotaCheckWarn))
+	}
+	h, err := handlers.New(clientset, deploymentInformer, localOpts...)
+	if err != nil {
+		return nil, err
+	}
 
-	if enableTLS {
-		tlsConfig, err := setupTLSConfig()
+	var tlsConfig *tls.Config
+	if cfg.TLS.Enabled {
+		tlsConfig, err = setupTLSConfig(cfg.TLS)
 		if err != nil {
 			return nil, fmt.Errorf("failed to set up TLS config: %v", err)
 		}
-		srv = &http.Server{
-			Addr:      ":8443",
-			TLSConfig: tlsConfig,
-			ErrorLog:  log.New(&customLogger{logger: log.Default()}, "", 0),
-			Handler:   handler,
+	}
+
+	// Readiness requires a synced, live informer cache and loaded TLS material
+	activity, err := health.InformerActivity("informer-watch", deploymentInformer.Informer(), cfg.Health.InformerMaxWatchAge.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to track informer activity: %v", err)
+	}
+	readinessChecks := []health.Check{
+		health.Ping,
+		health.InformerSynced("informer-sync", deploymentInformer.Informer()),
+		activity,
+	}
+	if o.hpaInformer != nil {
+		readinessChecks = append(readinessChecks, health.InformerSynced("hpa-informer-sync", o.hpaInformer.Informer()))
+	}
+	if o.pdbInformer != nil {
+		readinessChecks = append(readinessChecks, health.InformerSynced("pdb-informer-sync", o.pdbInformer.Informer()))
+	}
+	if o.quotaInformer != nil {
+		readinessChecks = append(readinessChecks, health.InformerSynced("quota-informer-sync", o.quotaInformer.Informer()))
+	}
+	if cfg.TLS.Enabled {
+		readinessChecks = append(readinessChecks, health.TLSCertificates("tls", tlsConfig))
+	}
+	readinessChecks = append(readinessChecks, o.readinessChecks...)
+
+	s := &Server{livez: health.Handler("livez", health.Ping)}
+	s.Clusters = cluster.NewRegistry(&cluster.Cluster{
+		Name:        cfg.ClusterName,
+		Clientset:   clientset,
+		Deployments: deploymentInformer,
+		HPAs:        o.hpaInformer,
+		PDBs:        o.pdbInformer,
+		Quotas:      o.quotaInformer,
+		Handlers:    h,
+	}, cluster.Options{
+		Connect:      o.connectCluster,
+		Setup:        clusterSetup(cfg, o),
+		ResyncPeriod: cfg.Informer.ResyncPeriod.Duration,
+		Namespaces:   cfg.Namespaces,
+		WatchHPAs:    o.hpaInformer != nil,
+		WatchPDBs:    o.pdbInformer != nil,
+		WatchQuotas:  o.quotaInformer != nil,
+		Health:       cfg.Health,
+	})
+	// Each configured cluster adds its own checks
+	s.readyz = health.DynamicHandler("readyz", func() []health.Check {
+		return slices.Concat(readinessChecks, s.Clusters.Checks())
+	})
+	var errorLog *log.Logger
+	errorLog, s.tlsErrors = newServerErrorLog(o.metrics)
+	s.handshakeTimeout = cfg.Timeouts.TLSHandshake.Duration
+	s.Server = &http.Server{
+		Addr:      cfg.ListenAddr,
+		TLSConfig: tlsConfig,
+		ErrorLog:  errorLog,
+	}
+	s.Handler, err = setupHandlers(h, s, cfg, o)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.GRPC.Addr != "" {
+		s.GRPC = setupGRPC(h, tlsConfig, o)
+	}
+	return s, nil
+}
+
+// clusterHandlerOptions returns the handler options shared by every cluster
+func clusterHandlerOptions(cfg *config.Config, o options) []handlers.Option {
+	return []handlers.Option{
+		handlers.WithChangePolicies(o.changePolicies),
+		handlers.WithMetrics(o.metrics),
+		handlers.WithUpdateScaleTimeout(cfg.Timeouts.UpdateScale.Duration),
+		handlers.WithScaleCooldown(cfg.Scale.Cooldown.Duration, cfg.Scale.ForceIdentities),
+		handlers.WithForceIdentities(cfg.Scale.PDBForceIdentities),
+		handlers.WithNamespaces(cfg.Namespaces),
+		handlers.WithProtection(o.protection),
+		handlers.WithPriceTable(o.priceTable),
+	}
+}
+
+// clusterSetup builds the handlers of a configured cluster. When Events are
+// emitted, each cluster records them through its own client.
+func clusterSetup(cfg *config.Config, o options) cluster.Setup {
+	return func(c *cluster.Cluster) (*handlers.Handlers, func(), error) {
+		opts := clusterHandlerOptions(cfg, o)
+		if c.HPAs != nil {
+			opts = append(opts, handlers.WithHorizontalPodAutoscalers(c.HPAs.Lister()))
+		}
+		if c.PDBs != nil {
+			opts = append(opts, handlers.WithPodDisruptionBudgets(c.PDBs.Lister()))
 		}
-	} else {
-		srv = &http.Server{
-			Addr:     ":8443",
-			ErrorLog: log.New(&customLogger{logger: log.Default()}, "", 0),
-			Handler:  handler,
+		if c.Quotas != nil {
+			opts = append(opts, handlers.WithResourceQuotas(c.Quotas.Lister(), cfg.Scale.QuotaCheck == config.QuotaCheckWarn))
 		}
+		release := func() {}
+		if o.eventRecorder != nil {
+			var recorder record.EventRecorder
+			recorder, release = k8s.NewEventRecorder(c.Clientset)
+			opts = append(opts, handlers.WithEventRecorder(recorder))
+		}
+		h, err := handlers.New(c.Clientset, c.Deployments, opts...)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		return h, release, nil
+	}
+}
+
+// setupGRPC returns a gRPC server for the ScalerService, with interceptors
+// matching the REST API's middleware
+func setupGRPC(h *handlers.Handlers, tlsConfig *tls.Config, o options) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{middleware.UnaryRequestID, middleware.UnaryLogging}
+	stream := []grpc.StreamServerInterceptor{middleware.StreamRequestID, middleware.StreamLogging}
+	if o.auditLogger != nil {
+		unary = append(unary, o.auditLogger.UnaryInterceptor)
+		stream = append(stream, o.auditLogger.StreamInterceptor)
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 
-	return &Server{Server: srv}, nil
+	s := grpc.NewServer(serverOpts...)
+	scalerv1.RegisterScalerServiceServer(s, h.GRPCService())
+	return s
 }
 
 // setupTLSConfig loads certificates and sets up TLS configuration.
-func setupTLSConfig() (*tls.Config, error) {
-	serverCert, err := tls.LoadX509KeyPair("certs/server-cert.pem", "certs/server-key.pem")
+func setupTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	serverCert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
 	if err != nil {
 		return nil, fmt.Errorf("loading server certificate: %v", err)
 	}
 
-	caCert, err := os.ReadFile("certs/ca-cert.pem")
+	caCert, err := os.ReadFile(cfg.CAFile)
 	if err != nil {
 		return nil, fmt.Errorf("loading CA certificate: %v", err)
 	}
@@ -76,6 +392,7 @@ func setupTLSConfig() (*tls.Config, error) {
 		Certificates: []tls.Certificate{serverCert},
 		ClientCAs:    caCertPool,
 		ClientAuth:   tls.RequireAndVerifyClientCert,
+		NextProtos:   []string{"h2", "http/1.1"},
 		MinVersion:   tls.VersionTLS13,
 		MaxVersion:   tls.VersionTLS13,
 		CipherSuites: []uint16{
@@ -87,15 +404,133 @@ func setupTLSConfig() (*tls.Config, error) {
 }
 
 // setupHandlers configures and returns the HTTP request multiplexer
-func setupHandlers(deploymentLister appslisters.DeploymentLister) http.Handler {
+func setupHandlers(h *handlers.Handlers, s *Server, cfg *config.Config, o options) (http.Handler, error) {
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /healthz", middleware.JSONContentType(http.HandlerFunc(handlers.HealthCheck)).ServeHTTP)
-	mux.HandleFunc("GET /replica-count", middleware.JSONContentType(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handlers.GetReplicaCount(w, r, deploymentLister)
-	})).ServeHTTP)
-	mux.HandleFunc("POST /replica-count", middleware.JSONContentType(http.HandlerFunc(handlers.PostReplicaCount)).ServeHTTP)
-	mux.HandleFunc("GET /deployments", middleware.JSONContentType(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		handlers.ListDeployments(w, r, deploymentLister)
-	})).ServeHTTP)
-	return mux
+	mux.Handle("GET /livez", s.livez)
+	mux.Handle("GET /readyz", s.readyz)
+
+	// API routes are subject to any rate limits configured for their pattern
+	routes := make(map[string]bool)
+	handle := func(pattern string, handler http.HandlerFunc) {
+		routes[pattern] = true
+		mux.HandleFunc(pattern, middleware.JSONContentType(h.RateLimited(pattern, handler)).ServeHTTP)
+	}
+	handle("GET /healthz", h.HealthCheck)
+	handle("GET /api/v1/openapi.json", handlers.OpenAPI)
+	handle("GET /api/v1/deployments", h.GetDeploymentList)
+	handle("GET /api/v1/namespaces/{namespace}/deployments", h.GetDeploymentList)
+	handle("GET /api/v1/namespaces/{namespace}/deployments/{name}/scale", h.GetScale)
+	handle("PUT /api/v1/namespaces/{namespace}/deployments/{name}/scale", h.PutScale)
+	handle("POST /api/v1/namespaces/{namespace}/deployments/{name}/hpa/restore", h.RestoreHPA)
+	handle("GET /api/v1/namespaces/{namespace}/deployments/{name}/history", h.GetScaleHistory)
+	handle("GET /api/v1/namespaces/{namespace}/deployments/{name}/cost", h.GetDeploymentCost)
+	handle("GET /api/v1/namespaces/{namespace}/cost", h.GetNamespaceCost)
+
+	// The same routes serve each cluster under /clusters/{cluster}
+	handle("GET /clusters", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListClusters(w, r, s.Clusters.Summary())
+	})
+	forCluster := func(pattern string, handler func(*handlers.Handlers, http.ResponseWriter, *http.Request)) {
+		handle(pattern, handlers.ForCluster(s.Clusters.Handlers, handler))
+	}
+	forCluster("GET /clusters/{cluster}/healthz", (*handlers.Handlers).HealthCheck)
+	forCluster("GET /clusters/{cluster}/api/v1/deployments", (*handlers.Handlers).GetDeploymentList)
+	forCluster("GET /clusters/{cluster}/api/v1/namespaces/{namespace}/deployments", (*handlers.Handlers).GetDeploymentList)
+	forCluster("GET /clusters/{cluster}/api/v1/namespaces/{namespace}/deployments/{name}/scale", (*handlers.Handlers).GetScale)
+	forCluster("PUT /clusters/{cluster}/api/v1/namespaces/{namespace}/deployments/{name}/scale", (*handlers.Handlers).PutScale)
+	forCluster("POST /clusters/{cluster}/api/v1/namespaces/{namespace}/deployments/{name}/hpa/restore", (*handlers.Handlers).RestoreHPA)
+	forCluster("GET /clusters/{cluster}/api/v1/namespaces/{namespace}/deployments/{name}/history", (*handlers.Handlers).GetScaleHistory)
+	forCluster("GET /clusters/{cluster}/api/v1/namespaces/{namespace}/deployments/{name}/cost", (*handlers.Handlers).GetDeploymentCost)
+	forCluster("GET /clusters/{cluster}/api/v1/namespaces/{namespace}/cost", (*handlers.Handlers).GetNamespaceCost)
+
+	// The /global routes span every cluster
+	global := handlers.NewGlobal(s.Clusters.Names, s.Clusters.Handlers)
+	handle("GET /global/deployments", global.ListDeployments)
+	handle("PUT /global/namespaces/{namespace}/deployments/{name}/scale", global.PutScale)
+
+	// The routes predating /api/v1 remain as deprecated aliases
+	deprecated := func(pattern string, handler http.HandlerFunc, successor func(*http.Request) string) {
+		handle(pattern, middleware.Deprecated(successor, handler).ServeHTTP)
+	}
+	deprecated("GET /replica-count", h.GetReplicaCount, scaleSuccessor)
+	deprecated("POST /replica-count", h.PostReplicaCount, scaleSuccessor)
+	deprecated("GET /deployments", h.ListDeployments, func(r *http.Request) string {
+		if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+			return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/deployments"
+		}
+		return "/api/v1/deployments"
+	})
+	deprecated("GET /deployments/{namespace}/{name}/history", h.GetScaleHistory, func(r *http.Request) string {
+		return v1DeploymentPath(r.PathValue("namespace"), r.PathValue("name")) + "/history"
+	})
+	for pattern := range cfg.RateLimits {
+		if !routes[pattern] {
+			return nil, fmt.Errorf("rate limit configured for unknown route %q", pattern)
+		}
+	}
+
+	// The debug routes expose the configuration and change the log level, so
+	// only admin identities may use them
+	debug := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, middleware.JSONContentType(handlers.AdminOnly(cfg.AdminIdentities, handler)).ServeHTTP)
+	}
+	debug("GET /debug/config", func(w http.ResponseWriter, r *http.Request) {
+		handlers.EffectiveConfig(w, r, cfg)
+	})
+
+	if o.logLevel != nil {
+		levelHandler := func(w http.ResponseWriter, r *http.Request) {
+			handlers.LogLevel(w, r, o.logLevel)
+		}
+		debug("GET /debug/loglevel", levelHandler)
+		debug("PUT /debug/loglevel", levelHandler)
+	}
+
+	var handler http.Handler = mux
+	if o.auditLogger != nil {
+		handler = o.auditLogger.Middleware(handler)
+	}
+	handler = o.metrics.Middleware(mux, handler)
+	handler = middleware.Logging(handler)
+	handler = tracing.Middleware(mux, handler)
+	return middleware.RequestID(handler), nil
+}
+
+// scaleSuccessor returns the /api/v1 scale path replacing a /replica-count request
+func scaleSuccessor(r *http.Request) string {
+	query := r.URL.Query()
+	namespace, name := query.Get("namespace"), query.Get("deployment")
+	if namespace == "" || name == "" {
+		return ""
+	}
+	return v1DeploymentPath(namespace, name) + "/scale"
+}
+
+// v1DeploymentPath returns the /api/v1 path of a deployment
+func v1DeploymentPath(namespace, name string) string {
+	return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/deployments/" + url.PathEscape(name)
+}
+
+// NewMetricsServer returns a server exposing /metrics on addr, along with api's
+// /livez and /readyz when api is not nil. When tlsConfig is nil it serves plain
+// HTTP so scrapers and kubelet probes without client certificates can reach it;
+// otherwise clients have handshakeTimeout to complete the TLS handshake.
+func NewMetricsServer(addr string, m *metrics.Metrics, tlsConfig *tls.Config, handshakeTimeout time.Duration, api *Server) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+	if api != nil {
+		mux.Handle("GET /livez", api.livez)
+		mux.Handle("GET /readyz", api.readyz)
+	}
+	errorLog, tlsErrors := newServerErrorLog(m)
+	return &Server{
+		Server: &http.Server{
+			Addr:      addr,
+			TLSConfig: tlsConfig,
+			ErrorLog:  errorLog,
+			Handler:   mux,
+		},
+		tlsErrors:        tlsErrors,
+		handshakeTimeout: handshakeTimeout,
+	}
 }
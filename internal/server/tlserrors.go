@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"k8s-deployment-scaler/internal/metrics"
+)
+
+// tlsHandshakeErrorPrefix starts the message net/http logs for a failed handshake
+const tlsHandshakeErrorPrefix = "http: TLS handshake error from "
+
+// TLS handshake failure reasons
+const (
+	tlsReasonEOF              = "eof"
+	tlsReasonConnectionReset  = "connection_reset"
+	tlsReasonTimeout          = "timeout"
+	tlsReasonNotTLS           = "not_tls"
+	tlsReasonNoCertificate    = "no_client_certificate"
+	tlsReasonBadCertificate   = "bad_certificate"
+	tlsReasonUnknownAuthority = "unknown_authority"
+	tlsReasonExpiredCert      = "expired_certificate"
+	tlsReasonProtocolVersion  = "protocol_version"
+	tlsReasonHandshakeFailure = "handshake_failure"
+	tlsReasonOther            = "other"
+)
+
+// tlsAlertReasons maps the TLS alerts a peer sends (RFC 8446 section 6) to reasons
+var tlsAlertReasons = map[tls.AlertError]string{
+	40:  tlsReasonHandshakeFailure,
+	42:  tlsReasonBadCertificate,
+	45:  tlsReasonExpiredCert,
+	46:  tlsReasonBadCertificate,
+	48:  tlsReasonUnknownAuthority,
+	70:  tlsReasonProtocolVersion,
+	116: tlsReasonNoCertificate,
+}
+
+// classifyTLSError returns the reason a TLS handshake failed with err. Errors
+// crypto/tls only reports as text, such as a missing client certificate, fall
+// back to tlsErrorClasses.
+func classifyTLSError(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var recordHeader tls.RecordHeaderError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return tlsReasonUnknownAuthority
+	case errors.As(err, &invalid):
+		if invalid.Reason == x509.Expired {
+			return tlsReasonExpiredCert
+		}
+		return tlsReasonBadCertificate
+	case errors.As(err, &recordHeader):
+		return tlsReasonNotTLS
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return tlsReasonEOF
+	case errors.Is(err, syscall.ECONNRESET):
+		return tlsReasonConnectionReset
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return tlsReasonTimeout
+	}
+	if alert, ok := alertOf(err); ok {
+		if reason, ok := tlsAlertReasons[alert]; ok {
+			return reason
+		}
+		return tlsReasonOther
+	}
+	return classifyTLSMessage(err.Error())
+}
+
+// alertOf returns the TLS alert in err. crypto/tls wraps alerts received over
+// TCP in a *net.OpError with its own unexported alert type rather than a
+// tls.AlertError, so both are recognised.
+func alertOf(err error) (tls.AlertError, bool) {
+	var alert tls.AlertError
+	if errors.As(err, &alert) {
+		return alert, true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "remote error" && opErr.Err != nil {
+		if v := reflect.ValueOf(opErr.Err); v.Kind() == reflect.Uint8 {
+			return tls.AlertError(v.Uint()), true
+		}
+	}
+	return 0, false
+}
+
+// tlsErrorClasses maps substrings of crypto/tls and net errors to reasons, most
+// specific first, for errors that are only available as text
+var tlsErrorClasses = []struct {
+	substr string
+	reason string
+}{
+	{"client didn't provide a certificate", tlsReasonNoCertificate},
+	{"certificate required", tlsReasonNoCertificate},
+	{"certificate has expired", tlsReasonExpiredCert},
+	{"certificate signed by unknown authority", tlsReasonUnknownAuthority},
+	{"unknown certificate authority", tlsReasonUnknownAuthority},
+	{"bad certificate", tlsReasonBadCertificate},
+	{"failed to verify certificate", tlsReasonBadCertificate},
+	{"protocol version", tlsReasonProtocolVersion},
+	{"unsupported versions", tlsReasonProtocolVersion},
+	{"does not look like a TLS handshake", tlsReasonNotTLS},
+	{"no cipher suite supported", tlsReasonHandshakeFailure},
+	{"handshake failure", tlsReasonHandshakeFailure},
+	{"i/o timeout", tlsReasonTimeout},
+	{"connection reset by peer", tlsReasonConnectionReset},
+	{"unexpected EOF", tlsReasonEOF},
+}
+
+// classifyTLSMessage returns the reason for a handshake error message
+func classifyTLSMessage(message string) string {
+	if message == io.EOF.Error() {
+		return tlsReasonEOF
+	}
+	for _, class := range tlsErrorClasses {
+		if strings.Contains(message, class.substr) {
+			return class.reason
+		}
+	}
+	return tlsReasonOther
+}
+
+// benignTLSReasons are failures caused by clients or probes closing the connection
+// mid-handshake, which are routine and logged at debug level only
+var benignTLSReasons = map[string]bool{
+	tlsReasonEOF:             true,
+	tlsReasonConnectionReset: true,
+}
+
+// tlsHandshakeError is a parsed net/http TLS handshake failure
+type tlsHandshakeError struct {
+	remoteAddr string
+	message    string
+	reason     string
+}
+
+// parseTLSHandshakeError reports whether the server log line is a TLS handshake
+// failure and, if so, classifies its cause. It is the fallback for handshakes
+// net/http performs itself; those on a handshakeListener are classified by
+// their error value.
+func parseTLSHandshakeError(line string) (tlsHandshakeError, bool) {
+	i := strings.Index(line, tlsHandshakeErrorPrefix)
+	if i < 0 {
+		return tlsHandshakeError{}, false
+	}
+	rest := strings.TrimSpace(line[i+len(tlsHandshakeErrorPrefix):])
+
+	parsed := tlsHandshakeError{message: rest}
+	if remoteAddr, message, found := strings.Cut(rest, ": "); found {
+		parsed.remoteAddr, parsed.message = remoteAddr, message
+	}
+	parsed.reason = classifyTLSMessage(parsed.message)
+	return parsed, true
+}
+
+// errorLogWriter turns net/http server error log lines into structured logs,
+// counting and classifying TLS handshake failures
+type errorLogWriter struct {
+	metrics *metrics.Metrics
+}
+
+func (l *errorLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+
+	if handshake, ok := parseTLSHandshakeError(line); ok {
+		l.record(handshake)
+		return len(p), nil
+	}
+
+	slog.Error("HTTP server error", "error", line)
+	return len(p), nil
+}
+
+// handshakeFailed counts and logs a failed handshake from the error crypto/tls returned
+func (l *errorLogWriter) handshakeFailed(remoteAddr net.Addr, err error) {
+	handshake := tlsHandshakeError{message: err.Error(), reason: classifyTLSError(err)}
+	if remoteAddr != nil {
+		handshake.remoteAddr = remoteAddr.String()
+	}
+	l.record(handshake)
+}
+
+func (l *errorLogWriter) record(handshake tlsHandshakeError) {
+	if l != nil {
+		l.metrics.TLSHandshakeFailed(handshake.reason)
+	}
+	level := slog.LevelWarn
+	if benignTLSReasons[handshake.reason] {
+		level = slog.LevelDebug
+	}
+	slog.Log(context.Background(), level, "TLS handshake failed",
+		"reason", handshake.reason,
+		"remote_addr", handshake.remoteAddr,
+		"error", handshake.message,
+	)
+}
+
+// newServerErrorLog returns the ErrorLog for an http.Server, along with the
+// writer behind it for reporting handshake failures directly
+func newServerErrorLog(m *metrics.Metrics) (*log.Logger, *errorLogWriter) {
+	w := &errorLogWriter{metrics: m}
+	return log.New(w, "", 0), w
+}
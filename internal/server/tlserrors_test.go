@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseTLSHandshakeError(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantReason string
+		wantOK     bool
+	}{
+		{
+			name:       "Client closed connection",
+			line:       "http: TLS handshake error from 10.0.0.1:51234: EOF\n",
+			wantReason: tlsReasonEOF,
+			wantOK:     true,
+		},
+		{
+			name:       "Missing client certificate",
+			line:       "http: TLS handshake error from 10.0.0.1:51234: tls: client didn't provide a certificate\n",
+			wantReason: tlsReasonNoCertificate,
+			wantOK:     true,
+		},
+		{
+			name:       "Untrusted client certificate",
+			line:       "http: TLS handshake error from 10.0.0.1:51234: tls: failed to verify certificate: x509: certificate signed by unknown authority\n",
+			wantReason: tlsReasonUnknownAuthority,
+			wantOK:     true,
+		},
+		{
+			name:       "Old protocol version",
+			line:       "http: TLS handshake error from 10.0.0.1:51234: tls: client offered only unsupported versions: [303 302 301]\n",
+			wantReason: tlsReasonProtocolVersion,
+			wantOK:     true,
+		},
+		{
+			name:       "Plain HTTP to TLS port",
+			line:       "http: TLS handshake error from 10.0.0.1:51234: tls: first record does not look like a TLS handshake\n",
+			wantReason: tlsReasonNotTLS,
+			wantOK:     true,
+		},
+		{
+			name:       "Unclassified failure",
+			line:       "http: TLS handshake error from 10.0.0.1:51234: remote error: tls: internal error\n",
+			wantReason: tlsReasonOther,
+			wantOK:     true,
+		},
+		{
+			name:   "Unrelated server error",
+			line:   "http: superfluous response.WriteHeader call\n",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, ok := parseTLSHandshakeError(tt.line)
+			if ok != tt.wantOK || parsed.reason != tt.wantReason {
+				t.Errorf("parseTLSHandshakeError() = (%q, %v), want (%q, %v)", parsed.reason, ok, tt.wantReason, tt.wantOK)
+			}
+			if ok && parsed.remoteAddr != "10.0.0.1:51234" {
+				t.Errorf("parseTLSHandshakeError() remoteAddr = %q, want 10.0.0.1:51234", parsed.remoteAddr)
+			}
+		})
+	}
+}
+
+func TestClassifyTLSError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+	}{
+		{
+			name:       "Unknown authority",
+			err:        &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}},
+			wantReason: tlsReasonUnknownAuthority,
+		},
+		{
+			name:       "Expired certificate",
+			err:        &tls.CertificateVerificationError{Err: x509.CertificateInvalidError{Reason: x509.Expired}},
+			wantReason: tlsReasonExpiredCert,
+		},
+		{
+			name:       "Invalid certificate",
+			err:        &tls.CertificateVerificationError{Err: x509.CertificateInvalidError{Reason: x509.IncompatibleUsage}},
+			wantReason: tlsReasonBadCertificate,
+		},
+		{
+			name:       "Alert from peer",
+			err:        fmt.Errorf("handshake: %w", tls.AlertError(48)),
+			wantReason: tlsReasonUnknownAuthority,
+		},
+		{
+			name:       "Unmapped alert",
+			err:        tls.AlertError(80),
+			wantReason: tlsReasonOther,
+		},
+		{
+			name:       "Not TLS",
+			err:        tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"},
+			wantReason: tlsReasonNotTLS,
+		},
+		{
+			name:       "Client closed connection",
+			err:        io.EOF,
+			wantReason: tlsReasonEOF,
+		},
+		{
+			name:       "Connection reset",
+			err:        &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+			wantReason: tlsReasonConnectionReset,
+		},
+		{
+			name:       "Handshake timeout",
+			err:        context.DeadlineExceeded,
+			wantReason: tlsReasonTimeout,
+		},
+		{
+			name:       "Missing client certificate",
+			err:        errors.New("tls: client didn't provide a certificate"),
+			wantReason: tlsReasonNoCertificate,
+		},
+		{
+			name:       "Message containing EOF",
+			err:        errors.New("tls: peer sent EOF-like garbage"),
+			wantReason: tlsReasonOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyTLSError(tt.err); got != tt.wantReason {
+				t.Errorf("classifyTLSError(%v) = %q, want %q", tt.err, got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestHandshakeListenerClassifiesFailures(t *testing.T) {
+	ca, caKey := newTestCA(t, "test-ca")
+	otherCA, otherKey := newTestCA(t, "other-ca")
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	now := time.Now()
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{issueTestCert(t, ca, caKey, "localhost", x509.ExtKeyUsageServerAuth, now.Add(time.Hour))},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}
+
+	failures := make(chan string, 1)
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := newHandshakeListener(inner, serverConfig, time.Second, func(_ net.Addr, err error) {
+		failures <- classifyTLSError(err)
+	})
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	addr := inner.Addr().String()
+
+	// The client presents its certificate even when the server does not list its issuer
+	clientConfig := func(certs ...tls.Certificate) *tls.Config {
+		return &tls.Config{
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				if len(certs) == 0 {
+					return &tls.Certificate{}, nil
+				}
+				return &certs[0], nil
+			},
+			RootCAs:    pool,
+			ServerName: "localhost",
+			MinVersion: tls.VersionTLS13,
+		}
+	}
+	handshake := func(config *tls.Config) func(net.Conn) {
+		return func(conn net.Conn) {
+			client := tls.Client(conn, config)
+			if err := client.Handshake(); err == nil {
+				// With TLS 1.3 the server verifies the client certificate after
+				// the client's handshake completes, and alerts on the next read
+				client.Read(make([]byte, 1))
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		client     func(net.Conn)
+		wantReason string
+	}{
+		{
+			name:       "Client closed connection",
+			client:     func(net.Conn) {},
+			wantReason: tlsReasonEOF,
+		},
+		{
+			name: "Plain HTTP to TLS port",
+			client: func(conn net.Conn) {
+				io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+				conn.Read(make([]byte, 1))
+			},
+			wantReason: tlsReasonNotTLS,
+		},
+		{
+			name:       "Missing client certificate",
+			client:     handshake(clientConfig()),
+			wantReason: tlsReasonNoCertificate,
+		},
+		{
+			name:       "Untrusted client certificate",
+			client:     handshake(clientConfig(issueTestCert(t, otherCA, otherKey, "client", x509.ExtKeyUsageClientAuth, now.Add(time.Hour)))),
+			wantReason: tlsReasonUnknownAuthority,
+		},
+		{
+			name:       "Expired client certificate",
+			client:     handshake(clientConfig(issueTestCert(t, ca, caKey, "client", x509.ExtKeyUsageClientAuth, now.Add(-time.Minute)))),
+			wantReason: tlsReasonExpiredCert,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.client(conn)
+			conn.Close()
+
+			select {
+			case reason := <-failures:
+				if reason != tt.wantReason {
+					t.Errorf("handshake failure reason = %q, want %q", reason, tt.wantReason)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("handshake failure not reported")
+			}
+		})
+	}
+
+	// A trusted client gets through to Accept
+	conn, err := tls.Dial("tcp", addr, clientConfig(issueTestCert(t, ca, caKey, "client", x509.ExtKeyUsageClientAuth, now.Add(time.Hour))))
+	if err != nil {
+		t.Fatalf("tls.Dial() error = %v", err)
+	}
+	conn.Read(make([]byte, 1))
+	conn.Close()
+	select {
+	case reason := <-failures:
+		t.Errorf("trusted client failed handshake: %s", reason)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// newTestCA returns a self-signed CA certificate and its key
+func newTestCA(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, key
+}
+
+// issueTestCert returns a certificate for name signed by ca, valid until notAfter
+func issueTestCert(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, name string, usage x509.ExtKeyUsage, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s-deployment-scaler/internal/logging"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceName identifies the scaler's spans unless OTEL_SERVICE_NAME overrides it
+const serviceName = "k8s-deployment-scaler"
+
+// Exporter names accepted by Setup
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Tracer returns the tracer used for the scaler's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(serviceName)
+}
+
+// Setup installs W3C trace context propagation and, unless exporter is "none" or
+// empty, a global tracer provider sending spans to the named exporter. The OTLP
+// exporter is configured by the standard OTEL_EXPORTER_OTLP_* variables; the stdout
+// exporter writes one JSON document per span to w. The returned function flushes
+// pending spans and stops the provider.
+func Setup(ctx context.Context, exporter string, w io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %v", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating trace resource: %v", err)
+	}
+
+	// The sampler defaults to parent-based always-on and honours OTEL_TRACES_SAMPLER
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Middleware starts a server span for each request, continuing any trace context
+// the caller sent in the traceparent header. Spans are named after the matched mux
+// pattern, e.g. "GET /replica-count", so names stay bounded.
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	annotate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if _, pattern := mux.Handler(r); pattern != "" {
+			span.SetAttributes(semconv.HTTPRoute(pattern))
+		}
+		if requestID := logging.RequestID(r.Context()); requestID != "" {
+			span.SetAttributes(attribute.String("request.id", requestID))
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	return otelhttp.NewHandler(annotate, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return spanName(mux, r)
+		}),
+	)
+}
+
+// spanName returns the mux pattern matching the request, prefixed with the method
+func spanName(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if pattern == "" {
+		return r.Method + " unmatched"
+	}
+	if !strings.Contains(pattern, " ") {
+		pattern = r.Method + " " + pattern
+	}
+	return pattern
+}
+
+// WrapTransport instruments a client-go transport, for use as rest.Config.WrapTransport.
+// Only calls made within a traced request get a client span and a traceparent header,
+// so the informers' long-running list and watch calls don't each start a trace.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt,
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return trace.SpanContextFromContext(r.Context()).IsValid()
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "kubernetes " + r.Method
+		}),
+	)
+}
+
+// Start starts a child span of the span in ctx with the given attributes
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
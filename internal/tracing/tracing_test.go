@@ -0,0 +1,154 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s-deployment-scaler/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// useRecorder installs a tracer provider recording spans in memory for the test
+func useRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := useRecorder(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /deployments/{namespace}/{name}/history", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "child")
+		span.End()
+	})
+	handler := Middleware(mux, mux)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/deployments/default/web/history", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req = req.WithContext(logging.WithRequestID(req.Context(), "req-1"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	child, server := spans[0], spans[1]
+
+	if server.Name != "GET /deployments/{namespace}/{name}/history" {
+		t.Errorf("server span name = %q", server.Name)
+	}
+	if got := server.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("server span trace ID = %s, want the caller's %s", got, traceID)
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Error("child span is not parented to the server span")
+	}
+
+	attrs := make(map[attribute.Key]string)
+	for _, attr := range server.Attributes {
+		attrs[attr.Key] = attr.Value.Emit()
+	}
+	if attrs["http.route"] != "GET /deployments/{namespace}/{name}/history" {
+		t.Errorf("http.route = %q", attrs["http.route"])
+	}
+	if attrs["request.id"] != "req-1" {
+		t.Errorf("request.id = %q, want req-1", attrs["request.id"])
+	}
+}
+
+func TestMiddlewareUnmatched(t *testing.T) {
+	exporter := useRecorder(t)
+
+	mux := http.NewServeMux()
+	Middleware(mux, mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "GET unmatched" {
+		t.Errorf("unexpected spans for an unmatched route: %v", spans)
+	}
+}
+
+func TestWrapTransport(t *testing.T) {
+	exporter := useRecorder(t)
+
+	var traceparent string
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer apiServer.Close()
+
+	client := &http.Client{Transport: WrapTransport(http.DefaultTransport)}
+
+	// Calls outside a traced request, like informer watches, are left alone
+	resp, err := client.Get(apiServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if traceparent != "" || len(exporter.GetSpans()) != 0 {
+		t.Errorf("untraced call was instrumented: traceparent %q, %d spans", traceparent, len(exporter.GetSpans()))
+	}
+
+	// Calls within a request span get a client span and propagate its context
+	ctx, span := Start(context.Background(), "request")
+	req, _ := http.NewRequestWithContext(ctx, "GET", apiServer.URL, nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	if !strings.Contains(traceparent, span.SpanContext().TraceID().String()) {
+		t.Errorf("traceparent %q does not carry trace %s", traceparent, span.SpanContext().TraceID())
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[0].Name != "kubernetes GET" {
+		t.Errorf("unexpected spans for a traced call: %v", spans)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", nil); err == nil {
+		t.Error("Setup accepted an unknown exporter")
+	}
+
+	shutdown, err := Setup(context.Background(), ExporterNone, nil)
+	if err != nil {
+		t.Fatalf("Setup(none) error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	shutdown, err = Setup(context.Background(), ExporterStdout, &buf)
+	if err != nil {
+		t.Fatalf("Setup(stdout) error = %v", err)
+	}
+	_, span := Start(context.Background(), "test-span")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"Name":"test-span"`) {
+		t.Errorf("stdout exporter output missing span: %s", buf.String())
+	}
+}
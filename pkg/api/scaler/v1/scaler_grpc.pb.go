@@ -0,0 +1,314 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: scaler/v1/scaler.proto
+
+package scalerv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	ScalerService_GetScale_FullMethodName         = "/scaler.v1.ScalerService/GetScale"
+	ScalerService_SetScale_FullMethodName         = "/scaler.v1.ScalerService/SetScale"
+	ScalerService_RestoreHPA_FullMethodName       = "/scaler.v1.ScalerService/RestoreHPA"
+	ScalerService_ListDeployments_FullMethodName  = "/scaler.v1.ScalerService/ListDeployments"
+	ScalerService_WatchDeployments_FullMethodName = "/scaler.v1.ScalerService/WatchDeployments"
+)
+
+// ScalerServiceClient is the client API for ScalerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ScalerService reads and sets deployment replica counts. It shares its rules
+// (change policies, cooldown, history) with the /api/v1 REST API. Errors carry
+// a google.rpc.ErrorInfo whose reason is one of the REST API's problem reasons,
+// and a google.rpc.RetryInfo when the call may be retried later.
+type ScalerServiceClient interface {
+	// GetScale returns a deployment's replica count from the informer cache
+	GetScale(ctx context.Context, in *GetScaleRequest, opts ...grpc.CallOption) (*GetScaleResponse, error)
+	// SetScale sets a deployment's replica count
+	SetScale(ctx context.Context, in *SetScaleRequest, opts ...grpc.CallOption) (*SetScaleResponse, error)
+	// RestoreHPA restores the bounds of the HorizontalPodAutoscaler a SetScale
+	// with pin_hpa pinned, handing the deployment back to it
+	RestoreHPA(ctx context.Context, in *RestoreHPARequest, opts ...grpc.CallOption) (*RestoreHPAResponse, error)
+	// ListDeployments lists deployments in a namespace, or in all namespaces
+	ListDeployments(ctx context.Context, in *ListDeploymentsRequest, opts ...grpc.CallOption) (*ListDeploymentsResponse, error)
+	// WatchDeployments streams an ADDED event for each existing deployment, then
+	// an event for every change until the client cancels
+	WatchDeployments(ctx context.Context, in *WatchDeploymentsRequest, opts ...grpc.CallOption) (ScalerService_WatchDeploymentsClient, error)
+}
+
+type scalerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScalerServiceClient(cc grpc.ClientConnInterface) ScalerServiceClient {
+	return &scalerServiceClient{cc}
+}
+
+func (c *scalerServiceClient) GetScale(ctx context.Context, in *GetScaleRequest, opts ...grpc.CallOption) (*GetScaleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetScaleResponse)
+	err := c.cc.Invoke(ctx, ScalerService_GetScale_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scalerServiceClient) SetScale(ctx context.Context, in *SetScaleRequest, opts ...grpc.CallOption) (*SetScaleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetScaleResponse)
+	err := c.cc.Invoke(ctx, ScalerService_SetScale_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scalerServiceClient) RestoreHPA(ctx context.Context, in *RestoreHPARequest, opts ...grpc.CallOption) (*RestoreHPAResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreHPAResponse)
+	err := c.cc.Invoke(ctx, ScalerService_RestoreHPA_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scalerServiceClient) ListDeployments(ctx context.Context, in *ListDeploymentsRequest, opts ...grpc.CallOption) (*ListDeploymentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDeploymentsResponse)
+	err := c.cc.Invoke(ctx, ScalerService_ListDeployments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scalerServiceClient) WatchDeployments(ctx context.Context, in *WatchDeploymentsRequest, opts ...grpc.CallOption) (ScalerService_WatchDeploymentsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ScalerService_ServiceDesc.Streams[0], ScalerService_WatchDeployments_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &scalerServiceWatchDeploymentsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ScalerService_WatchDeploymentsClient interface {
+	Recv() (*WatchDeploymentsResponse, error)
+	grpc.ClientStream
+}
+
+type scalerServiceWatchDeploymentsClient struct {
+	grpc.ClientStream
+}
+
+func (x *scalerServiceWatchDeploymentsClient) Recv() (*WatchDeploymentsResponse, error) {
+	m := new(WatchDeploymentsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ScalerServiceServer is the server API for ScalerService service.
+// All implementations must embed UnimplementedScalerServiceServer
+// for forward compatibility
+//
+// ScalerService reads and sets deployment replica counts. It shares its rules
+// (change policies, cooldown, history) with the /api/v1 REST API. Errors carry
+// a google.rpc.ErrorInfo whose reason is one of the REST API's problem reasons,
+// and a google.rpc.RetryInfo when the call may be retried later.
+type ScalerServiceServer interface {
+	// GetScale returns a deployment's replica count from the informer cache
+	GetScale(context.Context, *GetScaleRequest) (*GetScaleResponse, error)
+	// SetScale sets a deployment's replica count
+	SetScale(context.Context, *SetScaleRequest) (*SetScaleResponse, error)
+	// RestoreHPA restores the bounds of the HorizontalPodAutoscaler a SetScale
+	// with pin_hpa pinned, handing the deployment back to it
+	RestoreHPA(context.Context, *RestoreHPARequest) (*RestoreHPAResponse, error)
+	// ListDeployments lists deployments in a namespace, or in all namespaces
+	ListDeployments(context.Context, *ListDeploymentsRequest) (*ListDeploymentsResponse, error)
+	// WatchDeployments streams an ADDED event for each existing deployment, then
+	// an event for every change until the client cancels
+	WatchDeployments(*WatchDeploymentsRequest, ScalerService_WatchDeploymentsServer) error
+	mustEmbedUnimplementedScalerServiceServer()
+}
+
+// UnimplementedScalerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedScalerServiceServer struct {
+}
+
+func (UnimplementedScalerServiceServer) GetScale(context.Context, *GetScaleRequest) (*GetScaleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetScale not implemented")
+}
+func (UnimplementedScalerServiceServer) SetScale(context.Context, *SetScaleRequest) (*SetScaleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetScale not implemented")
+}
+func (UnimplementedScalerServiceServer) RestoreHPA(context.Context, *RestoreHPARequest) (*RestoreHPAResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreHPA not implemented")
+}
+func (UnimplementedScalerServiceServer) ListDeployments(context.Context, *ListDeploymentsRequest) (*ListDeploymentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeployments not implemented")
+}
+func (UnimplementedScalerServiceServer) WatchDeployments(*WatchDeploymentsRequest, ScalerService_WatchDeploymentsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDeployments not implemented")
+}
+func (UnimplementedScalerServiceServer) mustEmbedUnimplementedScalerServiceServer() {}
+
+// UnsafeScalerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScalerServiceServer will
+// result in compilation errors.
+type UnsafeScalerServiceServer interface {
+	mustEmbedUnimplementedScalerServiceServer()
+}
+
+func RegisterScalerServiceServer(s grpc.ServiceRegistrar, srv ScalerServiceServer) {
+	s.RegisterService(&ScalerService_ServiceDesc, srv)
+}
+
+func _ScalerService_GetScale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetScaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScalerServiceServer).GetScale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScalerService_GetScale_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScalerServiceServer).GetScale(ctx, req.(*GetScaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScalerService_SetScale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetScaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScalerServiceServer).SetScale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScalerService_SetScale_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScalerServiceServer).SetScale(ctx, req.(*SetScaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScalerService_RestoreHPA_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreHPARequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScalerServiceServer).RestoreHPA(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScalerService_RestoreHPA_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScalerServiceServer).RestoreHPA(ctx, req.(*RestoreHPARequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScalerService_ListDeployments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeploymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScalerServiceServer).ListDeployments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ScalerService_ListDeployments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScalerServiceServer).ListDeployments(ctx, req.(*ListDeploymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ScalerService_WatchDeployments_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDeploymentsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ScalerServiceServer).WatchDeployments(m, &scalerServiceWatchDeploymentsServer{ServerStream: stream})
+}
+
+type ScalerService_WatchDeploymentsServer interface {
+	Send(*WatchDeploymentsResponse) error
+	grpc.ServerStream
+}
+
+type scalerServiceWatchDeploymentsServer struct {
+	grpc.ServerStream
+}
+
+func (x *scalerServiceWatchDeploymentsServer) Send(m *WatchDeploymentsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ScalerService_ServiceDesc is the grpc.ServiceDesc for ScalerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ScalerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "scaler.v1.ScalerService",
+	HandlerType: (*ScalerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetScale",
+			Handler:    _ScalerService_GetScale_Handler,
+		},
+		{
+			MethodName: "SetScale",
+			Handler:    _ScalerService_SetScale_Handler,
+		},
+		{
+			MethodName: "RestoreHPA",
+			Handler:    _ScalerService_RestoreHPA_Handler,
+		},
+		{
+			MethodName: "ListDeployments",
+			Handler:    _ScalerService_ListDeployments_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDeployments",
+			Handler:       _ScalerService_WatchDeployments_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "scaler/v1/scaler.proto",
+}
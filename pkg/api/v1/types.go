@@ -0,0 +1,232 @@
+// Package v1 holds the request and response bodies of the /api/v1 REST API
+package v1
+
+import "time"
+
+// Scale is the replica count of a deployment
+type Scale struct {
+	Namespace       string `json:"namespace"`
+	Name            string `json:"name"`
+	Replicas        int32  `json:"replicas"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// TimedOut is set on responses to a wait, reporting whether the timeout
+	// expired before the deployment met the wait's conditions
+	TimedOut *bool `json:"timedOut,omitempty"`
+	// HPA is the HorizontalPodAutoscaler managing the replica count, if any
+	HPA *HorizontalPodAutoscaler `json:"hpa,omitempty"`
+	// Forced lists the checks a scale with force=true overrode, such as
+	// "cooldown" or "PodDisruptionBudget web"
+	Forced []string `json:"forced,omitempty"`
+	// Warnings describe problems the scale was allowed despite, such as the
+	// ResourceQuotas its new pods exceed when quotas only warn
+	Warnings []string `json:"warnings,omitempty"`
+	// CostDelta is the estimated change in cost of a scale, when a price table
+	// is configured
+	CostDelta *Cost `json:"costDelta,omitempty"`
+}
+
+// HorizontalPodAutoscaler reports the bounds and status of the HPA scaling a
+// deployment
+type HorizontalPodAutoscaler struct {
+	Name            string `json:"name"`
+	MinReplicas     int32  `json:"minReplicas"`
+	MaxReplicas     int32  `json:"maxReplicas"`
+	CurrentReplicas int32  `json:"currentReplicas"`
+	DesiredReplicas int32  `json:"desiredReplicas"`
+	// PinnedFrom holds the bounds the HPA had before a scale with mode=hpa
+	// pinned them, until they are restored
+	PinnedFrom *HPABounds `json:"pinnedFrom,omitempty"`
+}
+
+// HPABounds are the minimum and maximum replica counts of an HPA
+type HPABounds struct {
+	MinReplicas int32 `json:"minReplicas"`
+	MaxReplicas int32 `json:"maxReplicas"`
+}
+
+// ScaleRequest is the body of a scale update
+type ScaleRequest struct {
+	Replicas int32  `json:"replicas"`
+	Reason   string `json:"reason,omitempty"`
+	Ticket   string `json:"ticket,omitempty"`
+}
+
+// Deployment summarises a deployment in a list
+type Deployment struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Replicas  int32  `json:"replicas"`
+}
+
+// DeploymentList is a list of deployments ordered by namespace and name
+type DeploymentList struct {
+	Items []Deployment `json:"items"`
+}
+
+// ScaleHistoryEntry records a single successful scale operation
+type ScaleHistoryEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Actor       string    `json:"actor"`
+	OldReplicas int32     `json:"oldReplicas"`
+	NewReplicas int32     `json:"newReplicas"`
+	Reason      string    `json:"reason,omitempty"`
+	Ticket      string    `json:"ticket,omitempty"`
+	RequestID   string    `json:"requestId,omitempty"`
+}
+
+// ScaleHistory is a page of a deployment's scale history, newest entry first.
+// Continue is passed back as the continue query parameter to fetch the next page.
+type ScaleHistory struct {
+	Entries  []ScaleHistoryEntry `json:"entries"`
+	Continue string              `json:"continue,omitempty"`
+}
+
+// Cost is an estimated price per hour and per month of 730 hours
+type Cost struct {
+	Currency string  `json:"currency"`
+	Hourly   float64 `json:"hourly"`
+	Monthly  float64 `json:"monthly"`
+}
+
+// DeploymentCost estimates what a deployment's pods cost from their CPU and
+// memory requests, at the prices of the node pool they run in
+type DeploymentCost struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Pool names the price table pool pricing the pods, "default" if none does
+	Pool       string `json:"pool"`
+	Replicas   int32  `json:"replicas"`
+	PerReplica Cost   `json:"perReplica"`
+	Current    Cost   `json:"current"`
+	// TargetReplicas, Target and Delta estimate a change to the count given by
+	// the replicas query parameter
+	TargetReplicas *int32 `json:"targetReplicas,omitempty"`
+	Target         *Cost  `json:"target,omitempty"`
+	Delta          *Cost  `json:"delta,omitempty"`
+}
+
+// NamespaceCost sums the estimated cost of a namespace's deployments, ordered by
+// name
+type NamespaceCost struct {
+	Namespace   string           `json:"namespace"`
+	Total       Cost             `json:"total"`
+	Deployments []DeploymentCost `json:"deployments"`
+}
+
+// Cluster is a Kubernetes cluster the scaler serves
+type Cluster struct {
+	Name string `json:"name"`
+	// Local marks the cluster the scaler runs in, served by the routes without
+	// a /clusters/{cluster} prefix
+	Local bool `json:"local"`
+	// Synced reports whether the scaler has filled its cache of the cluster's
+	// deployments; its routes answer 503 until then
+	Synced bool `json:"synced"`
+}
+
+// ClusterList is the list of clusters ordered by name
+type ClusterList struct {
+	Items []Cluster `json:"items"`
+}
+
+// GlobalDeployment is a deployment in one of the clusters
+type GlobalDeployment struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Replicas  int32  `json:"replicas"`
+}
+
+// GlobalDeploymentList is the list of matching deployments across every cluster,
+// ordered by cluster, namespace and name
+type GlobalDeploymentList struct {
+	Items []GlobalDeployment `json:"items"`
+	// Partial is set when some clusters couldn't be queried, each listed in Errors
+	Partial bool           `json:"partial"`
+	Errors  []ClusterError `json:"errors,omitempty"`
+}
+
+// GlobalScaleRequest is the body of a scale update across several clusters
+type GlobalScaleRequest struct {
+	Replicas int32  `json:"replicas"`
+	Reason   string `json:"reason,omitempty"`
+	Ticket   string `json:"ticket,omitempty"`
+	// Clusters names the clusters to scale the deployment in
+	Clusters []string `json:"clusters"`
+}
+
+// ClusterScale is the replica count of a deployment in one of the clusters
+type ClusterScale struct {
+	Cluster         string `json:"cluster"`
+	Namespace       string `json:"namespace"`
+	Name            string `json:"name"`
+	Replicas        int32  `json:"replicas"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// Forced lists the checks a scale with force=true overrode in this cluster
+	Forced []string `json:"forced,omitempty"`
+	// Warnings describe problems the scale was allowed despite in this cluster
+	Warnings []string `json:"warnings,omitempty"`
+	// CostDelta is the estimated change in cost of the scale in this cluster,
+	// when it has a price table
+	CostDelta *Cost `json:"costDelta,omitempty"`
+}
+
+// GlobalScale is the result of a scale update across several clusters, ordered
+// by cluster
+type GlobalScale struct {
+	Items []ClusterScale `json:"items"`
+	// Partial is set when the deployment couldn't be scaled in some of the
+	// clusters, each listed in Errors
+	Partial bool           `json:"partial"`
+	Errors  []ClusterError `json:"errors,omitempty"`
+}
+
+// ClusterError reports why a global request failed in one cluster, with the
+// status, reason and detail the cluster's own route would have answered
+type ClusterError struct {
+	Cluster string `json:"cluster"`
+	Status  int    `json:"status"`
+	Reason  Reason `json:"reason"`
+	Detail  string `json:"detail"`
+}
+
+// Status is the body of a successful health check
+type Status struct {
+	Status string `json:"status"`
+}
+
+// Reason is a stable, machine-readable cause of an error
+type Reason string
+
+// Error reasons. Clients should branch on these rather than on titles or details.
+const (
+	// ReasonNotFound means the deployment doesn't exist
+	ReasonNotFound Reason = "NotFound"
+	// ReasonConflict means the deployment changed concurrently or the change
+	// conflicts with its current state
+	ReasonConflict Reason = "Conflict"
+	// ReasonForbidden means the client, or the scaler itself, may not make the change
+	ReasonForbidden Reason = "Forbidden"
+	// ReasonValidationFailed means the request was malformed or broke a policy
+	ReasonValidationFailed Reason = "ValidationFailed"
+	// ReasonUpstreamUnavailable means the Kubernetes API failed or couldn't be reached
+	ReasonUpstreamUnavailable Reason = "UpstreamUnavailable"
+	// ReasonTooManyRequests means a rate limit or cooldown applies; see Retry-After
+	ReasonTooManyRequests Reason = "TooManyRequests"
+	// ReasonInternalError means the scaler itself failed
+	ReasonInternalError Reason = "InternalError"
+)
+
+// ProblemContentType is the media type of error responses
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of every error response, an RFC 7807 problem details object.
+// Instance is the request ID, for correlating the error with the server's logs.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Reason   Reason `json:"reason"`
+}
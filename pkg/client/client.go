@@ -0,0 +1,412 @@
+// Package client is a Go client for the k8s-deployment-scaler REST API.
+//
+// A Client is safe for concurrent use. Error responses are returned as *Error,
+// whose Reason is stable across releases; requests rejected with 429 or 503
+// are retried with backoff according to the client's RetryPolicy.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+)
+
+// Client calls the scaler's REST API
+type Client struct {
+	server *url.URL
+	http   *http.Client
+	retry  RetryPolicy
+	// prefix addresses one of the server's clusters, as set by Cluster
+	prefix string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with httpClient instead of one built from the
+// TLS config given to New
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.http = httpClient
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// New returns a client for the server at the given http or https URL. tlsConfig
+// holds the client certificate and the CAs trusted to sign the server's; nil
+// uses the system defaults.
+func New(server string, tlsConfig *tls.Config, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(server, "/"))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("server must be an http or https URL, got %q", server)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c := &Client{
+		server: u,
+		http:   &http.Client{Transport: transport},
+		retry:  DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// NewFromFiles returns a client for the server authenticating with the PEM
+// certificate and key, and trusting the server certificates signed by the CA
+// in caFile. Empty paths are skipped, as by TLSConfigFromFiles.
+func NewFromFiles(server, certFile, keyFile, caFile string, opts ...Option) (*Client, error) {
+	tlsConfig, err := TLSConfigFromFiles(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return New(server, tlsConfig, opts...)
+}
+
+// TLSConfigFromFiles loads a client certificate and key and a CA certificate
+// from PEM files. Without a certificate and key no client certificate is sent,
+// and without a CA the system roots are trusted.
+func TLSConfigFromFiles(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS13}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading CA certificate: %v", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+	return config, nil
+}
+
+// Cluster returns a client for the named cluster among those the server serves,
+// sharing c's connections. The methods of c address the server's local cluster.
+func (c *Client) Cluster(name string) *Client {
+	cluster := *c
+	cluster.prefix = "/clusters/" + url.PathEscape(name)
+	return &cluster
+}
+
+// ListClusters lists the clusters the server serves
+func (c *Client) ListClusters(ctx context.Context) (*apiv1.ClusterList, error) {
+	var list apiv1.ClusterList
+	if err := c.root().do(ctx, http.MethodGet, "/clusters", nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// root returns the client without its cluster, for the server-wide routes
+func (c *Client) root() *Client {
+	root := *c
+	root.prefix = ""
+	return &root
+}
+
+// Health checks that the server can reach the Kubernetes API
+func (c *Client) Health(ctx context.Context) (*apiv1.Status, error) {
+	var status apiv1.Status
+	if err := c.do(ctx, http.MethodGet, "/healthz", nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// OpenAPI returns the server's OpenAPI document
+func (c *Client) OpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var document json.RawMessage
+	if err := c.root().do(ctx, http.MethodGet, "/api/v1/openapi.json", nil, nil, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// ListDeployments lists the deployments in namespace, or in every namespace when
+// it's empty
+func (c *Client) ListDeployments(ctx context.Context, namespace string) (*apiv1.DeploymentList, error) {
+	path := "/api/v1/deployments"
+	if namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/deployments"
+	}
+	var list apiv1.DeploymentList
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetScale returns a deployment's replica count
+func (c *Client) GetScale(ctx context.Context, namespace, name string) (*apiv1.Scale, error) {
+	var scale apiv1.Scale
+	if err := c.do(ctx, http.MethodGet, deploymentPath(namespace, name)+"/scale", nil, nil, &scale); err != nil {
+		return nil, err
+	}
+	return &scale, nil
+}
+
+// WaitOptions are the conditions WaitForScale waits for. When both are set,
+// both must hold.
+type WaitOptions struct {
+	// ResourceVersion waits for the deployment to change from this version
+	ResourceVersion string
+	// Until waits for the deployment to have this many replicas
+	Until *int32
+	// Timeout is how long the server waits, at most 5 minutes; zero uses its
+	// default of 30 seconds
+	Timeout time.Duration
+}
+
+// WaitForScale long-polls until the deployment meets the conditions or the
+// timeout expires, which the returned scale's TimedOut reports
+func (c *Client) WaitForScale(ctx context.Context, namespace, name string, opts WaitOptions) (*apiv1.Scale, error) {
+	if opts.ResourceVersion == "" && opts.Until == nil {
+		return nil, fmt.Errorf("WaitOptions must set ResourceVersion or Until")
+	}
+	query := url.Values{}
+	if opts.ResourceVersion != "" {
+		query.Set("waitForChange", opts.ResourceVersion)
+	}
+	if opts.Until != nil {
+		query.Set("until", strconv.Itoa(int(*opts.Until)))
+	}
+	if opts.Timeout > 0 {
+		query.Set("timeout", opts.Timeout.String())
+	}
+	var scale apiv1.Scale
+	if err := c.do(ctx, http.MethodGet, deploymentPath(namespace, name)+"/scale", query, nil, &scale); err != nil {
+		return nil, err
+	}
+	return &scale, nil
+}
+
+// SetScaleOptions modify a scale update
+type SetScaleOptions struct {
+	// Force scales during the cooldown or past a PodDisruptionBudget; only the
+	// server's force identities for the check overridden may
+	Force bool
+	// PinHPA scales a deployment managed by a HorizontalPodAutoscaler by pinning
+	// the HPA's bounds to the requested count, which is otherwise refused
+	PinHPA bool
+}
+
+// query returns the query parameters setting the options
+func (o SetScaleOptions) query() url.Values {
+	query := url.Values{}
+	if o.Force {
+		query.Set("force", "true")
+	}
+	if o.PinHPA {
+		query.Set("mode", "hpa")
+	}
+	return query
+}
+
+// SetScale sets a deployment's replica count
+func (c *Client) SetScale(ctx context.Context, namespace, name string, req apiv1.ScaleRequest, opts SetScaleOptions) (*apiv1.Scale, error) {
+	var scale apiv1.Scale
+	if err := c.do(ctx, http.MethodPut, deploymentPath(namespace, name)+"/scale", opts.query(), req, &scale); err != nil {
+		return nil, err
+	}
+	return &scale, nil
+}
+
+// RestoreHPA restores the bounds of the HorizontalPodAutoscaler pinned by a
+// scale with SetScaleOptions.PinHPA, handing the deployment back to it
+func (c *Client) RestoreHPA(ctx context.Context, namespace, name string) (*apiv1.HorizontalPodAutoscaler, error) {
+	var hpa apiv1.HorizontalPodAutoscaler
+	if err := c.do(ctx, http.MethodPost, deploymentPath(namespace, name)+"/hpa/restore", nil, nil, &hpa); err != nil {
+		return nil, err
+	}
+	return &hpa, nil
+}
+
+// HistoryOptions select a page of scale history
+type HistoryOptions struct {
+	// Limit is the most entries to return; zero uses the server's default
+	Limit int
+	// Continue is the previous page's ScaleHistory.Continue
+	Continue string
+}
+
+// GetScaleHistory returns a page of a deployment's scale history, newest entry first
+func (c *Client) GetScaleHistory(ctx context.Context, namespace, name string, opts HistoryOptions) (*apiv1.ScaleHistory, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Continue != "" {
+		query.Set("continue", opts.Continue)
+	}
+	var history apiv1.ScaleHistory
+	if err := c.do(ctx, http.MethodGet, deploymentPath(namespace, name)+"/history", query, nil, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// CostOptions select what GetDeploymentCost estimates
+type CostOptions struct {
+	// Replicas, when set, also estimates the cost at this many replicas and the
+	// change from the current count
+	Replicas *int32
+}
+
+// GetDeploymentCost estimates what a deployment's pods cost at the server's
+// prices. It fails with a NotFound error when the server has no price table.
+func (c *Client) GetDeploymentCost(ctx context.Context, namespace, name string, opts CostOptions) (*apiv1.DeploymentCost, error) {
+	query := url.Values{}
+	if opts.Replicas != nil {
+		query.Set("replicas", strconv.Itoa(int(*opts.Replicas)))
+	}
+	var estimate apiv1.DeploymentCost
+	if err := c.do(ctx, http.MethodGet, deploymentPath(namespace, name)+"/cost", query, nil, &estimate); err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+// GetNamespaceCost estimates the cost of every deployment in a namespace, and
+// their total
+func (c *Client) GetNamespaceCost(ctx context.Context, namespace string) (*apiv1.NamespaceCost, error) {
+	var estimate apiv1.NamespaceCost
+	if err := c.do(ctx, http.MethodGet, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/cost", nil, nil, &estimate); err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+// GlobalListOptions filter the deployments ListGlobalDeployments returns; empty
+// fields match every deployment
+type GlobalListOptions struct {
+	Name      string
+	Namespace string
+	// LabelSelector is a Kubernetes label selector, such as "app=web,tier!=cache"
+	LabelSelector string
+}
+
+// ListGlobalDeployments lists the matching deployments of every cluster the
+// server serves. Clusters that couldn't be queried set Partial and are listed in
+// Errors rather than failing the call.
+func (c *Client) ListGlobalDeployments(ctx context.Context, opts GlobalListOptions) (*apiv1.GlobalDeploymentList, error) {
+	query := url.Values{}
+	if opts.Name != "" {
+		query.Set("name", opts.Name)
+	}
+	if opts.Namespace != "" {
+		query.Set("namespace", opts.Namespace)
+	}
+	if opts.LabelSelector != "" {
+		query.Set("labelSelector", opts.LabelSelector)
+	}
+	var list apiv1.GlobalDeploymentList
+	if err := c.root().do(ctx, http.MethodGet, "/global/deployments", query, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// SetGlobalScale sets a deployment's replica count in each of the clusters
+// req names. Clusters where that fails set Partial and are listed in Errors;
+// the call only fails when none could be attempted.
+func (c *Client) SetGlobalScale(ctx context.Context, namespace, name string, req apiv1.GlobalScaleRequest, opts SetScaleOptions) (*apiv1.GlobalScale, error) {
+	path := "/global/namespaces/" + url.PathEscape(namespace) + "/deployments/" + url.PathEscape(name) + "/scale"
+	var scale apiv1.GlobalScale
+	if err := c.root().do(ctx, http.MethodPut, path, opts.query(), req, &scale); err != nil {
+		return nil, err
+	}
+	return &scale, nil
+}
+
+// do sends the request, retrying it as the retry policy allows, and decodes a
+// successful response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	u := *c.server
+	u.Path += c.prefix + path
+	u.RawQuery = query.Encode()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, method, u.String(), data, out)
+		delay, retry := c.retry.delay(attempt, err)
+		if !retry {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes a single attempt at the request
+func (c *Client) send(ctx context.Context, method, u string, data []byte, out interface{}) error {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %v", err)
+	}
+	return nil
+}
+
+// deploymentPath returns the API path of a deployment
+func deploymentPath(namespace, name string) string {
+	return "/api/v1/namespaces/" + url.PathEscape(namespace) + "/deployments/" + url.PathEscape(name)
+}
@@ -0,0 +1,423 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s-deployment-scaler/internal/config"
+	"k8s-deployment-scaler/internal/cost"
+	"k8s-deployment-scaler/internal/server"
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestHandler returns the real API handler serving the deployments
+func newTestHandler(t *testing.T, cfg *config.Config, deployments ...*appsv1.Deployment) http.Handler {
+	t.Helper()
+
+	fakeClientset := fake.NewSimpleClientset()
+	addScaleReactors(fakeClientset)
+	for _, deployment := range deployments {
+		if _, err := fakeClientset.AppsV1().Deployments(deployment.Namespace).Create(context.TODO(), deployment, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	factory := informers.NewSharedInformerFactory(fakeClientset, 0)
+	deploymentInformer := factory.Apps().V1().Deployments()
+	deploymentInformer.Informer()
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	var opts []server.Option
+	if cfg.PriceTableFile != "" {
+		prices, err := cost.LoadPriceTable(cfg.PriceTableFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, server.WithPriceTable(prices))
+	}
+
+	cfg.TLS.Enabled = false
+	srv, err := server.New(fakeClientset, deploymentInformer, cfg, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	return srv.Handler
+}
+
+// addScaleReactors serves the deployments/scale subresource from the stored
+// deployment and bumps its resourceVersion on every scale, as the API server does
+func addScaleReactors(fakeClientset *fake.Clientset) {
+	deploymentsResource := appsv1.SchemeGroupVersion.WithResource("deployments")
+
+	fakeClientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		scale := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		obj, err := fakeClientset.Tracker().Get(deploymentsResource, action.GetNamespace(), scale.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		deployment := obj.(*appsv1.Deployment).DeepCopy()
+		deployment.Spec.Replicas = &scale.Spec.Replicas
+		version, _ := strconv.Atoi(deployment.ResourceVersion)
+		deployment.ResourceVersion = strconv.Itoa(version + 1)
+		if err := fakeClientset.Tracker().Update(deploymentsResource, deployment, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		scale.ResourceVersion = deployment.ResourceVersion
+		return true, scale, nil
+	})
+}
+
+func newDeployment(namespace, name string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, ResourceVersion: "1"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+}
+
+// newTLSServer serves handler over mTLS and returns a client for it configured
+// from certificate files, as a service would be
+func newTLSServer(t *testing.T, handler http.Handler, opts ...Option) *Client {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "sdk"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	ts := httptest.NewUnstartedServer(handler)
+	ts.TLS = &tls.Config{ClientCAs: clientCAs, ClientAuth: tls.RequireAndVerifyClientCert}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	writePEM(t, certFile, "CERTIFICATE", certDER)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	writePEM(t, caFile, "CERTIFICATE", ts.Certificate().Raw)
+
+	c, err := NewFromFiles(ts.URL, certFile, keyFile, caFile, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	cfg := config.Default()
+	cfg.Scale.Cooldown = metav1.Duration{Duration: time.Hour}
+	cfg.PriceTableFile = filepath.Join(t.TempDir(), "prices.yaml")
+	if err := os.WriteFile(cfg.PriceTableFile, []byte("currency: USD\ndefault:\n  cpuHour: 0.04\n  memoryGiBHour: 0.005\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := newTLSServer(t, newTestHandler(t, cfg,
+		newDeployment("default", "web", 3),
+		newDeployment("batch", "jobs", 2),
+	))
+	ctx := context.Background()
+
+	status, err := c.Health(ctx)
+	if err != nil || status.Status != "OK" {
+		t.Fatalf("Health() = %v, %v", status, err)
+	}
+
+	document, err := c.OpenAPI(ctx)
+	if err != nil || len(document) == 0 {
+		t.Fatalf("OpenAPI() = %d bytes, %v", len(document), err)
+	}
+
+	list, err := c.ListDeployments(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 2 || list.Items[0].Name != "jobs" || list.Items[1].Name != "web" {
+		t.Errorf("ListDeployments() = %+v", list.Items)
+	}
+	list, err = c.ListDeployments(ctx, "batch")
+	if err != nil || len(list.Items) != 1 {
+		t.Errorf("ListDeployments(batch) = %+v, %v", list, err)
+	}
+
+	scale, err := c.GetScale(ctx, "default", "web")
+	if err != nil || scale.Replicas != 3 || scale.ResourceVersion != "1" {
+		t.Fatalf("GetScale() = %+v, %v", scale, err)
+	}
+
+	scale, err = c.SetScale(ctx, "default", "web", apiv1.ScaleRequest{Replicas: 5, Reason: "load test"}, SetScaleOptions{})
+	if err != nil || scale.Replicas != 5 {
+		t.Fatalf("SetScale() = %+v, %v", scale, err)
+	}
+
+	history, err := c.GetScaleHistory(ctx, "default", "web", HistoryOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Entries) != 1 || history.Entries[0].Actor != "sdk" || history.Entries[0].NewReplicas != 5 || history.Entries[0].Reason != "load test" {
+		t.Errorf("GetScaleHistory() = %+v", history.Entries)
+	}
+
+	target := int32(10)
+	estimate, err := c.GetDeploymentCost(ctx, "default", "web", CostOptions{Replicas: &target})
+	if err != nil || estimate.Pool != "default" || estimate.Replicas != 5 || estimate.TargetReplicas == nil || *estimate.TargetReplicas != 10 || estimate.Delta == nil {
+		t.Errorf("GetDeploymentCost() = %+v, %v", estimate, err)
+	}
+	namespaceCost, err := c.GetNamespaceCost(ctx, "batch")
+	if err != nil || len(namespaceCost.Deployments) != 1 || namespaceCost.Total.Currency != "USD" {
+		t.Errorf("GetNamespaceCost() = %+v, %v", namespaceCost, err)
+	}
+
+	until := int32(5)
+	scale, err = c.WaitForScale(ctx, "default", "web", WaitOptions{Until: &until, Timeout: time.Second})
+	if err != nil || scale.TimedOut == nil || *scale.TimedOut {
+		t.Errorf("WaitForScale(until 5) = %+v, %v", scale, err)
+	}
+	scale, err = c.WaitForScale(ctx, "default", "web", WaitOptions{ResourceVersion: scale.ResourceVersion, Timeout: 100 * time.Millisecond})
+	if err != nil || scale.TimedOut == nil || !*scale.TimedOut {
+		t.Errorf("WaitForScale(unchanged) = %+v, %v", scale, err)
+	}
+
+	// The cooldown's Retry-After is beyond the retry policy, so the error is returned at once
+	start := time.Now()
+	_, err = c.SetScale(ctx, "default", "web", apiv1.ScaleRequest{Replicas: 4}, SetScaleOptions{})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || !IsTooManyRequests(err) {
+		t.Fatalf("SetScale() during the cooldown = %v, want TooManyRequests", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter <= 59*time.Minute || apiErr.Instance == "" {
+		t.Errorf("cooldown error = %+v", apiErr)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cooldown error took %v, want no retries", elapsed)
+	}
+
+	_, err = c.SetScale(ctx, "default", "web", apiv1.ScaleRequest{Replicas: 4}, SetScaleOptions{Force: true})
+	if Reason(err) != apiv1.ReasonForbidden {
+		t.Errorf("SetScale(force) = %v, want Forbidden", err)
+	}
+
+	clusters, err := c.ListClusters(ctx)
+	if err != nil || len(clusters.Items) != 1 || !clusters.Items[0].Local {
+		t.Errorf("ListClusters() = %+v, %v", clusters, err)
+	}
+	scale, err = c.Cluster(clusters.Items[0].Name).GetScale(ctx, "default", "web")
+	if err != nil || scale.Replicas != 5 {
+		t.Errorf("GetScale() in the local cluster = %+v, %v", scale, err)
+	}
+	_, err = c.Cluster("north").GetScale(ctx, "default", "web")
+	if !IsNotFound(err) {
+		t.Errorf("GetScale() in an unknown cluster = %v, want NotFound", err)
+	}
+
+	global, err := c.ListGlobalDeployments(ctx, GlobalListOptions{Name: "web"})
+	if err != nil || global.Partial || len(global.Items) != 1 || global.Items[0].Cluster != clusters.Items[0].Name {
+		t.Errorf("ListGlobalDeployments() = %+v, %v", global, err)
+	}
+	globalScale, err := c.SetGlobalScale(ctx, "batch", "jobs", apiv1.GlobalScaleRequest{Replicas: 1, Clusters: []string{"local"}}, SetScaleOptions{})
+	if err != nil || globalScale.Partial || len(globalScale.Items) != 1 || globalScale.Items[0].Replicas != 1 {
+		t.Errorf("SetGlobalScale() = %+v, %v", globalScale, err)
+	}
+	_, err = c.SetGlobalScale(ctx, "batch", "jobs", apiv1.GlobalScaleRequest{Replicas: 1, Clusters: []string{"north"}}, SetScaleOptions{})
+	if !IsNotFound(err) {
+		t.Errorf("SetGlobalScale() in an unknown cluster = %v, want NotFound", err)
+	}
+
+	_, err = c.GetScale(ctx, "default", "missing")
+	if !IsNotFound(err) {
+		t.Errorf("GetScale(missing) = %v, want NotFound", err)
+	}
+
+	_, err = c.SetScale(ctx, "batch", "jobs", apiv1.ScaleRequest{Replicas: -1}, SetScaleOptions{})
+	if Reason(err) != apiv1.ReasonValidationFailed {
+		t.Errorf("SetScale(-1) = %v, want ValidationFailed", err)
+	}
+
+	// HPAs aren't watched here, so there is none to restore
+	_, err = c.RestoreHPA(ctx, "default", "web")
+	if !IsNotFound(err) {
+		t.Errorf("RestoreHPA() = %v, want NotFound", err)
+	}
+}
+
+// flaky answers the first failures requests with status before passing the rest to handler
+func flaky(status, failures int, handler http.Handler) (http.Handler, *atomic.Int32) {
+	var requests atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}), &requests
+}
+
+func TestClientRetries(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		status       int
+		failures     int
+		wantRequests int32
+		wantStatus   int
+	}{
+		{"Recovers from 503", http.StatusServiceUnavailable, 2, 3, 0},
+		{"Recovers from 429", http.StatusTooManyRequests, 1, 2, 0},
+		{"Gives up after MaxAttempts", http.StatusServiceUnavailable, 5, 3, http.StatusServiceUnavailable},
+		{"Doesn't retry other errors", http.StatusBadGateway, 1, 1, http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, requests := flaky(tt.status, tt.failures, newTestHandler(t, config.Default(), newDeployment("default", "web", 3)))
+			c := newTLSServer(t, handler, WithRetryPolicy(policy))
+
+			// A PUT's body is sent again on each attempt
+			_, err := c.SetScale(context.Background(), "default", "web", apiv1.ScaleRequest{Replicas: 4}, SetScaleOptions{})
+			var apiErr *Error
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Errorf("SetScale() = %v", err)
+			case tt.wantStatus != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus):
+				t.Errorf("SetScale() = %v, want status %d", err, tt.wantStatus)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	handler, _ := flaky(http.StatusServiceUnavailable, 100, http.NotFoundHandler())
+	c := newTLSServer(t, handler, WithRetryPolicy(RetryPolicy{MaxAttempts: 100, InitialBackoff: time.Hour, MaxBackoff: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := c.Health(ctx)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Health() = %v, want the last 503", err)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	unavailable := &Error{StatusCode: http.StatusServiceUnavailable}
+
+	tests := []struct {
+		name      string
+		attempt   int
+		err       error
+		wantMin   time.Duration
+		wantMax   time.Duration
+		wantRetry bool
+	}{
+		{"First retry", 1, unavailable, 50 * time.Millisecond, 100 * time.Millisecond, true},
+		{"Doubles", 3, unavailable, 200 * time.Millisecond, 400 * time.Millisecond, true},
+		{"Capped", 8, unavailable, 500 * time.Millisecond, time.Second, true},
+		{"Honours Retry-After", 1, &Error{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second}, time.Second, time.Second, true},
+		{"Retry-After beyond MaxBackoff", 1, &Error{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}, 0, time.Minute, false},
+		{"Last attempt", 10, unavailable, 0, 0, false},
+		{"Not found", 1, &Error{StatusCode: http.StatusNotFound}, 0, 0, false},
+		{"Transport error", 1, errors.New("connection refused"), 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		delay, retry := policy.delay(tt.attempt, tt.err)
+		if retry != tt.wantRetry || (retry && (delay < tt.wantMin || delay > tt.wantMax)) {
+			t.Errorf("%s: delay() = %v, %v; want %v-%v, %v", tt.name, delay, retry, tt.wantMin, tt.wantMax, tt.wantRetry)
+		}
+	}
+}
+
+func TestNewRejectsBadURL(t *testing.T) {
+	t.Parallel()
+
+	for _, server := range []string{"", "localhost:8443", "ftp://localhost"} {
+		if _, err := New(server, nil); err == nil {
+			t.Errorf("New(%q) succeeded", server)
+		}
+	}
+}
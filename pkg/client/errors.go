@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	apiv1 "k8s-deployment-scaler/pkg/api/v1"
+)
+
+// Error is an error response from the server
+type Error struct {
+	apiv1.Problem
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// RetryAfter is the wait the server asked for with Retry-After, if any
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = e.Title
+	}
+	if e.Reason == "" {
+		return message
+	}
+	return fmt.Sprintf("%s (%s)", message, e.Reason)
+}
+
+// Reason returns the reason of err when it's an *Error, and "" otherwise
+func Reason(err error) apiv1.Reason {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Reason
+	}
+	return ""
+}
+
+// IsNotFound reports whether err means the deployment doesn't exist
+func IsNotFound(err error) bool {
+	return Reason(err) == apiv1.ReasonNotFound
+}
+
+// IsConflict reports whether err means the deployment changed concurrently
+func IsConflict(err error) bool {
+	return Reason(err) == apiv1.ReasonConflict
+}
+
+// IsTooManyRequests reports whether err means a rate limit or cooldown applies
+func IsTooManyRequests(err error) bool {
+	return Reason(err) == apiv1.ReasonTooManyRequests
+}
+
+// responseError reads an error response. Responses that aren't problem details,
+// as from a proxy in front of the server, keep just their status.
+func responseError(resp *http.Response) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr.Problem); err != nil || apiErr.Status == 0 {
+		apiErr.Problem = apiv1.Problem{Title: resp.Status, Status: resp.StatusCode}
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides how requests answered with 429 Too Many Requests or 503
+// Service Unavailable are retried. Other errors are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the most times a request is sent; 1 or less disables retries
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubling for each one after
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts. A response whose Retry-After
+	// exceeds it, as during a scale cooldown, is returned rather than retried.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy sends a request up to four times over about two seconds
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// delay returns how long to wait before the next attempt, and false when err,
+// the result of the given attempt, should be returned instead
+func (p RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	var apiErr *Error
+	if attempt >= p.MaxAttempts || !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests && apiErr.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	if apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, apiErr.RetryAfter <= p.MaxBackoff
+	}
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	// Jitter over the upper half, so concurrent clients spread out
+	return backoff/2 + rand.N(backoff/2+1), true
+}